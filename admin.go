@@ -0,0 +1,275 @@
+package main
+
+import (
+	"booking-api/config"
+	"booking-api/models"
+	"booking-api/services"
+	"booking-api/utils"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// newFlagSet returns the flags of a subcommand, printing usage in its help.
+func newFlagSet(name, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "uso: booking-api %s\n", usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags parses args and, when the command must not go on, returns its
+// exit code: 0 after -h and 2 after a usage error.
+func parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0, false
+	}
+	if err != nil {
+		return 2, false
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(flags.Output(), "argumento inesperado %q\n", flags.Arg(0))
+		flags.Usage()
+		return 2, false
+	}
+	return 0, true
+}
+
+// require reports the required flags left empty, with the usage.
+func require(flags *flag.FlagSet, names ...string) bool {
+	for _, name := range names {
+		if flags.Lookup(name).Value.String() == "" {
+			fmt.Fprintf(flags.Output(), "falta la opción -%s\n", name)
+			flags.Usage()
+			return false
+		}
+	}
+	return true
+}
+
+func fail(err error) int {
+	fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+	return 1
+}
+
+// passwordOrRandom returns password, or a random one when it is empty. The
+// second result tells whether it was generated and must be shown.
+func passwordOrRandom(password string) (string, bool, error) {
+	if password != "" {
+		return password, false, nil
+	}
+	token, err := utils.RandomToken()
+	if err != nil {
+		return "", false, err
+	}
+	return token[:16], true, nil
+}
+
+// seedResources are created by "seed", with their booking rules.
+var seedResources = []struct {
+	resource models.Resource
+	rules    map[string]string
+}{
+	{
+		resource: models.Resource{Name: "Sala de reuniones", PostBufferMinutes: 10},
+		rules: map[string]string{
+			services.RuleOpeningHours:    "08:00-20:00",
+			services.RuleMaxDuration:     "120",
+			services.RuleAllowedWeekdays: "mon,tue,wed,thu,fri",
+		},
+	},
+	{
+		resource: models.Resource{Name: "Auditorio", PreBufferMinutes: 30, PostBufferMinutes: 30},
+		rules: map[string]string{
+			services.RuleOpeningHours:  "09:00-18:00",
+			services.RuleMinDuration:   "60",
+			services.RuleAdvanceWindow: "60",
+			services.RuleMinNotice:     "1440",
+		},
+	},
+	{resource: models.Resource{Name: "Puesto de trabajo 1"}},
+	{resource: models.Resource{Name: "Puesto de trabajo 2"}},
+}
+
+func runSeed(cfg config.Config, args []string) int {
+	flags := newFlagSet("seed", "seed")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	ctx := context.Background()
+	a := newApplication(cfg)
+	existing, err := a.resource.GetResources(ctx)
+	if err != nil {
+		return fail(err)
+	}
+	if len(existing) > 0 {
+		fmt.Printf("La base de datos ya tiene %d recursos, no se crea nada\n", len(existing))
+		return 0
+	}
+
+	created, err := seed(ctx, a)
+	if err != nil {
+		return fail(err)
+	}
+	for i, resource := range created {
+		fmt.Printf("✅ Recurso %d: %s (%d reglas)\n", resource.ID, resource.Name, len(seedResources[i].rules))
+	}
+	return 0
+}
+
+// seed creates seedResources and returns them, in the same order.
+func seed(ctx context.Context, a *application) ([]models.Resource, error) {
+	created := make([]models.Resource, 0, len(seedResources))
+	for _, seed := range seedResources {
+		resource := seed.resource
+		if err := a.resource.CreateResource(ctx, &resource); err != nil {
+			return nil, err
+		}
+		for kind, value := range seed.rules {
+			if err := a.resource.AddRule(ctx, &models.BookingRule{ResourceID: resource.ID, Kind: kind, Value: value}); err != nil {
+				return nil, err
+			}
+		}
+		created = append(created, resource)
+	}
+	return created, nil
+}
+
+func runCreateAdmin(cfg config.Config, args []string) int {
+	flags := newFlagSet("create-admin", "create-admin -email <email> [-name <nombre>] [-password <contraseña>]")
+	email := flags.String("email", "", "email del administrador")
+	name := flags.String("name", "Administrador", "nombre del administrador")
+	password := flags.String("password", "", "contraseña; si se omite se genera una")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if !require(flags, "email") {
+		return 2
+	}
+
+	secret, generated, err := passwordOrRandom(*password)
+	if err != nil {
+		return fail(err)
+	}
+	user := &models.User{Name: *name, Email: *email, Password: secret}
+	if err := newApplication(cfg).auth.CreateAdmin(context.Background(), user); err != nil {
+		return fail(err)
+	}
+
+	fmt.Printf("✅ Administrador %s creado con id %d\n", user.Email, user.ID)
+	if generated {
+		fmt.Printf("Contraseña: %s\n", secret)
+	}
+	return 0
+}
+
+func runResetPassword(cfg config.Config, args []string) int {
+	flags := newFlagSet("reset-password", "reset-password -email <email> [-password <contraseña>]")
+	email := flags.String("email", "", "email del usuario")
+	password := flags.String("password", "", "nueva contraseña; si se omite se genera una")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if !require(flags, "email") {
+		return 2
+	}
+
+	secret, generated, err := passwordOrRandom(*password)
+	if err != nil {
+		return fail(err)
+	}
+	if err := newApplication(cfg).auth.ResetPassword(context.Background(), *email, secret); err != nil {
+		return fail(err)
+	}
+
+	fmt.Printf("✅ Contraseña de %s actualizada\n", *email)
+	if generated {
+		fmt.Printf("Contraseña: %s\n", secret)
+	}
+	return 0
+}
+
+func runExportReservations(cfg config.Config, args []string) int {
+	flags := newFlagSet("export-reservations", "export-reservations -from <AAAA-MM-DD> -to <AAAA-MM-DD> [-user <email>] [-output <archivo>]")
+	from := flags.String("from", "", "primer día, incluido")
+	to := flags.String("to", "", "último día, incluido")
+	email := flags.String("user", "", "exporta solo las reservas de este usuario")
+	output := flags.String("output", "-", "archivo de salida; - para la salida estándar")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if !require(flags, "from", "to") {
+		return 2
+	}
+
+	ctx := context.Background()
+	a := newApplication(cfg)
+	var userID uint
+	if *email != "" {
+		user, err := a.users.FindByEmail(ctx, *email)
+		if err != nil {
+			return fail(err)
+		}
+		userID = user.ID
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fail(err)
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := a.reservationCSV.Export(ctx, w, *from, *to, userID); err != nil {
+		return fail(err)
+	}
+	if *output != "-" {
+		fmt.Printf("✅ Reservas exportadas a %s\n", *output)
+	}
+	return 0
+}
+
+func runPurge(cfg config.Config, args []string) int {
+	flags := newFlagSet("purge", "purge -before <AAAA-MM-DD> [-dry-run]")
+	before := flags.String("before", "", "borra lo anterior a este día, que no se incluye")
+	dryRun := flags.Bool("dry-run", false, "solo cuenta lo que se borraría")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+	if !require(flags, "before") {
+		return 2
+	}
+
+	report, err := newApplication(cfg).purge.Purge(context.Background(), *before, *dryRun)
+	if err != nil {
+		return fail(err)
+	}
+
+	verb := "Borrados"
+	if *dryRun {
+		verb = "Se borrarían"
+	}
+	fmt.Printf("%s antes del %s:\n", verb, *before)
+	for _, line := range []struct {
+		label string
+		count int64
+	}{
+		{"Reservas", report.Reservations},
+		{"Grupos de reservas", report.BookingGroups},
+		{"Bloqueos de calendarios externos", report.ExternalBlocks},
+		{"Eventos", report.OutboxEvents},
+		{"Entregas de webhooks", report.WebhookDeliveries},
+	} {
+		fmt.Printf("  %-34s %d\n", line.label, line.count)
+	}
+	return 0
+}
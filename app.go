@@ -0,0 +1,151 @@
+package main
+
+import (
+	"booking-api/config"
+	"booking-api/database"
+	"booking-api/models"
+	"booking-api/notifications"
+	"booking-api/realtime"
+	"booking-api/repositories"
+	"booking-api/repositories/memory"
+	"booking-api/services"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// application holds the services shared by the server and the admin commands.
+type application struct {
+	users repositories.UserRepository
+
+	auth             services.AuthService
+	externalCalendar services.ExternalCalendarService
+	webhook          services.WebhookService
+	stream           services.StreamService
+	notification     services.NotificationService
+	calendar         services.CalendarService
+	quota            services.QuotaService
+	reservation      services.ReservationService
+	reservationCSV   services.ReservationCSVService
+	resource         services.ResourceService
+	checkIn          services.CheckInService
+	purge            services.PurgeService
+	audit            services.AuditService
+}
+
+// storage holds the repositories an application is built on.
+type storage struct {
+	users            repositories.UserRepository
+	reservations     repositories.ReservationRepository
+	resources        repositories.ResourceRepository
+	rules            repositories.BookingRuleRepository
+	quotas           repositories.QuotaRepository
+	externalCalendar repositories.ExternalCalendarRepository
+	webhooks         repositories.WebhookRepository
+	notifications    repositories.NotificationRepository
+	outbox           repositories.OutboxRepository
+	purge            repositories.PurgeRepository
+	audit            repositories.AuditRepository
+	uow              repositories.UnitOfWork
+}
+
+// sqlStorage keeps every table in the database.
+func sqlStorage(db *gorm.DB) storage {
+	return storage{
+		users:            repositories.NewUserRepository(db),
+		reservations:     repositories.NewReservationRepository(db),
+		resources:        repositories.NewResourceRepository(db),
+		rules:            repositories.NewBookingRuleRepository(db),
+		quotas:           repositories.NewQuotaRepository(db),
+		externalCalendar: repositories.NewExternalCalendarRepository(db),
+		webhooks:         repositories.NewWebhookRepository(db),
+		notifications:    repositories.NewNotificationRepository(db),
+		outbox:           repositories.NewOutboxRepository(db),
+		purge:            repositories.NewPurgeRepository(db),
+		audit:            repositories.NewAuditRepository(db),
+		uow:              repositories.NewUnitOfWork(db),
+	}
+}
+
+// memoryStorage keeps every table in store, for config.StorageMemory.
+func memoryStorage(store *memory.Store) storage {
+	return storage{
+		users:            memory.NewUserRepository(store),
+		reservations:     memory.NewReservationRepository(store),
+		resources:        memory.NewResourceRepository(store),
+		rules:            memory.NewBookingRuleRepository(store),
+		quotas:           memory.NewQuotaRepository(store),
+		externalCalendar: memory.NewExternalCalendarRepository(store),
+		webhooks:         memory.NewWebhookRepository(store),
+		notifications:    memory.NewNotificationRepository(store),
+		outbox:           memory.NewOutboxRepository(store),
+		purge:            memory.NewPurgeRepository(store),
+		audit:            memory.NewAuditRepository(store),
+		uow:              memory.NewUnitOfWork(store),
+	}
+}
+
+func newApplication(cfg config.Config) *application {
+	var st storage
+	if cfg.Database.Storage == config.StorageMemory {
+		store := memory.NewStore()
+		if cfg.Audit.HashChain {
+			store.ChainAudit()
+		}
+		st = memoryStorage(store)
+	} else {
+		st = sqlStorage(database.DB)
+	}
+
+	app := &application{users: st.users}
+	app.auth = services.NewAuthService(st.users, services.AuthSettings{
+		JWTSecret: cfg.Auth.JWTSecret,
+		TokenTTL:  cfg.TokenTTL(),
+	})
+	app.externalCalendar = services.NewExternalCalendarService(st.externalCalendar, st.resources, services.ExternalCalendarSettings{
+		FileDir:           cfg.Calendars.FileDir,
+		AllowPrivateHosts: cfg.Calendars.AllowPrivateHosts,
+	})
+	app.webhook = services.NewWebhookService(st.webhooks, services.WebhookSettings{
+		AllowPrivateHosts: cfg.Webhooks.AllowPrivateHosts,
+	})
+	app.stream = services.NewStreamService(st.outbox, realtime.NewMemoryBroker())
+	app.notification = services.NewNotificationService(st.notifications, st.users, st.resources, newNotifier(cfg.Notifications), services.NotificationSettings{
+		ReminderMinutes: cfg.Notifications.ReminderMinutes,
+		Locale:          cfg.Notifications.DefaultLocale,
+	})
+	app.calendar = services.NewCalendarService(st.reservations, st.resources, st.users)
+	app.quota = services.NewQuotaService(st.quotas, st.users, st.reservations, models.QuotaLimits{
+		HoursPerWeek:          cfg.Booking.QuotaHoursPerWeek,
+		MaxActiveReservations: cfg.Booking.QuotaMaxActive,
+		BookingsPerDay:        cfg.Booking.QuotaBookingsPerDay,
+	})
+	app.reservation = services.NewReservationService(st.reservations, st.resources, st.rules, st.uow, app.quota)
+	app.reservationCSV = services.NewReservationCSVService(app.reservation, st.reservations, st.users)
+	app.resource = services.NewResourceService(st.resources, st.rules)
+	app.checkIn = services.NewCheckInService(st.reservations, st.resources, st.uow, services.CheckInSettings{
+		Before: time.Duration(cfg.Booking.CheckInBeforeMinutes) * time.Minute,
+		Grace:  time.Duration(cfg.Booking.NoShowGraceMinutes) * time.Minute,
+	})
+	app.purge = services.NewPurgeService(st.purge)
+	app.audit = services.NewAuditService(st.audit)
+	return app
+}
+
+// newNotifier returns the email sink selected by the NOTIFIER setting, already
+// validated by config.Load.
+func newNotifier(cfg config.NotificationsConfig) notifications.Notifier {
+	switch cfg.Notifier {
+	case "smtp":
+		return &notifications.SMTPNotifier{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.MailFrom,
+		}
+	case "file":
+		return &notifications.FileNotifier{Dir: cfg.MailDir, From: cfg.MailFrom}
+	}
+	return notifications.LogNotifier{}
+}
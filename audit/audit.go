@@ -0,0 +1,150 @@
+// Package audit builds the entries of the audit log. The actor of a request,
+// who and from where, travels in its context from the middleware down to the
+// services, which append an entry in the same transaction as each change.
+package audit
+
+import (
+	"booking-api/logging"
+	"booking-api/models"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Actor is who makes a change. A zero Actor is the system.
+type Actor struct {
+	UserID    *uint
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type contextKey struct{}
+
+// WithActor returns ctx carrying actor.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, contextKey{}, actor)
+}
+
+// WithUser returns ctx whose actor is the given user, keeping where the
+// request comes from.
+func WithUser(ctx context.Context, userID uint) context.Context {
+	actor := ActorFrom(ctx)
+	actor.UserID = &userID
+	return WithActor(ctx, actor)
+}
+
+// ActorFrom returns the actor carried by ctx, the system if none.
+func ActorFrom(ctx context.Context) Actor {
+	actor, _ := ctx.Value(contextKey{}).(Actor)
+	return actor
+}
+
+// ignoredFields change with every write and say nothing about the change.
+var ignoredFields = map[string]bool{"ID": true, "CreatedAt": true, "UpdatedAt": true, "DeletedAt": true, "previous": true}
+
+// sensitiveFields only record that they changed, never their value. They are
+// matched anywhere in the field name, as in "feed_token".
+var sensitiveFields = []string{"password", "token", "secret"}
+
+// NewEntry returns the entry of the actor of ctx taking action on a target.
+// before and after are the target as JSON-encodable values, either of which may
+// be nil when the target is created or when nothing is changed.
+func NewEntry(ctx context.Context, action, targetType string, targetID uint, before, after interface{}) (*models.AuditEntry, error) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe %s: %v", action, err)
+	}
+	actor := ActorFrom(ctx)
+	return &models.AuditEntry{
+		// In UTC and to the microsecond, as stored by every database, so
+		// the time can be filtered on and hashed the same after a reload.
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+		ActorID:    actor.UserID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Changes:    changes,
+		IP:         actor.IP,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+	}, nil
+}
+
+// Diff returns the JSON fields whose value differs between before and after,
+// as {"field": {"before": ..., "after": ...}} sorted by field, or "" when none
+// does.
+func Diff(before, after interface{}) (string, error) {
+	old, err := fields(before)
+	if err != nil {
+		return "", err
+	}
+	current, err := fields(after)
+	if err != nil {
+		return "", err
+	}
+
+	names := map[string]bool{}
+	for name := range old {
+		names[name] = true
+	}
+	for name := range current {
+		names[name] = true
+	}
+
+	type change struct {
+		Before interface{} `json:"before"`
+		After  interface{} `json:"after"`
+	}
+	changes := map[string]change{}
+	for name := range names {
+		b, a := old[name], current[name]
+		if ignoredFields[name] || reflect.DeepEqual(b, a) {
+			continue
+		}
+		if sensitive(name) {
+			b, a = redact(b), redact(a)
+		}
+		changes[name] = change{Before: b, After: a}
+	}
+	if len(changes) == 0 {
+		return "", nil
+	}
+	data, err := json.Marshal(changes)
+	return string(data), err
+}
+
+// fields decodes the JSON object v encodes to. A nil v, or a nil pointer, has
+// no fields.
+func fields(v interface{}) (map[string]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var decoded map[string]interface{}
+	err = json.Unmarshal(data, &decoded)
+	return decoded, err
+}
+
+func sensitive(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range sensitiveFields {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redact(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+	return logging.Redacted
+}
@@ -1,34 +1,486 @@
+// Package config loads the settings of the API once, at startup, from the
+// defaults below, an optional YAML file, the environment (and a .env file)
+// and the command line flags, each one overriding the previous. The result is
+// validated before anything starts and then handed to whatever needs it;
+// nothing else reads the environment.
 package config
 
 import (
-	"log"
+	"booking-api/i18n"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/mail"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+// Environments accepted in APP_ENV.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Storages accepted in STORAGE. With StorageMemory every table is kept in
+// memory and lost on exit, for demos.
+const (
+	StorageSQL    = "sql"
+	StorageMemory = "memory"
+)
+
+// Every field has a key in the YAML file and, when it can be set from the
+// environment, the name of its variable.
 type Config struct {
-	Port      string
-	JWTSecret string
-	DBUrl     string
+	Env string `yaml:"env" env:"APP_ENV"`
+
+	Server        ServerConfig        `yaml:"server"`
+	Database      DatabaseConfig      `yaml:"database"`
+	Auth          AuthConfig          `yaml:"auth"`
+	Booking       BookingConfig       `yaml:"booking"`
+	Jobs          JobsConfig          `yaml:"jobs"`
+	Calendars     CalendarsConfig     `yaml:"calendars"`
+	Webhooks      WebhooksConfig      `yaml:"webhooks"`
+	Notifications NotificationsConfig `yaml:"notifications"`
+	Logging       LoggingConfig       `yaml:"logging"`
+	Tracing       TracingConfig       `yaml:"tracing"`
+	Audit         AuditConfig         `yaml:"audit"`
+
+	// generatedSecret tells that no JWT secret was given and a random one
+	// was made up for this run.
+	generatedSecret bool
+}
+
+type ServerConfig struct {
+	Port                   int    `yaml:"port" env:"PORT"`
+	ShutdownTimeoutSeconds int    `yaml:"shutdown_timeout_seconds" env:"SHUTDOWN_TIMEOUT_SECONDS"`
+	LegacyRoutesSunset     string `yaml:"legacy_routes_sunset" env:"LEGACY_ROUTES_SUNSET"`
+}
+
+// DatabaseConfig selects the database: a postgres:// URL or the path of a
+// SQLite file, unless Storage is memory. A pool setting of 0 keeps the default of database/sql.
+// RequestTimeoutSeconds bounds the queries of each API request, 0 meaning no
+// bound.
+type DatabaseConfig struct {
+	Storage                string `yaml:"storage" env:"STORAGE"`
+	URL                    string `yaml:"url" env:"DB_URL"`
+	MigrateOnStart         bool   `yaml:"migrate_on_start" env:"MIGRATE_ON_START"`
+	MaxOpenConns           int    `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS"`
+	MaxIdleConns           int    `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS"`
+	ConnMaxLifetimeMinutes int    `yaml:"conn_max_lifetime_minutes" env:"DB_CONN_MAX_LIFETIME_MINUTES"`
+	RequestTimeoutSeconds  int    `yaml:"request_timeout_seconds" env:"DB_REQUEST_TIMEOUT_SECONDS"`
+}
+
+type AuthConfig struct {
+	JWTSecret     string `yaml:"jwt_secret" env:"JWT_SECRET"`
+	TokenTTLHours int    `yaml:"token_ttl_hours" env:"TOKEN_TTL_HOURS"`
+}
+
+// BookingConfig holds the default quotas, 0 meaning unlimited, and the
+// check-in window.
+type BookingConfig struct {
+	QuotaHoursPerWeek    int `yaml:"quota_hours_per_week" env:"QUOTA_HOURS_PER_WEEK"`
+	QuotaMaxActive       int `yaml:"quota_max_active" env:"QUOTA_MAX_ACTIVE"`
+	QuotaBookingsPerDay  int `yaml:"quota_bookings_per_day" env:"QUOTA_BOOKINGS_PER_DAY"`
+	CheckInBeforeMinutes int `yaml:"check_in_before_minutes" env:"CHECKIN_BEFORE_MINUTES"`
+	NoShowGraceMinutes   int `yaml:"no_show_grace_minutes" env:"NO_SHOW_GRACE_MINUTES"`
+}
+
+// JobsConfig sets how often each background job runs.
+type JobsConfig struct {
+	NoShowIntervalSeconds       int `yaml:"no_show_interval_seconds" env:"NO_SHOW_INTERVAL_SECONDS"`
+	ExternalSyncIntervalMinutes int `yaml:"external_sync_interval_minutes" env:"EXTERNAL_SYNC_INTERVAL_MINUTES"`
+	WebhookIntervalSeconds      int `yaml:"webhook_interval_seconds" env:"WEBHOOK_INTERVAL_SECONDS"`
+	NotificationIntervalSeconds int `yaml:"notification_interval_seconds" env:"NOTIFICATION_INTERVAL_SECONDS"`
+	StreamPollMilliseconds      int `yaml:"stream_poll_milliseconds" env:"STREAM_POLL_MILLISECONDS"`
+}
+
+// CalendarsConfig limits the sources of external calendars: files are only
+// read inside FileDir, none while it is empty, and URLs only reach public
+// addresses unless AllowPrivateHosts is set.
+type CalendarsConfig struct {
+	FileDir           string `yaml:"file_dir" env:"CALENDAR_FILE_DIR"`
+	AllowPrivateHosts bool   `yaml:"allow_private_hosts" env:"CALENDAR_ALLOW_PRIVATE_HOSTS"`
+}
+
+// WebhooksConfig limits the webhook deliveries to public addresses unless
+// AllowPrivateHosts is set.
+type WebhooksConfig struct {
+	AllowPrivateHosts bool `yaml:"allow_private_hosts" env:"WEBHOOK_ALLOW_PRIVATE_HOSTS"`
+}
+
+type NotificationsConfig struct {
+	Notifier        string `yaml:"notifier" env:"NOTIFIER"`
+	MailFrom        string `yaml:"mail_from" env:"MAIL_FROM"`
+	MailDir         string `yaml:"mail_dir" env:"MAIL_DIR"`
+	SMTPHost        string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort        int    `yaml:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername    string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword    string `yaml:"smtp_password" env:"SMTP_PASSWORD"`
+	ReminderMinutes int    `yaml:"reminder_minutes" env:"REMINDER_MINUTES"`
+	DefaultLocale   string `yaml:"default_locale" env:"DEFAULT_LOCALE"`
+}
+
+type LoggingConfig struct {
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+}
+
+type TracingConfig struct {
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER"`
+}
+
+type AuditConfig struct {
+	HashChain bool `yaml:"hash_chain" env:"AUDIT_HASH_CHAIN"`
+}
+
+// Defaults returns the settings used when nothing else is given. They suit
+// development; production needs at least a JWT secret and a database.
+func Defaults() Config {
+	return Config{
+		Env: EnvDevelopment,
+		Server: ServerConfig{
+			Port:                   3000,
+			ShutdownTimeoutSeconds: 20,
+			LegacyRoutesSunset:     "2027-04-30",
+		},
+		Database: DatabaseConfig{
+			Storage:                StorageSQL,
+			URL:                    "booking.db",
+			MigrateOnStart:         true,
+			MaxOpenConns:           25,
+			MaxIdleConns:           5,
+			ConnMaxLifetimeMinutes: 30,
+			RequestTimeoutSeconds:  10,
+		},
+		Auth: AuthConfig{TokenTTLHours: 24},
+		Booking: BookingConfig{
+			CheckInBeforeMinutes: 15,
+			NoShowGraceMinutes:   15,
+		},
+		Jobs: JobsConfig{
+			NoShowIntervalSeconds:       60,
+			ExternalSyncIntervalMinutes: 15,
+			WebhookIntervalSeconds:      5,
+			NotificationIntervalSeconds: 30,
+			StreamPollMilliseconds:      500,
+		},
+		Notifications: NotificationsConfig{
+			Notifier:        "log",
+			MailFrom:        "Booking API <no-reply@booking-api.local>",
+			MailDir:         "mail",
+			SMTPHost:        "localhost",
+			SMTPPort:        587,
+			ReminderMinutes: 60,
+			DefaultLocale:   "es",
+		},
+		Logging: LoggingConfig{Format: "json", Level: "info"},
+		Tracing: TracingConfig{Exporter: "none"},
+	}
+}
+
+// ErrHelp is returned by Load when the flags ask for help.
+var ErrHelp = flag.ErrHelp
+
+// options are the flags read before the command name. Each one overrides the
+// setting it names.
+type options struct {
+	file     string
+	env      string
+	port     int
+	storage  string
+	dbURL    string
+	logLevel string
+}
+
+func newFlagSet(opts *options) *flag.FlagSet {
+	flags := flag.NewFlagSet("booking-api", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&opts.file, "config", "", "archivo YAML de configuración (también CONFIG_FILE)")
+	flags.StringVar(&opts.env, "env", "", "entorno: development o production (también APP_ENV)")
+	flags.IntVar(&opts.port, "port", 0, "puerto HTTP (también PORT)")
+	flags.StringVar(&opts.storage, "storage", "", "almacenamiento: sql o memory, para demos (también STORAGE)")
+	flags.StringVar(&opts.dbURL, "db-url", "", "URL de PostgreSQL o ruta de SQLite (también DB_URL)")
+	flags.StringVar(&opts.logLevel, "log-level", "", "nivel de los logs (también LOG_LEVEL)")
+	return flags
+}
+
+// PrintFlags writes the description of the flags read by Load.
+func PrintFlags(w io.Writer) {
+	flags := newFlagSet(&options{})
+	flags.SetOutput(w)
+	flags.PrintDefaults()
+}
+
+// Load reads the configuration and validates it. args are the command line
+// arguments after the program name; the ones following the flags, starting
+// with the command name, are returned.
+func Load(args []string) (Config, []string, error) {
+	var opts options
+	flags := newFlagSet(&opts)
+	if err := flags.Parse(args); err != nil {
+		return Config{}, nil, err
+	}
+
+	// A variable already set wins over the .env file.
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, nil, fmt.Errorf(".env: %v", err)
+	}
+
+	cfg := Defaults()
+	file := opts.file
+	if file == "" {
+		file = os.Getenv("CONFIG_FILE")
+	}
+	if file != "" {
+		if err := cfg.loadFile(file); err != nil {
+			return Config{}, nil, err
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem(), os.LookupEnv); err != nil {
+		return Config{}, nil, err
+	}
+
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "env":
+			cfg.Env = opts.env
+		case "port":
+			cfg.Server.Port = opts.port
+		case "storage":
+			cfg.Database.Storage = opts.storage
+		case "db-url":
+			cfg.Database.URL = opts.dbURL
+		case "log-level":
+			cfg.Logging.Level = opts.logLevel
+		}
+	})
+
+	if cfg.Auth.JWTSecret == "" && cfg.Env != EnvProduction {
+		secret, err := randomSecret()
+		if err != nil {
+			return Config{}, nil, err
+		}
+		cfg.Auth.JWTSecret = secret
+		cfg.generatedSecret = true
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, nil, err
+	}
+	return cfg, flags.Args(), nil
 }
 
-func LoadConfig() Config {
-	err := godotenv.Load()
+// loadFile reads the YAML file at path over cfg. Unknown keys are an error,
+// so a misspelt setting is not silently ignored.
+func (cfg *Config) loadFile(path string) error {
+	f, err := os.Open(path)
 	if err != nil {
-		log.Println("No se pudo cargar .env, usando variables del sistema...")
+		return fmt.Errorf("config file: %v", err)
 	}
+	defer f.Close()
 
-	return Config{
-		Port:      getEnv("PORT", "3000"),
-		JWTSecret: getEnv("JWT_SECRET", "defaultsecret"),
-		DBUrl:     getEnv("DB_URL", "booking.db"),
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// applyEnv sets every field of v tagged with a variable that lookup finds.
+func applyEnv(v reflect.Value, lookup func(string) (string, bool)) error {
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Type.Kind() == reflect.Struct {
+			errs = append(errs, applyEnv(value, lookup))
+			continue
+		}
+		name := field.Tag.Get("env")
+		raw, ok := lookup(name)
+		if name == "" || !ok {
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.String:
+			value.SetString(raw)
+		case reflect.Int:
+			n, err := strconv.Atoi(strings.TrimSpace(raw))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not a whole number", name, raw))
+				continue
+			}
+			value.SetInt(int64(n))
+		case reflect.Bool:
+			b, err := strconv.ParseBool(strings.TrimSpace(raw))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not true or false", name, raw))
+				continue
+			}
+			value.SetBool(b)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// weakSecrets are secrets seen in examples and old defaults, refused in
+// production whatever their length.
+var weakSecrets = []string{"defaultsecret", "supersecretkey", "secret", "changeme", "jwtsecret", "password"}
+
+// minSecretLength is the shortest JWT secret accepted in production, 256
+// bits for HS256.
+const minSecretLength = 32
+
+// Validate checks every setting and reports all the problems at once.
+func (cfg Config) Validate() error {
+	var problems []string
+	check := func(ok bool, key, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, key+": "+fmt.Sprintf(format, args...))
+		}
+	}
+	oneOf := func(value, key string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		check(false, key, "%q is not one of %s", value, strings.Join(allowed, ", "))
+	}
+
+	oneOf(cfg.Env, "env (APP_ENV)", EnvDevelopment, EnvProduction)
+
+	check(cfg.Server.Port > 0 && cfg.Server.Port <= 65535, "server.port (PORT)", "must be between 1 and 65535")
+	check(cfg.Server.ShutdownTimeoutSeconds > 0, "server.shutdown_timeout_seconds (SHUTDOWN_TIMEOUT_SECONDS)", "must be positive")
+	_, err := time.Parse("2006-01-02", cfg.Server.LegacyRoutesSunset)
+	check(err == nil, "server.legacy_routes_sunset (LEGACY_ROUTES_SUNSET)", "must be a date as YYYY-MM-DD")
+
+	oneOf(cfg.Database.Storage, "database.storage (STORAGE)", StorageSQL, StorageMemory)
+	check(cfg.Database.Storage != StorageMemory || cfg.Env != EnvProduction, "database.storage (STORAGE)", "cannot be memory in production")
+	check(cfg.Database.URL != "" || cfg.Database.Storage == StorageMemory, "database.url (DB_URL)", "is required")
+	check(cfg.Database.MaxOpenConns >= 0, "database.max_open_conns (DB_MAX_OPEN_CONNS)", "cannot be negative")
+	check(cfg.Database.MaxIdleConns >= 0, "database.max_idle_conns (DB_MAX_IDLE_CONNS)", "cannot be negative")
+	check(cfg.Database.MaxOpenConns == 0 || cfg.Database.MaxIdleConns <= cfg.Database.MaxOpenConns,
+		"database.max_idle_conns (DB_MAX_IDLE_CONNS)", "cannot exceed max_open_conns")
+	check(cfg.Database.ConnMaxLifetimeMinutes >= 0, "database.conn_max_lifetime_minutes (DB_CONN_MAX_LIFETIME_MINUTES)", "cannot be negative")
+	check(cfg.Database.RequestTimeoutSeconds >= 0, "database.request_timeout_seconds (DB_REQUEST_TIMEOUT_SECONDS)", "cannot be negative")
+
+	check(cfg.Auth.JWTSecret != "", "auth.jwt_secret (JWT_SECRET)", "is required")
+	if cfg.Env == EnvProduction && cfg.Auth.JWTSecret != "" {
+		if weakSecret(cfg.Auth.JWTSecret) {
+			check(false, "auth.jwt_secret (JWT_SECRET)", "is a well-known example secret")
+		} else {
+			check(len(cfg.Auth.JWTSecret) >= minSecretLength, "auth.jwt_secret (JWT_SECRET)",
+				"must be at least %d characters in production", minSecretLength)
+		}
+	}
+	check(cfg.Auth.TokenTTLHours > 0, "auth.token_ttl_hours (TOKEN_TTL_HOURS)", "must be positive")
+
+	check(cfg.Booking.QuotaHoursPerWeek >= 0, "booking.quota_hours_per_week (QUOTA_HOURS_PER_WEEK)", "cannot be negative")
+	check(cfg.Booking.QuotaMaxActive >= 0, "booking.quota_max_active (QUOTA_MAX_ACTIVE)", "cannot be negative")
+	check(cfg.Booking.QuotaBookingsPerDay >= 0, "booking.quota_bookings_per_day (QUOTA_BOOKINGS_PER_DAY)", "cannot be negative")
+	check(cfg.Booking.CheckInBeforeMinutes >= 0, "booking.check_in_before_minutes (CHECKIN_BEFORE_MINUTES)", "cannot be negative")
+	check(cfg.Booking.NoShowGraceMinutes >= 0, "booking.no_show_grace_minutes (NO_SHOW_GRACE_MINUTES)", "cannot be negative")
+
+	check(cfg.Jobs.NoShowIntervalSeconds > 0, "jobs.no_show_interval_seconds (NO_SHOW_INTERVAL_SECONDS)", "must be positive")
+	check(cfg.Jobs.ExternalSyncIntervalMinutes > 0, "jobs.external_sync_interval_minutes (EXTERNAL_SYNC_INTERVAL_MINUTES)", "must be positive")
+	check(cfg.Jobs.WebhookIntervalSeconds > 0, "jobs.webhook_interval_seconds (WEBHOOK_INTERVAL_SECONDS)", "must be positive")
+	check(cfg.Jobs.NotificationIntervalSeconds > 0, "jobs.notification_interval_seconds (NOTIFICATION_INTERVAL_SECONDS)", "must be positive")
+	check(cfg.Jobs.StreamPollMilliseconds > 0, "jobs.stream_poll_milliseconds (STREAM_POLL_MILLISECONDS)", "must be positive")
+
+	n := cfg.Notifications
+	oneOf(n.Notifier, "notifications.notifier (NOTIFIER)", "log", "smtp", "file")
+	_, err = mail.ParseAddress(n.MailFrom)
+	check(err == nil, "notifications.mail_from (MAIL_FROM)", "is not an email address")
+	if n.Notifier == "smtp" {
+		check(n.SMTPHost != "", "notifications.smtp_host (SMTP_HOST)", "is required with the smtp notifier")
+		check(n.SMTPPort > 0 && n.SMTPPort <= 65535, "notifications.smtp_port (SMTP_PORT)", "must be between 1 and 65535")
+	}
+	if n.Notifier == "file" {
+		check(n.MailDir != "", "notifications.mail_dir (MAIL_DIR)", "is required with the file notifier")
+	}
+	check(n.ReminderMinutes >= 0, "notifications.reminder_minutes (REMINDER_MINUTES)", "cannot be negative")
+	check(i18n.Supported(n.DefaultLocale), "notifications.default_locale (DEFAULT_LOCALE)",
+		"%q is not one of %s", n.DefaultLocale, strings.Join(i18n.Locales(), ", "))
+
+	oneOf(cfg.Logging.Format, "logging.format (LOG_FORMAT)", "json", "text")
+	var level slog.Level
+	check(level.UnmarshalText([]byte(cfg.Logging.Level)) == nil, "logging.level (LOG_LEVEL)", "%q is not debug, info, warn or error", cfg.Logging.Level)
+
+	oneOf(cfg.Tracing.Exporter, "tracing.exporter (TRACING_EXPORTER)", "none", "stdout", "otlp")
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// ValidationError lists every invalid setting.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Warnings describes settings that are accepted but unsafe, to be logged once
+// logging is set up.
+func (cfg Config) Warnings() []string {
+	var warnings []string
+	switch {
+	case cfg.generatedSecret:
+		warnings = append(warnings, "JWT_SECRET no está definido: se usa uno aleatorio y los tokens dejan de valer al reiniciar")
+	case cfg.Env != EnvProduction && (len(cfg.Auth.JWTSecret) < minSecretLength || weakSecret(cfg.Auth.JWTSecret)):
+		warnings = append(warnings, "JWT_SECRET es débil: no se aceptará en producción")
+	}
+	if cfg.Database.Storage == StorageMemory {
+		warnings = append(warnings, "modo demo: los datos se guardan en memoria y se pierden al salir")
+	}
+	return warnings
+}
+
+// ShutdownTimeout, TokenTTL and the like turn the settings kept in whole
+// units into durations.
+func (cfg Config) ShutdownTimeout() time.Duration {
+	return time.Duration(cfg.Server.ShutdownTimeoutSeconds) * time.Second
+}
+
+func (cfg Config) TokenTTL() time.Duration {
+	return time.Duration(cfg.Auth.TokenTTLHours) * time.Hour
+}
+
+func (cfg Config) DBRequestTimeout() time.Duration {
+	return time.Duration(cfg.Database.RequestTimeoutSeconds) * time.Second
+}
+
+// randomSecret makes up a JWT secret for a development run without one.
+func randomSecret() (string, error) {
+	b := make([]byte, minSecretLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate a JWT secret: %v", err)
 	}
+	return hex.EncodeToString(b), nil
 }
 
-func getEnv(key, defaultVal string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		return value
+func weakSecret(secret string) bool {
+	for _, weak := range weakSecrets {
+		if strings.EqualFold(secret, weak) {
+			return true
+		}
 	}
-	return defaultVal
+	return false
 }
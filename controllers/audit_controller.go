@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"booking-api/repositories"
+	"booking-api/services"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditController struct {
+	Service services.AuditService
+}
+
+func NewAuditController(service services.AuditService) *AuditController {
+	return &AuditController{Service: service}
+}
+
+// GetEntries lists the audit log, newest first, filtered by actor_id, action,
+// target_type, target_id and a from/to time range. Older pages are read
+// passing the ID of the last entry received as before_id.
+func (ac *AuditController) GetEntries(c *fiber.Ctx) error {
+	filter := repositories.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+	}
+
+	if value := c.Query("actor_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil || id == 0 {
+			return invalidParam("actor_id")
+		}
+		actorID := uint(id)
+		filter.ActorID = &actorID
+	}
+	for name, field := range map[string]*uint{"target_id": &filter.TargetID, "before_id": &filter.BeforeID} {
+		if value := c.Query(name); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || id == 0 {
+				return invalidParam(name)
+			}
+			*field = uint(id)
+		}
+	}
+	for name, field := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := c.Query(name); value != "" {
+			t, err := parseAuditTime(value)
+			if err != nil {
+				return invalidParam(name)
+			}
+			*field = t
+		}
+	}
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return invalidParam("limit")
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := ac.Service.List(c.UserContext(), filter)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(entries)
+}
+
+// VerifyChain checks the hash chain of the audit log.
+func (ac *AuditController) VerifyChain(c *fiber.Ctx) error {
+	result, err := ac.Service.Verify(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(result)
+}
+
+// parseAuditTime reads an RFC 3339 time, or a date standing for its start in
+// UTC.
+func parseAuditTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
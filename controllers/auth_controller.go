@@ -3,7 +3,6 @@ package controllers
 import (
 	"booking-api/models"
 	"booking-api/services"
-	"booking-api/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -24,11 +23,16 @@ func (a *AuthController) Register(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return errInvalidInput
 	}
 
-	if input.Name == "" || input.Email == "" || input.Password == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "All fields are required"})
+	switch {
+	case input.Name == "":
+		return missingParam("name")
+	case input.Email == "":
+		return missingParam("email")
+	case input.Password == "":
+		return missingParam("password")
 	}
 
 	user := models.User{
@@ -37,14 +41,14 @@ func (a *AuthController) Register(c *fiber.Ctx) error {
 		Password: input.Password,
 	}
 
-	err := a.AuthService.Register(&user)
+	err := a.AuthService.Register(c.UserContext(), &user)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
-	token, err := utils.GenerateJWT(user.ID)
+	token, err := a.AuthService.IssueToken(&user)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to generate token"})
+		return err
 	}
 
 	return c.JSON(fiber.Map{"token": token})
@@ -57,12 +61,12 @@ func (a *AuthController) Login(c *fiber.Ctx) error {
 	}
 
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return errInvalidInput
 	}
 
-	token, err := a.AuthService.Login(input.Email, input.Password)
+	token, err := a.AuthService.Login(c.UserContext(), input.Email, input.Password)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(fiber.Map{"token": token})
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"booking-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CalendarController struct {
+	Service services.CalendarService
+}
+
+func NewCalendarController(service services.CalendarService) *CalendarController {
+	return &CalendarController{Service: service}
+}
+
+func (cc *CalendarController) GetReservationCalendar(c *fiber.Ctx) error {
+	userID, ok := userIDFromToken(c)
+	if !ok {
+		return errInvalidClaims
+	}
+
+	reservationID, err := c.ParamsInt("id")
+	if err != nil || reservationID <= 0 {
+		return invalidParam("id")
+	}
+
+	calendar, err := cc.Service.ReservationCalendar(c.UserContext(), uint(reservationID), userID, localeOf(c))
+	if err != nil {
+		return err
+	}
+
+	return sendCalendar(c, calendar)
+}
+
+func (cc *CalendarController) GetFeed(c *fiber.Ctx) error {
+	calendar, err := cc.Service.FeedCalendar(c.UserContext(), c.Params("token"), localeOf(c))
+	if err != nil {
+		return err
+	}
+
+	return sendCalendar(c, calendar)
+}
+
+// GetMyFeed returns the subscribable feed URL of the user.
+func (cc *CalendarController) GetMyFeed(c *fiber.Ctx) error {
+	return cc.myFeed(c, false)
+}
+
+// RotateMyFeed replaces the feed URL of the user, revoking the old one.
+func (cc *CalendarController) RotateMyFeed(c *fiber.Ctx) error {
+	return cc.myFeed(c, true)
+}
+
+func (cc *CalendarController) GetResourceFeed(c *fiber.Ctx) error {
+	return cc.resourceFeed(c, false)
+}
+
+func (cc *CalendarController) RotateResourceFeed(c *fiber.Ctx) error {
+	return cc.resourceFeed(c, true)
+}
+
+func (cc *CalendarController) myFeed(c *fiber.Ctx, rotate bool) error {
+	userID, ok := userIDFromToken(c)
+	if !ok {
+		return errInvalidClaims
+	}
+
+	token, err := cc.Service.UserFeedToken(c.UserContext(), userID, rotate)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"url": feedURL(c, token)})
+}
+
+func (cc *CalendarController) resourceFeed(c *fiber.Ctx, rotate bool) error {
+	resourceID, err := c.ParamsInt("id")
+	if err != nil || resourceID <= 0 {
+		return invalidParam("id")
+	}
+
+	token, err := cc.Service.ResourceFeedToken(c.UserContext(), uint(resourceID), rotate)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(fiber.Map{"url": feedURL(c, token)})
+}
+
+func sendCalendar(c *fiber.Ctx, calendar string) error {
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	return c.SendString(calendar)
+}
+
+func feedURL(c *fiber.Ctx, token string) string {
+	return c.BaseURL() + "/v1/feeds/" + token + "/calendar.ics"
+}
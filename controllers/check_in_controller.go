@@ -0,0 +1,43 @@
+package controllers
+
+import (
+	"booking-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CheckInController struct {
+	Service services.CheckInService
+}
+
+func NewCheckInController(service services.CheckInService) *CheckInController {
+	return &CheckInController{Service: service}
+}
+
+func (cc *CheckInController) CheckIn(c *fiber.Ctx) error {
+	userID, ok := userIDFromToken(c)
+	if !ok {
+		return errInvalidClaims
+	}
+
+	reservationID, err := c.ParamsInt("id")
+	if err != nil || reservationID <= 0 {
+		return invalidParam("id")
+	}
+
+	var input struct {
+		Code string `json:"code"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return errInvalidInput
+		}
+	}
+
+	reservation, err := cc.Service.CheckIn(c.UserContext(), uint(reservationID), userID, input.Code)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(reservation)
+}
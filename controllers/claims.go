@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// userIDFromToken reads the user_id claim of the token stored by the JWT
+// middleware.
+func userIDFromToken(c *fiber.Ctx) (uint, bool) {
+	userToken, ok := c.Locals("user").(*jwt.Token)
+	if !ok {
+		return 0, false
+	}
+	claims, ok := userToken.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, false
+	}
+	userIDf, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, false
+	}
+	return uint(userIDf), true
+}
+
+// roleFromToken reads the role claim of the token stored by the JWT
+// middleware.
+func roleFromToken(c *fiber.Ctx) string {
+	userToken, ok := c.Locals("user").(*jwt.Token)
+	if !ok {
+		return ""
+	}
+	claims, ok := userToken.Claims.(jwt.MapClaims)
+	if !ok {
+		return ""
+	}
+	role, _ := claims["role"].(string)
+	return role
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"booking-api/docs"
+	"encoding/json"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// docsPage renders the OpenAPI document with Swagger UI, loaded from a CDN.
+const docsPage = `<!DOCTYPE html>
+<html lang="es">
+<head>
+  <meta charset="utf-8">
+  <title>Booking API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+type DocsController struct {
+	once sync.Once
+	spec []byte
+	err  error
+}
+
+func NewDocsController() *DocsController {
+	return &DocsController{}
+}
+
+// GetSpec serves the OpenAPI document. It is built on the first request.
+func (dc *DocsController) GetSpec(c *fiber.Ctx) error {
+	dc.once.Do(func() {
+		dc.spec, dc.err = json.Marshal(docs.Spec())
+	})
+	if dc.err != nil {
+		return dc.err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return c.Send(dc.spec)
+}
+
+func (dc *DocsController) GetUI(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.SendString(docsPage)
+}
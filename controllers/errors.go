@@ -0,0 +1,215 @@
+package controllers
+
+import (
+	"booking-api/i18n"
+	"booking-api/middlewares"
+	"booking-api/services"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MIMEProblemJSON is the media type of error responses.
+const MIMEProblemJSON = "application/problem+json"
+
+// Problem is an RFC 9457 problem details document. Code identifies the error
+// for clients and never changes; Violations lists field-level details. Title,
+// Detail and the violation messages are in the negotiated locale.
+type Problem struct {
+	Type       string               `json:"type"`
+	Title      string               `json:"title"`
+	Status     int                  `json:"status"`
+	Detail     string               `json:"detail,omitempty"`
+	Instance   string               `json:"instance,omitempty"`
+	Code       string               `json:"code"`
+	RequestID  string               `json:"request_id,omitempty"`
+	Violations []services.Violation `json:"violations,omitempty"`
+}
+
+// Codes of errors detected by the handlers themselves.
+const (
+	CodeInvalidBody      = "invalid_body"
+	CodeInvalidParameter = "invalid_parameter"
+	CodeMissingParameter = "missing_parameter"
+	CodePolicyViolation  = "policy_violation"
+	CodeDatabaseTimeout  = "database_timeout"
+	CodeInternalError    = "internal_error"
+)
+
+// requestError is a request the handler could not read, as opposed to one the
+// services rejected. Its message comes from the catalog.
+type requestError struct {
+	code  string
+	field string
+}
+
+func (e *requestError) Error() string {
+	return describe(i18n.Fallback, e.code, e.field, nil, e.code)
+}
+
+var (
+	errInvalidInput  = &requestError{code: CodeInvalidBody}
+	errInvalidClaims = fiber.NewError(fiber.StatusUnauthorized, "Invalid token claims")
+)
+
+// invalidParam reports a malformed path, query or body field.
+func invalidParam(field string) error {
+	return &requestError{code: CodeInvalidParameter, field: field}
+}
+
+// missingParam reports a required path, query or body field that is empty.
+func missingParam(field string) error {
+	return &requestError{code: CodeMissingParameter, field: field}
+}
+
+// ErrorHandler renders every error returned by a handler as
+// application/problem+json. Unexpected errors are logged and hidden.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	locale := localeOf(c)
+	problem := problemFor(c, locale, err)
+	switch problem.Code {
+	case CodeInternalError:
+		slog.ErrorContext(c.UserContext(), "error interno", "method", c.Method(), "route", c.Route().Path, "error", err)
+	case CodeDatabaseTimeout:
+		slog.WarnContext(c.UserContext(), "la petición agotó su tiempo de base de datos", "method", c.Method(), "route", c.Route().Path, "error", err)
+	}
+
+	problem.Type = "about:blank"
+	problem.Title = describe(locale, "status."+strconv.Itoa(problem.Status), "", nil, http.StatusText(problem.Status))
+	problem.Instance = c.Path()
+	problem.RequestID, _ = c.Locals(middlewares.RequestIDKey).(string)
+	return c.Status(problem.Status).JSON(problem, MIMEProblemJSON)
+}
+
+func problemFor(c *fiber.Ctx, locale string, err error) Problem {
+	var requestErr *requestError
+	var policyErr *services.PolicyViolationError
+	var domainErr *services.Error
+	var notFoundErr *services.NotFoundError
+	var fiberErr *fiber.Error
+
+	switch {
+	case errors.As(err, &requestErr):
+		detail := describe(locale, requestErr.code, requestErr.field, nil, requestErr.Error())
+		return Problem{
+			Status:     fiber.StatusBadRequest,
+			Code:       requestErr.code,
+			Detail:     detail,
+			Violations: fieldViolation(requestErr.code, requestErr.field, detail),
+		}
+	case errors.As(err, &policyErr):
+		// A slot that is only taken is a conflict; anything else breaks the
+		// booking rules.
+		status, code := fiber.StatusConflict, services.CodeOverlap
+		for _, v := range policyErr.Violations {
+			if v.Code != services.CodeOverlap {
+				status, code = fiber.StatusUnprocessableEntity, CodePolicyViolation
+			}
+		}
+		violations := localizeViolations(locale, policyErr.Violations)
+		return Problem{Status: status, Code: code, Detail: joinMessages(violations), Violations: violations}
+	case errors.As(err, &domainErr):
+		detail := describe(locale, domainErr.Code, domainErr.Field, domainErr.Params, domainErr.Message)
+		return Problem{
+			Status:     statusFor(domainErr.Err),
+			Code:       domainErr.Code,
+			Detail:     detail,
+			Violations: fieldViolation(domainErr.Code, domainErr.Field, detail),
+		}
+	case errors.As(err, &notFoundErr):
+		code := strings.ReplaceAll(notFoundErr.Entity, " ", "_") + "_not_found"
+		return Problem{
+			Status: fiber.StatusNotFound,
+			Code:   code,
+			Detail: describe(locale, code, "", nil, notFoundErr.Error()),
+		}
+	case errors.As(err, &fiberErr):
+		code := strings.ToLower(strings.ReplaceAll(http.StatusText(fiberErr.Code), " ", "_"))
+		params := i18n.Params{"method": c.Method(), "path": c.Path()}
+		return Problem{
+			Status: fiberErr.Code,
+			Code:   code,
+			Detail: describe(locale, code, "", params, fiberErr.Message),
+		}
+	case errors.Is(c.UserContext().Err(), context.DeadlineExceeded):
+		// Services wrap query errors as text, so the deadline set by
+		// middlewares.DBTimeout is read from the request itself.
+		return Problem{
+			Status: fiber.StatusServiceUnavailable,
+			Code:   CodeDatabaseTimeout,
+			Detail: describe(locale, CodeDatabaseTimeout, "", nil, "The database took too long to answer, try again"),
+		}
+	}
+	return Problem{
+		Status: fiber.StatusInternalServerError,
+		Code:   CodeInternalError,
+		Detail: describe(locale, CodeInternalError, "", nil, "An unexpected error occurred"),
+	}
+}
+
+func statusFor(kind error) int {
+	switch kind {
+	case services.ErrNotFound:
+		return fiber.StatusNotFound
+	case services.ErrInvalid:
+		return fiber.StatusUnprocessableEntity
+	case services.ErrConflict:
+		return fiber.StatusConflict
+	case services.ErrForbidden:
+		return fiber.StatusForbidden
+	case services.ErrUnauthorized:
+		return fiber.StatusUnauthorized
+	case services.ErrUnavailable:
+		return fiber.StatusBadGateway
+	}
+	return fiber.StatusInternalServerError
+}
+
+func fieldViolation(code, field, message string) []services.Violation {
+	if field == "" {
+		return nil
+	}
+	return []services.Violation{{Code: code, Field: field, Message: message}}
+}
+
+// localeOf returns the locale negotiated by middlewares.Locale, negotiating it
+// here for apps mounted without the middleware.
+func localeOf(c *fiber.Ctx) string {
+	if locale, ok := c.Locals("locale").(string); ok {
+		return locale
+	}
+	return i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage))
+}
+
+// describe renders the catalog message of a code, or fallback if the catalogs
+// do not have it.
+func describe(locale, code, field string, params i18n.Params, fallback string) string {
+	if message, ok := i18n.Describe(locale, code, field, params); ok {
+		return message
+	}
+	return fallback
+}
+
+// localizeViolations returns a copy of violations with their messages in the
+// locale.
+func localizeViolations(locale string, violations []services.Violation) []services.Violation {
+	localized := make([]services.Violation, len(violations))
+	for i, v := range violations {
+		v.Message = describe(locale, v.Code, v.Field, v.Params, v.Message)
+		localized[i] = v
+	}
+	return localized
+}
+
+func joinMessages(violations []services.Violation) string {
+	messages := make([]string, len(violations))
+	for i, v := range violations {
+		messages[i] = v.Message
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"booking-api/models"
+	"booking-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ExternalCalendarController struct {
+	Service services.ExternalCalendarService
+}
+
+func NewExternalCalendarController(service services.ExternalCalendarService) *ExternalCalendarController {
+	return &ExternalCalendarController{Service: service}
+}
+
+func (ec *ExternalCalendarController) AddCalendar(c *fiber.Ctx) error {
+	resourceID, err := c.ParamsInt("id")
+	if err != nil || resourceID <= 0 {
+		return invalidParam("id")
+	}
+
+	var input struct {
+		Name     string `json:"name"`
+		URL      string `json:"url"`
+		FilePath string `json:"file_path"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errInvalidInput
+	}
+
+	calendar := models.ExternalCalendar{
+		ResourceID: uint(resourceID),
+		Name:       input.Name,
+		URL:        input.URL,
+		FilePath:   input.FilePath,
+	}
+	if err := ec.Service.AddCalendar(c.UserContext(), &calendar); err != nil {
+		if calendar.ID != 0 {
+			// Registered, but the first sync failed.
+			return c.Status(fiber.StatusAccepted).JSON(calendar)
+		}
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(calendar)
+}
+
+// UploadCalendar imports an iCalendar file sent as the raw request body or as
+// the "file" field of a multipart form.
+func (ec *ExternalCalendarController) UploadCalendar(c *fiber.Ctx) error {
+	resourceID, err := c.ParamsInt("id")
+	if err != nil || resourceID <= 0 {
+		return invalidParam("id")
+	}
+
+	data, filename, err := uploadedFile(c)
+	if err != nil {
+		return invalidParam("file")
+	}
+	name := c.Query("name", filename)
+
+	calendar, err := ec.Service.UploadCalendar(c.UserContext(), uint(resourceID), name, string(data))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(calendar)
+}
+
+func (ec *ExternalCalendarController) GetCalendars(c *fiber.Ctx) error {
+	resourceID, err := c.ParamsInt("id")
+	if err != nil || resourceID <= 0 {
+		return invalidParam("id")
+	}
+
+	calendars, err := ec.Service.GetCalendars(c.UserContext(), uint(resourceID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(calendars)
+}
+
+func (ec *ExternalCalendarController) SyncCalendar(c *fiber.Ctx) error {
+	calendarID, err := c.ParamsInt("id")
+	if err != nil || calendarID <= 0 {
+		return invalidParam("id")
+	}
+
+	calendar, err := ec.Service.SyncCalendar(c.UserContext(), uint(calendarID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(calendar)
+}
+
+func (ec *ExternalCalendarController) DeleteCalendar(c *fiber.Ctx) error {
+	calendarID, err := c.ParamsInt("id")
+	if err != nil || calendarID <= 0 {
+		return invalidParam("id")
+	}
+
+	if err := ec.Service.DeleteCalendar(c.UserContext(), uint(calendarID)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"booking-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthController struct {
+	Service services.HealthService
+}
+
+func NewHealthController(service services.HealthService) *HealthController {
+	return &HealthController{Service: service}
+}
+
+// Liveness answers as long as the process serves requests, so the orchestrator
+// only restarts it when it hangs.
+func (hc *HealthController) Liveness(c *fiber.Ctx) error {
+	return c.JSON(services.HealthReport{Status: services.HealthOK})
+}
+
+// Readiness answers 503 while a dependency fails or the server shuts down.
+func (hc *HealthController) Readiness(c *fiber.Ctx) error {
+	report, ready := hc.Service.Readiness()
+	if !ready {
+		c.Status(fiber.StatusServiceUnavailable)
+	}
+	return c.JSON(report)
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"booking-api/models"
+	"booking-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type NotificationController struct {
+	Service services.NotificationService
+}
+
+func NewNotificationController(service services.NotificationService) *NotificationController {
+	return &NotificationController{Service: service}
+}
+
+func (nc *NotificationController) GetMyPreferences(c *fiber.Ctx) error {
+	userID, ok := userIDFromToken(c)
+	if !ok {
+		return errInvalidClaims
+	}
+
+	preferences, err := nc.Service.GetPreferences(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(preferences)
+}
+
+func (nc *NotificationController) UpdateMyPreferences(c *fiber.Ctx) error {
+	userID, ok := userIDFromToken(c)
+	if !ok {
+		return errInvalidClaims
+	}
+
+	var input models.NotificationPreferences
+	if err := c.BodyParser(&input); err != nil {
+		return errInvalidInput
+	}
+	// Without an explicit locale, emails follow the language of the client.
+	if input.Locale == "" && c.Get(fiber.HeaderAcceptLanguage) != "" {
+		input.Locale = localeOf(c)
+	}
+
+	preferences, err := nc.Service.UpdatePreferences(c.UserContext(), userID, input)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(preferences)
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"booking-api/models"
+	"booking-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type QuotaController struct {
+	Service services.QuotaService
+}
+
+func NewQuotaController(service services.QuotaService) *QuotaController {
+	return &QuotaController{Service: service}
+}
+
+func (qc *QuotaController) GetMyQuota(c *fiber.Ctx) error {
+	userID, ok := userIDFromToken(c)
+	if !ok {
+		return errInvalidClaims
+	}
+
+	status, err := qc.Service.GetQuotaStatus(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(status)
+}
+
+func (qc *QuotaController) SetRoleQuota(c *fiber.Ctx) error {
+	var limits models.QuotaLimits
+	if err := c.BodyParser(&limits); err != nil {
+		return errInvalidInput
+	}
+
+	quota, err := qc.Service.SetRoleQuota(c.UserContext(), c.Params("role"), limits)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(quota)
+}
+
+func (qc *QuotaController) SetUserQuota(c *fiber.Ctx) error {
+	userID, err := c.ParamsInt("id")
+	if err != nil || userID <= 0 {
+		return invalidParam("id")
+	}
+
+	var limits models.QuotaLimits
+	if err := c.BodyParser(&limits); err != nil {
+		return errInvalidInput
+	}
+
+	quota, err := qc.Service.SetUserQuota(c.UserContext(), uint(userID), limits)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(quota)
+}
@@ -3,12 +3,10 @@ package controllers
 import (
 	"booking-api/models"
 	"booking-api/services"
-	"fmt"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v4"
 )
 
 type ReservationController struct {
@@ -21,119 +19,135 @@ func NewReservationController(service services.ReservationService) *ReservationC
 
 func (rc *ReservationController) CreateReservation(c *fiber.Ctx) error {
 
-	userToken := c.Locals("user").(*jwt.Token)
-	claims := userToken.Claims.(jwt.MapClaims)
-	userIDf, ok := claims["user_id"].(float64)
+	userID, ok := userIDFromToken(c)
 	if !ok {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid token claims"})
+		return errInvalidClaims
 	}
-	userID := uint(userIDf)
 
 	var input struct {
-		Date      string `json:"date"`
-		StartTime string `json:"start_time"`
-		EndTime   string `json:"end_time"`
+		ResourceID  uint   `json:"resource_id"`
+		ResourceIDs []uint `json:"resource_ids"`
+		Date        string `json:"date"`
+		StartTime   string `json:"start_time"`
+		EndTime     string `json:"end_time"`
 	}
 	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid input"})
+		return errInvalidInput
 	}
 
-	if _, err := time.Parse("2006-01-02", input.Date); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date format"})
-	}
-
-	start, err := time.Parse("15:04", input.StartTime)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid start_time format"})
-	}
-	end, err := time.Parse("15:04", input.EndTime)
-	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid end_time format"})
+	reservation := models.Reservation{
+		UserID:     userID,
+		ResourceID: input.ResourceID,
+		Date:       input.Date,
+		StartTime:  input.StartTime,
+		EndTime:    input.EndTime,
 	}
 
-	if start.Equal(end) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": fmt.Sprintf(
-				"The reservation start time (%s) and end time (%s) cannot be the same—duration must be at least 1 hour.",
-				input.StartTime, input.EndTime,
-			),
+	if len(input.ResourceIDs) > 0 {
+		if input.ResourceID != 0 {
+			return invalidParam("resource_ids")
+		}
+		reservations, err := rc.Service.CreateGroupReservation(c.UserContext(), &reservation, input.ResourceIDs)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+			"group_id":     reservations[0].GroupID,
+			"reservations": reservations,
 		})
 	}
 
-	if !start.Before(end) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": fmt.Sprintf(
-				"The reservation start time (%s) must be earlier than the end time (%s). For example, 10:00–11:00 is valid.",
-				input.StartTime, input.EndTime,
-			),
-		})
+	if err := rc.Service.CreateReservation(c.UserContext(), &reservation); err != nil {
+		return err
 	}
 
-	open, _ := time.Parse("15:04", "09:00")
-	close, _ := time.Parse("15:04", "18:00")
-	if start.Before(open) || end.After(close) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Reservation must be between 09:00 and 18:00",
-		})
+	return c.Status(fiber.StatusCreated).JSON(reservation)
+}
+
+func (rc *ReservationController) CancelReservation(c *fiber.Ctx) error {
+	userID, ok := userIDFromToken(c)
+	if !ok {
+		return errInvalidClaims
 	}
 
-	if end.Sub(start) < time.Hour {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Minimum reservation duration is 1 hour",
-		})
+	reservationID, err := c.ParamsInt("id")
+	if err != nil || reservationID <= 0 {
+		return invalidParam("id")
 	}
 
-	existing, err := rc.Service.GetReservationsByDate(input.Date)
+	reservations, err := rc.Service.CancelReservation(c.UserContext(), uint(reservationID), userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
-	}
-	for _, ex := range existing {
-		exStart, _ := time.Parse("15:04", ex.StartTime)
-		exEnd, _ := time.Parse("15:04", ex.EndTime)
-		if start.Before(exEnd) && end.After(exStart) {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Reservation time overlaps with existing reservation",
-			})
-		}
+		return err
 	}
 
-	reservation := models.Reservation{
-		UserID:    userID,
-		Date:      input.Date,
-		StartTime: start.Format("15:04"),
-		EndTime:   end.Format("15:04"),
+	return c.JSON(reservations)
+}
+
+func (rc *ReservationController) RescheduleReservation(c *fiber.Ctx) error {
+	userID, ok := userIDFromToken(c)
+	if !ok {
+		return errInvalidClaims
 	}
-	if err := rc.Service.CreateReservation(&reservation); err != nil {
 
-		if strings.Contains(err.Error(), "foreign key") {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "The user does not exist or is not authorized to create reservations.",
-			})
-		}
+	reservationID, err := c.ParamsInt("id")
+	if err != nil || reservationID <= 0 {
+		return invalidParam("id")
+	}
 
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Could not create the reservation",
-		})
+	var input struct {
+		Date      string `json:"date"`
+		StartTime string `json:"start_time"`
+		EndTime   string `json:"end_time"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errInvalidInput
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(reservation)
+	reservations, err := rc.Service.RescheduleReservation(c.UserContext(), uint(reservationID), userID, input.Date, input.StartTime, input.EndTime)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(reservations)
 }
 
 func (rc *ReservationController) GetReservationsByDate(c *fiber.Ctx) error {
 	date := c.Query("date")
 	if date == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "date query param is required"})
+		return missingParam("date")
 	}
 
 	_, err := time.Parse("2006-01-02", date)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid date format"})
+		return invalidParam("date")
 	}
 
-	reservations, err := rc.Service.GetReservationsByDate(date)
+	reservations, err := rc.Service.GetReservationsByDate(c.UserContext(), date)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(reservations)
 }
+
+func (rc *ReservationController) GetAvailability(c *fiber.Ctx) error {
+	date := c.Query("date")
+	if date == "" {
+		return missingParam("date")
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		return invalidParam("date")
+	}
+
+	resourceID, err := strconv.ParseUint(c.Query("resource_id", "0"), 10, 64)
+	if err != nil {
+		return invalidParam("resource_id")
+	}
+
+	slots, err := rc.Service.GetAvailability(c.UserContext(), uint(resourceID), date)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(slots)
+}
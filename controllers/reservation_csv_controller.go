@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"booking-api/models"
+	"booking-api/services"
+	"bufio"
+	"bytes"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReservationCSVController struct {
+	Service services.ReservationCSVService
+}
+
+func NewReservationCSVController(service services.ReservationCSVService) *ReservationCSVController {
+	return &ReservationCSVController{Service: service}
+}
+
+// ImportReservations imports a CSV file sent as the raw request body or as the
+// "file" field of a multipart form. With ?dry_run=true the rows are only
+// validated.
+func (rc *ReservationCSVController) ImportReservations(c *fiber.Ctx) error {
+	data, _, err := uploadedFile(c)
+	if err != nil {
+		return invalidParam("file")
+	}
+
+	dryRun := c.QueryBool("dry_run", false)
+	report, err := rc.Service.Import(c.UserContext(), bytes.NewReader(data), dryRun)
+	if err != nil {
+		return err
+	}
+	locale := localeOf(c)
+	for i, rowErr := range report.Errors {
+		if len(rowErr.Violations) > 0 {
+			report.Errors[i].Violations = localizeViolations(locale, rowErr.Violations)
+			report.Errors[i].Error = joinMessages(report.Errors[i].Violations)
+		}
+	}
+
+	switch {
+	case len(report.Errors) > 0:
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(report)
+	case dryRun:
+		return c.JSON(report)
+	}
+	return c.Status(fiber.StatusCreated).JSON(report)
+}
+
+// ExportReservations streams the reservations between the from and to dates
+// as CSV. Administrators export every reservation, other users their own.
+func (rc *ReservationCSVController) ExportReservations(c *fiber.Ctx) error {
+	userID, ok := userIDFromToken(c)
+	if !ok {
+		return errInvalidClaims
+	}
+
+	if format := c.Query("format", "csv"); format != "csv" {
+		return invalidParam("format")
+	}
+
+	from, to := c.Query("from"), c.Query("to")
+	if from == "" {
+		return missingParam("from")
+	}
+	if to == "" {
+		return missingParam("to")
+	}
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return invalidParam("from")
+	}
+	toDate, err := time.Parse("2006-01-02", to)
+	if err != nil {
+		return invalidParam("to")
+	}
+	if toDate.Before(fromDate) {
+		return &requestError{code: services.CodeInvalidTimeRange, field: "to"}
+	}
+
+	if roleFromToken(c) == models.RoleAdmin {
+		userID = 0
+	}
+
+	c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="reservations_`+from+`_`+to+`.csv"`)
+	// The writer runs after the handler returns, when c is no longer usable
+	// and the deadline of the request no longer applies.
+	ctx := context.WithoutCancel(c.UserContext())
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		// The status is already sent, so a failure can only cut the file short.
+		if err := rc.Service.Export(ctx, w, from, to, userID); err != nil {
+			slog.ErrorContext(ctx, "error al exportar reservas", "error", err)
+		}
+		w.Flush()
+	})
+	return nil
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"booking-api/models"
+	"booking-api/services"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ResourceController struct {
+	Service services.ResourceService
+}
+
+func NewResourceController(service services.ResourceService) *ResourceController {
+	return &ResourceController{Service: service}
+}
+
+func (rc *ResourceController) CreateResource(c *fiber.Ctx) error {
+	var input struct {
+		Name              string `json:"name"`
+		PreBufferMinutes  int    `json:"pre_buffer_minutes"`
+		PostBufferMinutes int    `json:"post_buffer_minutes"`
+		CheckInCode       string `json:"check_in_code"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errInvalidInput
+	}
+
+	resource := models.Resource{
+		Name:              input.Name,
+		PreBufferMinutes:  input.PreBufferMinutes,
+		PostBufferMinutes: input.PostBufferMinutes,
+		CheckInCode:       input.CheckInCode,
+	}
+	if err := rc.Service.CreateResource(c.UserContext(), &resource); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(resource)
+}
+
+func (rc *ResourceController) GetResources(c *fiber.Ctx) error {
+	resources, err := rc.Service.GetResources(c.UserContext())
+	if err != nil {
+		return err
+	}
+	return c.JSON(resources)
+}
+
+func (rc *ResourceController) AddRule(c *fiber.Ctx) error {
+	resourceID, err := c.ParamsInt("id")
+	if err != nil || resourceID <= 0 {
+		return invalidParam("id")
+	}
+
+	var input struct {
+		Kind  string `json:"kind"`
+		Value string `json:"value"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errInvalidInput
+	}
+
+	rule := models.BookingRule{
+		ResourceID: uint(resourceID),
+		Kind:       input.Kind,
+		Value:      input.Value,
+	}
+	if err := rc.Service.AddRule(c.UserContext(), &rule); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(rule)
+}
+
+func (rc *ResourceController) GetRules(c *fiber.Ctx) error {
+	resourceID, err := c.ParamsInt("id")
+	if err != nil || resourceID <= 0 {
+		return invalidParam("id")
+	}
+
+	rules, err := rc.Service.GetRules(c.UserContext(), uint(resourceID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(rules)
+}
+
+func (rc *ResourceController) UpdateRule(c *fiber.Ctx) error {
+	ruleID, err := c.ParamsInt("id")
+	if err != nil || ruleID <= 0 {
+		return invalidParam("id")
+	}
+
+	var input struct {
+		Kind  string `json:"kind"`
+		Value string `json:"value"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errInvalidInput
+	}
+
+	rule, err := rc.Service.UpdateRule(c.UserContext(), uint(ruleID), input.Kind, input.Value)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(rule)
+}
+
+func (rc *ResourceController) DeleteRule(c *fiber.Ctx) error {
+	ruleID, err := c.ParamsInt("id")
+	if err != nil || ruleID <= 0 {
+		return invalidParam("id")
+	}
+
+	if err := rc.Service.DeleteRule(c.UserContext(), uint(ruleID)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"booking-api/realtime"
+	"booking-api/services"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// streamHeartbeat keeps idle connections open through proxies and detects
+// clients that went away.
+const streamHeartbeat = 15 * time.Second
+
+type StreamController struct {
+	Service services.StreamService
+}
+
+func NewStreamController(service services.StreamService) *StreamController {
+	return &StreamController{Service: service}
+}
+
+// StreamReservations pushes reservation changes as Server-Sent Events. The
+// optional date and resource_id query params filter the events. Clients
+// reconnecting with Last-Event-ID first receive the events they missed.
+func (sc *StreamController) StreamReservations(c *fiber.Ctx) error {
+	var filter services.StreamFilter
+	if date := c.Query("date"); date != "" {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return invalidParam("date")
+		}
+		filter.Date = date
+	}
+	if value := c.Query("resource_id"); value != "" {
+		resourceID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return invalidParam("resource_id")
+		}
+		id := uint(resourceID)
+		filter.ResourceID = &id
+	}
+
+	// Subscribe before loading missed events so nothing falls in between;
+	// duplicates are skipped by ID.
+	events, unsubscribe := sc.Service.Subscribe(filter)
+
+	var missed []realtime.Event
+	if lastEventID := c.Get("Last-Event-ID"); lastEventID != "" {
+		afterID, err := strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			unsubscribe()
+			return invalidParam("Last-Event-ID")
+		}
+		missed, err = sc.Service.Missed(c.UserContext(), uint(afterID), filter)
+		if err != nil {
+			unsubscribe()
+			return err
+		}
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer unsubscribe()
+
+		var lastID uint
+		send := func(event realtime.Event) error {
+			if event.ID <= lastID {
+				return nil
+			}
+			lastID = event.ID
+			data, err := json.Marshal(event.Reservation)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+			return w.Flush()
+		}
+
+		fmt.Fprint(w, "retry: 3000\n\n")
+		if w.Flush() != nil {
+			return
+		}
+		for _, event := range missed {
+			if send(event) != nil {
+				return
+			}
+		}
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					// Dropped for falling behind; the client reconnects
+					// with Last-Event-ID.
+					return
+				}
+				if send(event) != nil {
+					return
+				}
+			case <-heartbeat.C:
+				fmt.Fprint(w, ": ping\n\n")
+				if w.Flush() != nil {
+					return
+				}
+			}
+		}
+	})
+	return nil
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// uploadedFile returns the "file" field of a multipart form with its name, or
+// the raw request body when there is no such field.
+func uploadedFile(c *fiber.Ctx) ([]byte, string, error) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Body(), "", nil
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, file.Filename, nil
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"booking-api/models"
+	"booking-api/services"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WebhookController struct {
+	Service services.WebhookService
+}
+
+func NewWebhookController(service services.WebhookService) *WebhookController {
+	return &WebhookController{Service: service}
+}
+
+// CreateSubscription registers a webhook. The signing secret is only returned
+// in this response.
+func (wc *WebhookController) CreateSubscription(c *fiber.Ctx) error {
+	var input struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+		Secret string   `json:"secret"`
+	}
+	if err := c.BodyParser(&input); err != nil {
+		return errInvalidInput
+	}
+
+	subscription := models.WebhookSubscription{
+		URL:    input.URL,
+		Events: strings.Join(input.Events, ","),
+		Secret: input.Secret,
+	}
+	if err := wc.Service.CreateSubscription(c.UserContext(), &subscription); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"webhook": subscription,
+		"secret":  subscription.Secret,
+	})
+}
+
+func (wc *WebhookController) GetSubscriptions(c *fiber.Ctx) error {
+	subscriptions, err := wc.Service.GetSubscriptions(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(subscriptions)
+}
+
+func (wc *WebhookController) DeleteSubscription(c *fiber.Ctx) error {
+	subscriptionID, err := c.ParamsInt("id")
+	if err != nil || subscriptionID <= 0 {
+		return invalidParam("id")
+	}
+
+	if err := wc.Service.DeleteSubscription(c.UserContext(), uint(subscriptionID)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (wc *WebhookController) GetDeliveries(c *fiber.Ctx) error {
+	subscriptionID, err := c.ParamsInt("id")
+	if err != nil || subscriptionID <= 0 {
+		return invalidParam("id")
+	}
+
+	deliveries, err := wc.Service.GetDeliveries(c.UserContext(), uint(subscriptionID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(deliveries)
+}
+
+func (wc *WebhookController) Redeliver(c *fiber.Ctx) error {
+	deliveryID, err := c.ParamsInt("id")
+	if err != nil || deliveryID <= 0 {
+		return invalidParam("id")
+	}
+
+	delivery, err := wc.Service.Redeliver(c.UserContext(), uint(deliveryID))
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(delivery)
+}
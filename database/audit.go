@@ -0,0 +1,61 @@
+package database
+
+import (
+	"booking-api/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// auditChainLock is the key of the PostgreSQL advisory lock serializing the
+// appends to the audit log, so two transactions cannot chain to the same entry.
+const auditChainLock = 0x61756469
+
+// ChainAudit makes every audit entry created through db carry the hash of the
+// entry before it, turning the audit log into a hash chain. The hash is taken
+// inside the transaction appending the entry, after locking the chain: on
+// SQLite by writing to the last entry, which takes the write lock of the
+// database even when the entry is the first change of its transaction; on
+// PostgreSQL appends wait for each other on an advisory lock until commit.
+func ChainAudit(db *gorm.DB) error {
+	return db.Callback().Create().Before("gorm:create").Register("audit:chain", chainAuditEntry)
+}
+
+func chainAuditEntry(db *gorm.DB) {
+	entry, ok := db.Statement.Dest.(*models.AuditEntry)
+	if !ok || db.Error != nil {
+		return
+	}
+
+	tx := db.Session(&gorm.Session{NewDB: true})
+	if err := lockAuditChain(tx); err != nil {
+		db.AddError(err)
+		return
+	}
+
+	var last []string
+	err := tx.Model(&models.AuditEntry{}).Where("hash <> ''").Order("id DESC").Limit(1).Pluck("hash", &last).Error
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+
+	// Databases keep microseconds at most, and the digest must match the
+	// stored time.
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now().UTC()
+	}
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+	entry.PrevHash = ""
+	if len(last) > 0 {
+		entry.PrevHash = last[0]
+	}
+	entry.Hash = entry.Digest()
+}
+
+func lockAuditChain(tx *gorm.DB) error {
+	if tx.Dialector.Name() == "postgres" {
+		return tx.Exec("SELECT pg_advisory_xact_lock(?)", auditChainLock).Error
+	}
+	return tx.Exec("UPDATE audit_entries SET id = id WHERE id = (SELECT MAX(id) FROM audit_entries)").Error
+}
@@ -2,10 +2,11 @@ package database
 
 import (
 	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 
 	"booking-api/config"
-	"booking-api/models"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/driver/sqlite"
@@ -18,7 +19,7 @@ var DB *gorm.DB
 func ConnectDatabase(cfg config.Config) error {
 	var err error
 
-	dsn := cfg.DBUrl
+	dsn := cfg.Database.URL
 	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
 		DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
 			Logger: logger.Default.LogMode(logger.Silent),
@@ -32,11 +33,90 @@ func ConnectDatabase(cfg config.Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to connect to database: %w", err)
 	}
-	fmt.Println("📦 Conectado a la base de datos correctamente")
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(time.Duration(cfg.Database.ConnMaxLifetimeMinutes) * time.Minute)
+
+	if err := Instrument(DB); err != nil {
+		return fmt.Errorf("failed to instrument database: %w", err)
+	}
+	if cfg.Audit.HashChain {
+		if err := ChainAudit(DB); err != nil {
+			return fmt.Errorf("failed to chain the audit log: %w", err)
+		}
+	}
+	slog.Info("conectado a la base de datos", "driver", DB.Dialector.Name())
+	return nil
+}
 
-	if err := DB.AutoMigrate(&models.User{}, &models.Reservation{}); err != nil {
+// PrepareSchema applies the pending migrations when migrateOnStart is set, and
+// otherwise refuses to go on while any is pending.
+func PrepareSchema(migrateOnStart bool) error {
+	migrator, err := NewMigrator(DB)
+	if err != nil {
+		return err
+	}
+
+	if !migrateOnStart {
+		pending, err := migrator.Pending()
+		if err != nil {
+			return err
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("%d pending migrations, run \"migrate up\" first", len(pending))
+		}
+		return nil
+	}
+
+	applied, err := migrator.Up()
+	if err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
-	fmt.Println("✅ Migraciones completadas")
+	slog.Info("migraciones completadas", "applied", len(applied))
 	return nil
 }
+
+// Ping checks that the database answers.
+func Ping() error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
+}
+
+// CheckMigrations fails while a migration of this build is not applied.
+// Unlike Migrator.Pending it only reads, so it suits frequent probes.
+func CheckMigrations() error {
+	migrations, err := LoadMigrations(DB.Dialector.Name())
+	if err != nil {
+		return err
+	}
+	done, err := appliedVersions(DB)
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for _, m := range migrations {
+		if _, ok := done[m.Version]; !ok {
+			pending++
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("%d pending migrations", pending)
+	}
+	return nil
+}
+
+// Close closes the connection pool once nothing uses it anymore.
+func Close() error {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
@@ -0,0 +1,56 @@
+package database
+
+import (
+	"booking-api/metrics"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const queryStartKey = "metrics:start"
+
+// Instrument times and traces every query of db and exports the statistics of
+// its connection pool.
+func Instrument(db *gorm.DB) error {
+	callbacks := db.Callback()
+	err := errors.Join(
+		traceQueries(db),
+		callbacks.Create().Before("gorm:create").Register("metrics:before_create", startQuery),
+		callbacks.Create().After("gorm:create").Register("metrics:after_create", endQuery("create")),
+		callbacks.Query().Before("gorm:query").Register("metrics:before_query", startQuery),
+		callbacks.Query().After("gorm:query").Register("metrics:after_query", endQuery("query")),
+		callbacks.Update().Before("gorm:update").Register("metrics:before_update", startQuery),
+		callbacks.Update().After("gorm:update").Register("metrics:after_update", endQuery("update")),
+		callbacks.Delete().Before("gorm:delete").Register("metrics:before_delete", startQuery),
+		callbacks.Delete().After("gorm:delete").Register("metrics:after_delete", endQuery("delete")),
+		callbacks.Row().Before("gorm:row").Register("metrics:before_row", startQuery),
+		callbacks.Row().After("gorm:row").Register("metrics:after_row", endQuery("row")),
+		callbacks.Raw().Before("gorm:raw").Register("metrics:before_raw", startQuery),
+		callbacks.Raw().After("gorm:raw").Register("metrics:after_raw", endQuery("raw")),
+	)
+	if err != nil {
+		return err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return metrics.WatchPool(sqlDB, db.Dialector.Name())
+}
+
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(queryStartKey, time.Now())
+}
+
+func endQuery(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		value, ok := db.InstanceGet(queryStartKey)
+		if !ok {
+			return
+		}
+		failed := db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound)
+		metrics.ObserveQuery(operation, db.Statement.Table, time.Since(value.(time.Time)), failed)
+	}
+}
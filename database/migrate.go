@@ -0,0 +1,342 @@
+package database
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migrations live in migrations/<dialect>/<version>_<name>.<up|down>.sql.
+// Every dialect must have the same versions.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// Migration is a numbered schema change with the SQL to apply and revert it.
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus tells whether a migration is applied, and when.
+type MigrationStatus struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the embedded migrations of the dialect of db. Applied
+// versions are recorded in schema_migrations; every step runs in its own
+// transaction holding the row of schema_migrations_lock, so instances starting
+// at once apply each migration only once.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+func NewMigrator(db *gorm.DB) (*Migrator, error) {
+	migrations, err := LoadMigrations(db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// LoadMigrations reads the migrations of a dialect ("sqlite" or "postgres")
+// sorted by version.
+func LoadMigrations(dialect string) ([]Migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for %s", dialect)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := cutDirection(name)
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+		number, title, found := strings.Cut(base, "_")
+		version, err := strconv.Atoi(number)
+		if !found || err != nil {
+			return nil, fmt.Errorf("invalid migration file name %s", name)
+		}
+		data, err := fs.ReadFile(migrationFiles, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: title}
+			byVersion[version] = m
+		}
+		if m.Name != title {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, title)
+		}
+		if direction == "up" {
+			m.Up = string(data)
+		} else {
+			m.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+func cutDirection(name string) (base, direction string, ok bool) {
+	for _, direction := range []string{"up", "down"} {
+		if base, found := strings.CutSuffix(name, "."+direction+".sql"); found {
+			return base, direction, true
+		}
+	}
+	return "", "", false
+}
+
+// Up applies every pending migration in order and returns them.
+func (m *Migrator) Up() ([]Migration, error) {
+	var applied []Migration
+	for {
+		var next *Migration
+		err := m.step(func(tx *gorm.DB, done map[int]time.Time) error {
+			for i := range m.migrations {
+				if _, ok := done[m.migrations[i].Version]; !ok {
+					next = &m.migrations[i]
+					break
+				}
+			}
+			if next == nil {
+				return nil
+			}
+			if err := adoptTables(tx, next.Up); err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %v", next.Version, next.Name, err)
+			}
+			if err := execScript(tx, next.Up); err != nil {
+				return fmt.Errorf("migration %04d_%s failed: %v", next.Version, next.Name, err)
+			}
+			return tx.Exec("INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)",
+				next.Version, next.Name, time.Now().UTC()).Error
+		})
+		if err != nil {
+			return applied, err
+		}
+		if next == nil {
+			return applied, nil
+		}
+		applied = append(applied, *next)
+	}
+}
+
+// Down reverts the last steps applied migrations and returns them.
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var reverted []Migration
+	for ; steps > 0; steps-- {
+		var last *Migration
+		err := m.step(func(tx *gorm.DB, done map[int]time.Time) error {
+			latest := -1
+			for version := range done {
+				if version > latest {
+					latest = version
+				}
+			}
+			if latest < 0 {
+				return nil
+			}
+			for i := range m.migrations {
+				if m.migrations[i].Version == latest {
+					last = &m.migrations[i]
+				}
+			}
+			if last == nil {
+				return fmt.Errorf("applied migration %d is unknown to this build", latest)
+			}
+			if err := execScript(tx, last.Down); err != nil {
+				return fmt.Errorf("reverting migration %04d_%s failed: %v", last.Version, last.Name, err)
+			}
+			return tx.Exec("DELETE FROM schema_migrations WHERE version = ?", last.Version).Error
+		})
+		if err != nil {
+			return reverted, err
+		}
+		if last == nil {
+			break
+		}
+		reverted = append(reverted, *last)
+	}
+	return reverted, nil
+}
+
+// Status lists every migration with the time it was applied, if it was.
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	if err := m.ensureTables(); err != nil {
+		return nil, err
+	}
+	done, err := appliedVersions(m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	status := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		status[i].Migration = migration
+		if at, ok := done[migration.Version]; ok {
+			status[i].AppliedAt = &at
+		}
+	}
+	return status, nil
+}
+
+// Pending returns the migrations not applied yet.
+func (m *Migrator) Pending() ([]Migration, error) {
+	status, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, s := range status {
+		if s.AppliedAt == nil {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// step runs fn in a transaction that holds the migration lock, with the
+// versions applied when the lock was taken.
+func (m *Migrator) step(fn func(tx *gorm.DB, done map[int]time.Time) error) error {
+	if err := m.ensureTables(); err != nil {
+		return err
+	}
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		// Writing the row blocks other migrators until this transaction ends.
+		if err := tx.Exec("UPDATE schema_migrations_lock SET locked_at = ? WHERE id = 1", time.Now().UTC()).Error; err != nil {
+			return fmt.Errorf("failed to take the migration lock: %v", err)
+		}
+		done, err := appliedVersions(tx)
+		if err != nil {
+			return err
+		}
+		return fn(tx, done)
+	})
+}
+
+func (m *Migrator) ensureTables() error {
+	return execScript(m.db, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version bigint PRIMARY KEY,
+    name text NOT NULL,
+    applied_at timestamp NOT NULL
+);
+CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+    id integer PRIMARY KEY,
+    locked_at timestamp
+);
+INSERT INTO schema_migrations_lock (id) VALUES (1) ON CONFLICT (id) DO NOTHING;
+`)
+}
+
+func appliedVersions(db *gorm.DB) (map[int]time.Time, error) {
+	var rows []struct {
+		Version   int
+		AppliedAt time.Time
+	}
+	if err := db.Raw("SELECT version, applied_at FROM schema_migrations").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %v", err)
+	}
+	done := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		done[row.Version] = row.AppliedAt
+	}
+	return done, nil
+}
+
+// execScript runs the statements of a script one by one, as not every driver
+// accepts several in one call. Statements end with a semicolon at the end of
+// a line.
+func execScript(db *gorm.DB, script string) error {
+	for _, statement := range strings.Split(script, ";\n") {
+		statement = strings.TrimSuffix(strings.TrimSpace(statement), ";")
+		if stripComments(statement) == "" {
+			continue
+		}
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createTable matches the tables a script creates only if they do not exist,
+// with the column definitions, one per line, between the parentheses.
+var createTable = regexp.MustCompile(`(?s)CREATE TABLE IF NOT EXISTS (\w+) \((.*?)\n\);`)
+
+// adoptTables adds to tables the script creates "IF NOT EXISTS" the columns
+// they lack. Databases created by AutoMigrate before the migrations existed
+// already have some of those tables, with the columns of an older model, and
+// the rest of the script expects the current ones. Existing rows take the
+// column defaults. Table constraints are not added.
+func adoptTables(tx *gorm.DB, script string) error {
+	for _, match := range createTable.FindAllStringSubmatch(script, -1) {
+		table := match[1]
+		if !tx.Migrator().HasTable(table) {
+			continue
+		}
+		columns, err := tx.Migrator().ColumnTypes(table)
+		if err != nil {
+			return err
+		}
+		existing := make(map[string]bool, len(columns))
+		for _, column := range columns {
+			existing[strings.ToLower(column.Name())] = true
+		}
+
+		for _, line := range strings.Split(stripComments(match[2]), "\n") {
+			definition := strings.TrimSuffix(line, ",")
+			name := strings.ToLower(strings.Fields(definition)[0])
+			if existing[name] || isTableConstraint(name) {
+				continue
+			}
+			if err := tx.Exec("ALTER TABLE " + table + " ADD COLUMN " + definition).Error; err != nil {
+				return fmt.Errorf("adding %s.%s: %v", table, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func isTableConstraint(word string) bool {
+	switch word {
+	case "constraint", "primary", "foreign", "unique", "check":
+		return true
+	}
+	return false
+}
+
+func stripComments(statement string) string {
+	var lines []string
+	for _, line := range strings.Split(statement, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "--") {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,12 @@
+DROP TABLE IF EXISTS notification_preferences;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS outbox_events;
+DROP TABLE IF EXISTS webhook_subscriptions;
+DROP TABLE IF EXISTS external_blocks;
+DROP TABLE IF EXISTS external_calendars;
+DROP TABLE IF EXISTS quota;
+DROP TABLE IF EXISTS reservations;
+DROP TABLE IF EXISTS booking_groups;
+DROP TABLE IF EXISTS booking_rules;
+DROP TABLE IF EXISTS resources;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,181 @@
+-- Schema previously created by AutoMigrate. On databases created that way,
+-- IF NOT EXISTS keeps the existing tables and the migrator first adds the
+-- columns they lack (see adoptTables in database/migrate.go).
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    email text,
+    password text,
+    role text DEFAULT 'user',
+    no_show_count bigint NOT NULL DEFAULT 0,
+    feed_token text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_feed_token ON users (feed_token);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS resources (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    pre_buffer_minutes bigint,
+    post_buffer_minutes bigint,
+    check_in_code text,
+    feed_token text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_resources_feed_token ON resources (feed_token);
+CREATE INDEX IF NOT EXISTS idx_resources_deleted_at ON resources (deleted_at);
+
+CREATE TABLE IF NOT EXISTS booking_rules (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    resource_id bigint,
+    kind text,
+    value text
+);
+CREATE INDEX IF NOT EXISTS idx_booking_rules_resource_id ON booking_rules (resource_id);
+CREATE INDEX IF NOT EXISTS idx_booking_rules_deleted_at ON booking_rules (deleted_at);
+
+CREATE TABLE IF NOT EXISTS booking_groups (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint
+);
+CREATE INDEX IF NOT EXISTS idx_booking_groups_deleted_at ON booking_groups (deleted_at);
+
+CREATE TABLE IF NOT EXISTS reservations (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint,
+    -- Reservations kept from before resources existed get resource 0, so
+    -- they still hold their slot once the column is added.
+    resource_id bigint NOT NULL DEFAULT 0,
+    group_id bigint,
+    date text,
+    start_time text,
+    end_time text,
+    status text DEFAULT 'confirmed',
+    checked_in_at timestamptz,
+    sequence bigint NOT NULL DEFAULT 0,
+    reminded_at timestamptz,
+    CONSTRAINT fk_users_reservations FOREIGN KEY (user_id) REFERENCES users (id),
+    CONSTRAINT fk_booking_groups_reservations FOREIGN KEY (group_id) REFERENCES booking_groups (id)
+);
+CREATE INDEX IF NOT EXISTS idx_reservations_resource_id ON reservations (resource_id);
+CREATE INDEX IF NOT EXISTS idx_reservations_group_id ON reservations (group_id);
+CREATE INDEX IF NOT EXISTS idx_reservations_status ON reservations (status);
+CREATE INDEX IF NOT EXISTS idx_reservations_deleted_at ON reservations (deleted_at);
+
+CREATE TABLE IF NOT EXISTS quota (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    role text,
+    user_id bigint,
+    hours_per_week bigint,
+    max_active_reservations bigint,
+    bookings_per_day bigint
+);
+CREATE INDEX IF NOT EXISTS idx_quota_role ON quota (role);
+CREATE INDEX IF NOT EXISTS idx_quota_user_id ON quota (user_id);
+CREATE INDEX IF NOT EXISTS idx_quota_deleted_at ON quota (deleted_at);
+
+CREATE TABLE IF NOT EXISTS external_calendars (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    resource_id bigint,
+    name text,
+    url text,
+    file_path text,
+    last_synced_at timestamptz,
+    last_error text,
+    block_count bigint
+);
+CREATE INDEX IF NOT EXISTS idx_external_calendars_resource_id ON external_calendars (resource_id);
+CREATE INDEX IF NOT EXISTS idx_external_calendars_deleted_at ON external_calendars (deleted_at);
+
+CREATE TABLE IF NOT EXISTS external_blocks (
+    id bigserial PRIMARY KEY,
+    calendar_id bigint,
+    resource_id bigint,
+    date text,
+    start_time text,
+    end_time text,
+    uid text,
+    summary text
+);
+CREATE INDEX IF NOT EXISTS idx_external_blocks_calendar_id ON external_blocks (calendar_id);
+CREATE INDEX IF NOT EXISTS idx_external_blocks_slot ON external_blocks (resource_id, date);
+
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    url text,
+    events text,
+    secret text
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_subscriptions_deleted_at ON webhook_subscriptions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    type text,
+    payload text,
+    processed_at timestamptz,
+    notified_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_processed_at ON outbox_events (processed_at);
+CREATE INDEX IF NOT EXISTS idx_outbox_events_notified_at ON outbox_events (notified_at);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    subscription_id bigint,
+    event_id bigint,
+    event_type text,
+    payload text,
+    status text DEFAULT 'pending',
+    attempts bigint NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz,
+    last_attempt_at timestamptz,
+    response_status bigint,
+    last_error text
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_subscription_id ON webhook_deliveries (subscription_id);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status ON webhook_deliveries (status);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_deleted_at ON webhook_deliveries (deleted_at);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    id bigserial PRIMARY KEY,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    user_id bigint,
+    confirmation boolean,
+    change boolean,
+    cancellation boolean,
+    reminder boolean,
+    reminder_minutes bigint,
+    locale text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_notification_preferences_user_id ON notification_preferences (user_id);
+CREATE INDEX IF NOT EXISTS idx_notification_preferences_deleted_at ON notification_preferences (deleted_at);
@@ -0,0 +1,18 @@
+CREATE TABLE audit_entries (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    actor_id integer,
+    action text,
+    target_type text,
+    target_id integer,
+    changes text,
+    ip text,
+    user_agent text,
+    request_id text,
+    prev_hash text,
+    hash text
+);
+CREATE INDEX idx_audit_entries_created_at ON audit_entries (created_at);
+CREATE INDEX idx_audit_entries_actor_id ON audit_entries (actor_id);
+CREATE INDEX idx_audit_entries_action ON audit_entries (action);
+CREATE INDEX idx_audit_entries_target ON audit_entries (target_type, target_id);
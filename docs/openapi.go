@@ -0,0 +1,453 @@
+// Package docs builds the OpenAPI document of the API. Schemas are reflected
+// from the models so they follow the JSON the handlers actually return; the
+// operations table below must list every route registered in routes.Setup.
+package docs
+
+import (
+	"booking-api/i18n"
+	"booking-api/models"
+	"booking-api/services"
+	"sort"
+	"strings"
+)
+
+// Security requirements of an operation.
+const (
+	public = iota
+	authenticated
+	adminOnly
+	streamToken
+)
+
+type parameter struct {
+	name        string
+	in          string
+	description string
+	schema      map[string]interface{}
+	required    bool
+}
+
+type operation struct {
+	method      string
+	path        string
+	tag         string
+	summary     string
+	security    int
+	parameters  []parameter
+	body        interface{}
+	bodyType    string
+	status      string
+	response    interface{}
+	contentType string
+	errors      []string
+	// rejected is the body of 422 responses that are not problems.
+	rejected interface{}
+}
+
+// oneOf documents a response with several possible shapes.
+type oneOf []interface{}
+
+// Request payloads read by the handlers into anonymous structs.
+type (
+	RegisterRequest struct {
+		Name     string `json:"name"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	LoginRequest struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	TokenResponse struct {
+		Token string `json:"token"`
+	}
+	ReservationRequest struct {
+		ResourceID  uint   `json:"resource_id,omitempty"`
+		ResourceIDs []uint `json:"resource_ids,omitempty"`
+		Date        string `json:"date"`
+		StartTime   string `json:"start_time"`
+		EndTime     string `json:"end_time"`
+	}
+	GroupReservation struct {
+		GroupID      uint                 `json:"group_id"`
+		Reservations []models.Reservation `json:"reservations"`
+	}
+	RescheduleRequest struct {
+		Date      string `json:"date"`
+		StartTime string `json:"start_time"`
+		EndTime   string `json:"end_time"`
+	}
+	CheckInRequest struct {
+		Code string `json:"code,omitempty"`
+	}
+	ResourceRequest struct {
+		Name              string `json:"name"`
+		PreBufferMinutes  int    `json:"pre_buffer_minutes,omitempty"`
+		PostBufferMinutes int    `json:"post_buffer_minutes,omitempty"`
+		CheckInCode       string `json:"check_in_code,omitempty"`
+	}
+	RuleRequest struct {
+		Kind  string `json:"kind"`
+		Value string `json:"value"`
+	}
+	ExternalCalendarRequest struct {
+		Name     string `json:"name"`
+		URL      string `json:"url,omitempty"`
+		FilePath string `json:"file_path,omitempty"`
+	}
+	FeedURL struct {
+		URL string `json:"url"`
+	}
+	WebhookRequest struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+		Secret string   `json:"secret,omitempty"`
+	}
+	WebhookCreated struct {
+		Webhook models.WebhookSubscription `json:"webhook"`
+		Secret  string                     `json:"secret"`
+	}
+)
+
+// Problem mirrors controllers.Problem, the RFC 9457 body of every error.
+type Problem struct {
+	Type       string               `json:"type"`
+	Title      string               `json:"title"`
+	Status     int                  `json:"status"`
+	Detail     string               `json:"detail,omitempty"`
+	Instance   string               `json:"instance,omitempty"`
+	Code       string               `json:"code"`
+	RequestID  string               `json:"request_id,omitempty"`
+	Violations []services.Violation `json:"violations,omitempty"`
+}
+
+func pathParam(name, description string) parameter {
+	return parameter{name: name, in: "path", description: description, schema: map[string]interface{}{"type": "integer", "minimum": 1}, required: true}
+}
+
+func queryParam(name, description, format string, required bool) parameter {
+	schema := map[string]interface{}{"type": "string"}
+	if format != "" {
+		schema["format"] = format
+	}
+	return parameter{name: name, in: "query", description: description, schema: schema, required: required}
+}
+
+func idQuery(name, description string) parameter {
+	return parameter{name: name, in: "query", description: description, schema: map[string]interface{}{"type": "integer", "minimum": 1}}
+}
+
+var (
+	reservationID = pathParam("id", "Reservation ID")
+	resourceID    = pathParam("id", "Resource ID")
+	dateQuery     = queryParam("date", "Day in YYYY-MM-DD format", "date", true)
+)
+
+var operations = []operation{
+	{method: "get", path: "/openapi.json", tag: "docs", summary: "This OpenAPI document", status: "200", contentType: "application/json"},
+	{method: "get", path: "/docs", tag: "docs", summary: "Interactive API documentation", status: "200", contentType: "text/html"},
+
+	{method: "post", path: "/register", tag: "auth", summary: "Register a user and return a token", body: RegisterRequest{}, status: "200", response: TokenResponse{}, errors: []string{"400", "409", "422"}},
+	{method: "post", path: "/login", tag: "auth", summary: "Log in and return a token", body: LoginRequest{}, status: "200", response: TokenResponse{}, errors: []string{"400", "401"}},
+	{method: "get", path: "/feeds/{token}/calendar.ics", tag: "calendars", summary: "Subscribable iCalendar feed of a user or resource",
+		parameters: []parameter{{name: "token", in: "path", description: "Secret feed token", schema: map[string]interface{}{"type": "string"}, required: true}},
+		status:     "200", contentType: "text/calendar", errors: []string{"404"}},
+
+	{method: "get", path: "/reservations/stream", tag: "reservations", summary: "Live reservation changes as Server-Sent Events", security: streamToken,
+		parameters: []parameter{
+			queryParam("date", "Only events of this day", "date", false),
+			{name: "resource_id", in: "query", description: "Only events of this resource", schema: map[string]interface{}{"type": "integer", "minimum": 1}},
+			{name: "Last-Event-ID", in: "header", description: "Replay the events after this ID", schema: map[string]interface{}{"type": "integer"}},
+		},
+		status: "200", contentType: "text/event-stream", errors: []string{"400"}},
+	{method: "post", path: "/reservations", tag: "reservations", summary: "Book one resource, or several at once with resource_ids", security: authenticated,
+		body: ReservationRequest{}, status: "201", response: oneOf{models.Reservation{}, GroupReservation{}}, errors: []string{"400", "404", "409", "422"}},
+	{method: "get", path: "/reservations", tag: "reservations", summary: "Reservations of a day", security: authenticated,
+		parameters: []parameter{dateQuery}, status: "200", response: []models.Reservation{}, errors: []string{"400"}},
+	{method: "get", path: "/reservations/availability", tag: "reservations", summary: "Free slots of a resource on a day", security: authenticated,
+		parameters: []parameter{dateQuery, {name: "resource_id", in: "query", schema: map[string]interface{}{"type": "integer", "minimum": 0}}},
+		status:     "200", response: []models.TimeSlot{}, errors: []string{"400", "404"}},
+	{method: "get", path: "/reservations/export", tag: "reservations", summary: "Export reservations as CSV; administrators get every user's", security: authenticated,
+		parameters: []parameter{
+			queryParam("from", "First day", "date", true),
+			queryParam("to", "Last day", "date", true),
+			queryParam("format", "Only csv is supported", "", false),
+		},
+		status: "200", contentType: "text/csv", errors: []string{"400", "422"}},
+	{method: "get", path: "/reservations/{id}.ics", tag: "calendars", summary: "A reservation as an iCalendar file", security: authenticated,
+		parameters: []parameter{reservationID}, status: "200", contentType: "text/calendar", errors: []string{"400", "403", "404"}},
+	{method: "post", path: "/reservations/{id}/check-in", tag: "reservations", summary: "Check in to a reservation", security: authenticated,
+		parameters: []parameter{reservationID}, body: CheckInRequest{}, status: "200", response: models.Reservation{}, errors: []string{"400", "403", "404", "409", "422"}},
+	{method: "post", path: "/reservations/{id}/cancel", tag: "reservations", summary: "Cancel a reservation and the rest of its group", security: authenticated,
+		parameters: []parameter{reservationID}, status: "200", response: []models.Reservation{}, errors: []string{"400", "403", "404", "409"}},
+	{method: "patch", path: "/reservations/{id}", tag: "reservations", summary: "Reschedule a reservation and the rest of its group", security: authenticated,
+		parameters: []parameter{reservationID}, body: RescheduleRequest{}, status: "200", response: []models.Reservation{}, errors: []string{"400", "403", "404", "409", "422"}},
+
+	{method: "post", path: "/resources", tag: "resources", summary: "Create a resource", security: adminOnly,
+		body: ResourceRequest{}, status: "201", response: models.Resource{}, errors: []string{"400", "422"}},
+	{method: "get", path: "/resources", tag: "resources", summary: "List resources", security: authenticated,
+		status: "200", response: []models.Resource{}},
+	{method: "post", path: "/resources/{id}/rules", tag: "resources", summary: "Add a booking rule to a resource", security: adminOnly,
+		parameters: []parameter{resourceID}, body: RuleRequest{}, status: "201", response: models.BookingRule{}, errors: []string{"400", "404", "422"}},
+	{method: "get", path: "/resources/{id}/rules", tag: "resources", summary: "Booking rules of a resource", security: adminOnly,
+		parameters: []parameter{resourceID}, status: "200", response: []models.BookingRule{}, errors: []string{"400", "404"}},
+	{method: "get", path: "/resources/{id}/feed", tag: "calendars", summary: "Feed URL of a resource", security: adminOnly,
+		parameters: []parameter{resourceID}, status: "200", response: FeedURL{}, errors: []string{"400", "404"}},
+	{method: "post", path: "/resources/{id}/feed", tag: "calendars", summary: "Rotate the feed URL of a resource", security: adminOnly,
+		parameters: []parameter{resourceID}, status: "200", response: FeedURL{}, errors: []string{"400", "404"}},
+	{method: "get", path: "/resources/{id}/calendars", tag: "external calendars", summary: "External calendars blocking a resource", security: adminOnly,
+		parameters: []parameter{resourceID}, status: "200", response: []models.ExternalCalendar{}, errors: []string{"400", "404"}},
+	{method: "post", path: "/resources/{id}/calendars", tag: "external calendars", summary: "Subscribe a resource to an iCalendar URL or file", security: adminOnly,
+		parameters: []parameter{resourceID}, body: ExternalCalendarRequest{}, status: "201", response: models.ExternalCalendar{}, errors: []string{"400", "404", "422", "502"}},
+	{method: "post", path: "/resources/{id}/calendars/upload", tag: "external calendars", summary: "Import an iCalendar file once", security: adminOnly,
+		parameters: []parameter{resourceID, queryParam("name", "Name of the calendar", "", false)},
+		bodyType:   "text/calendar", status: "201", response: models.ExternalCalendar{}, errors: []string{"400", "404", "422"}},
+
+	{method: "post", path: "/calendars/{id}/sync", tag: "external calendars", summary: "Sync an external calendar now", security: adminOnly,
+		parameters: []parameter{pathParam("id", "External calendar ID")}, status: "200", response: models.ExternalCalendar{}, errors: []string{"400", "404", "409", "422", "502"}},
+	{method: "delete", path: "/calendars/{id}", tag: "external calendars", summary: "Remove an external calendar and its blocks", security: adminOnly,
+		parameters: []parameter{pathParam("id", "External calendar ID")}, status: "204", errors: []string{"400", "404"}},
+
+	{method: "get", path: "/me/quota", tag: "me", summary: "Quota usage of the current user", security: authenticated,
+		status: "200", response: models.QuotaStatus{}},
+	{method: "get", path: "/me/feed", tag: "calendars", summary: "Feed URL of the current user", security: authenticated,
+		status: "200", response: FeedURL{}},
+	{method: "post", path: "/me/feed", tag: "calendars", summary: "Rotate the feed URL of the current user", security: authenticated,
+		status: "200", response: FeedURL{}},
+	{method: "get", path: "/me/notifications", tag: "me", summary: "Email preferences of the current user", security: authenticated,
+		status: "200", response: models.NotificationPreferences{}},
+	{method: "put", path: "/me/notifications", tag: "me", summary: "Update the email preferences of the current user", security: authenticated,
+		body: models.NotificationPreferences{}, status: "200", response: models.NotificationPreferences{}, errors: []string{"400", "422"}},
+
+	{method: "post", path: "/admin/reservations/import", tag: "reservations", summary: "Import reservations from CSV, all or nothing", security: adminOnly,
+		parameters: []parameter{{name: "dry_run", in: "query", description: "Only validate the rows", schema: map[string]interface{}{"type": "boolean"}}},
+		bodyType:   "text/csv", status: "201", response: services.ImportReport{}, errors: []string{"400", "422"},
+		rejected: services.ImportReport{}},
+	{method: "put", path: "/admin/rules/{id}", tag: "resources", summary: "Change a booking rule", security: adminOnly,
+		parameters: []parameter{pathParam("id", "Rule ID")}, body: RuleRequest{}, status: "200", response: models.BookingRule{}, errors: []string{"400", "404", "422"}},
+	{method: "delete", path: "/admin/rules/{id}", tag: "resources", summary: "Remove a booking rule, so the default of its kind applies again", security: adminOnly,
+		parameters: []parameter{pathParam("id", "Rule ID")}, status: "204", errors: []string{"400", "404"}},
+
+	{method: "post", path: "/webhooks", tag: "webhooks", summary: "Subscribe a URL to reservation events", security: adminOnly,
+		body: WebhookRequest{}, status: "201", response: WebhookCreated{}, errors: []string{"400", "422"}},
+	{method: "get", path: "/webhooks", tag: "webhooks", summary: "List webhook subscriptions", security: adminOnly,
+		status: "200", response: []models.WebhookSubscription{}},
+	{method: "delete", path: "/webhooks/{id}", tag: "webhooks", summary: "Delete a webhook subscription", security: adminOnly,
+		parameters: []parameter{pathParam("id", "Webhook ID")}, status: "204", errors: []string{"400", "404"}},
+	{method: "get", path: "/webhooks/{id}/deliveries", tag: "webhooks", summary: "Delivery log of a webhook", security: adminOnly,
+		parameters: []parameter{pathParam("id", "Webhook ID")}, status: "200", response: []models.WebhookDelivery{}, errors: []string{"400", "404"}},
+	{method: "post", path: "/webhooks/deliveries/{id}/redeliver", tag: "webhooks", summary: "Send a delivery again", security: adminOnly,
+		parameters: []parameter{pathParam("id", "Delivery ID")}, status: "200", response: models.WebhookDelivery{}, errors: []string{"400", "404"}},
+
+	{method: "put", path: "/quotas/roles/{role}", tag: "quotas", summary: "Set the quota of a role", security: adminOnly,
+		parameters: []parameter{{name: "role", in: "path", schema: map[string]interface{}{"type": "string", "enum": []string{models.RoleUser, models.RoleAdmin}}, required: true}},
+		body:       models.QuotaLimits{}, status: "200", response: models.Quota{}, errors: []string{"400", "422"}},
+	{method: "put", path: "/quotas/users/{id}", tag: "quotas", summary: "Set the quota of a user", security: adminOnly,
+		parameters: []parameter{pathParam("id", "User ID")}, body: models.QuotaLimits{}, status: "200", response: models.Quota{}, errors: []string{"400", "404", "422"}},
+
+	{method: "get", path: "/audit", tag: "audit", summary: "Audit log of reservation and account changes, newest first", security: adminOnly,
+		parameters: []parameter{
+			idQuery("actor_id", "User who made the change"),
+			queryParam("action", "Action, such as reservation.cancelled or auth.login_failed", "", false),
+			{name: "target_type", in: "query", schema: map[string]interface{}{"type": "string", "enum": []string{models.AuditTargetReservation, models.AuditTargetUser}}},
+			idQuery("target_id", "ID of the changed reservation or user"),
+			queryParam("from", "Entries from this time, RFC 3339 or YYYY-MM-DD", "", false),
+			queryParam("to", "Entries before this time, RFC 3339 or YYYY-MM-DD", "", false),
+			idQuery("before_id", "Entries older than this one, to read the next page"),
+			{name: "limit", in: "query", description: "Page size", schema: map[string]interface{}{"type": "integer", "minimum": 1, "maximum": services.MaxAuditLimit, "default": services.DefaultAuditLimit}},
+		},
+		status: "200", response: []models.AuditEntry{}, errors: []string{"400", "422"}},
+	{method: "get", path: "/audit/verify", tag: "audit", summary: "Check the hash chain of the audit log", security: adminOnly,
+		status: "200", response: services.AuditVerification{}},
+}
+
+var errorDescriptions = map[string]string{
+	"400": "Invalid request",
+	"401": "Missing or invalid token",
+	"403": "Not allowed",
+	"404": "Not found",
+	"409": "Conflicts with the current state, such as an overlapping reservation",
+	"422": "Breaks a validation or booking rule",
+	"502": "The external calendar could not be fetched",
+}
+
+// Spec returns the OpenAPI 3.1 document of the API.
+func Spec() map[string]interface{} {
+	b := &schemaBuilder{components: map[string]interface{}{}}
+	problemSchema := b.ref(Problem{})
+
+	paths := map[string]interface{}{}
+	for _, op := range operations {
+		item, ok := paths[op.path].(map[string]interface{})
+		if !ok {
+			item = map[string]interface{}{}
+			paths[op.path] = item
+		}
+		item[op.method] = b.operation(op, problemSchema)
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.1.0",
+		"info": map[string]interface{}{
+			"title":       "Booking API",
+			"version":     "1.0.0",
+			"description": "API de reservas de recursos con autenticación JWT.",
+		},
+		"servers": []interface{}{
+			map[string]interface{}{"url": "/v1", "description": "Version 1. The unversioned paths are deprecated aliases."},
+		},
+		"tags":  tags(),
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": b.components,
+			"parameters": map[string]interface{}{
+				"AcceptLanguage": map[string]interface{}{
+					"name":        "Accept-Language",
+					"in":          "header",
+					"description": "Language of the messages: " + strings.Join(i18n.Locales(), ", ") + ". Defaults to " + i18n.Fallback + ".",
+					"schema":      map[string]interface{}{"type": "string"},
+				},
+			},
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{
+					"type":         "http",
+					"scheme":       "bearer",
+					"bearerFormat": "JWT",
+				},
+				"accessToken": map[string]interface{}{
+					"type":        "apiKey",
+					"in":          "query",
+					"name":        "access_token",
+					"description": "JWT for clients that cannot set headers, such as EventSource.",
+				},
+			},
+		},
+	}
+}
+
+func (b *schemaBuilder) operation(op operation, problemSchema map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{
+		"tags":        []string{op.tag},
+		"summary":     op.summary,
+		"operationId": operationID(op),
+	}
+
+	// Every response, errors included, is localised.
+	parameters := []interface{}{map[string]interface{}{"$ref": "#/components/parameters/AcceptLanguage"}}
+	for _, p := range op.parameters {
+		param := map[string]interface{}{"name": p.name, "in": p.in, "schema": p.schema}
+		if p.description != "" {
+			param["description"] = p.description
+		}
+		if p.required {
+			param["required"] = true
+		}
+		parameters = append(parameters, param)
+	}
+	result["parameters"] = parameters
+
+	switch {
+	case op.body != nil:
+		result["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{"application/json": map[string]interface{}{"schema": b.ref(op.body)}},
+		}
+	case op.bodyType != "":
+		file := map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}
+		multipart := map[string]interface{}{"schema": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"file": map[string]interface{}{"type": "string", "contentMediaType": op.bodyType}},
+			"required":   []string{"file"},
+		}}
+		result["requestBody"] = map[string]interface{}{
+			"required": true,
+			"content":  map[string]interface{}{op.bodyType: file, "multipart/form-data": multipart},
+		}
+	}
+
+	responses := map[string]interface{}{}
+	success := map[string]interface{}{"description": "OK"}
+	switch {
+	case op.response != nil:
+		success["content"] = map[string]interface{}{"application/json": map[string]interface{}{"schema": b.response(op.response)}}
+	case op.contentType != "":
+		success["content"] = map[string]interface{}{op.contentType: map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}}
+	}
+	if op.status == "204" {
+		success["description"] = "No content"
+	}
+	responses[op.status] = success
+
+	errorCodes := append([]string{}, op.errors...)
+	switch op.security {
+	case authenticated, streamToken:
+		errorCodes = append(errorCodes, "401")
+	case adminOnly:
+		errorCodes = append(errorCodes, "401", "403")
+	}
+	problem := map[string]interface{}{"application/problem+json": map[string]interface{}{"schema": problemSchema}}
+	for _, code := range errorCodes {
+		content := problem
+		if code == "422" && op.rejected != nil {
+			content = map[string]interface{}{"application/json": map[string]interface{}{"schema": b.ref(op.rejected)}}
+		}
+		responses[code] = map[string]interface{}{"description": errorDescriptions[code], "content": content}
+	}
+	responses["default"] = map[string]interface{}{"description": "Unexpected error", "content": problem}
+	result["responses"] = responses
+
+	switch op.security {
+	case authenticated, adminOnly:
+		result["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+	case streamToken:
+		result["security"] = []interface{}{
+			map[string]interface{}{"bearerAuth": []string{}},
+			map[string]interface{}{"accessToken": []string{}},
+		}
+	}
+	if op.security == adminOnly {
+		result["description"] = "Requires the admin role."
+	}
+	return result
+}
+
+func (b *schemaBuilder) response(v interface{}) map[string]interface{} {
+	alternatives, ok := v.(oneOf)
+	if !ok {
+		return b.ref(v)
+	}
+	var schemas []interface{}
+	for _, alternative := range alternatives {
+		schemas = append(schemas, b.ref(alternative))
+	}
+	return map[string]interface{}{"oneOf": schemas}
+}
+
+// operationID derives a stable ID such as postReservationsIdCheckIn.
+func operationID(op operation) string {
+	id := op.method
+	for _, part := range strings.FieldsFunc(op.path, func(r rune) bool {
+		return r == '/' || r == '{' || r == '}' || r == '-' || r == '.'
+	}) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+func tags() []interface{} {
+	seen := map[string]bool{}
+	var names []string
+	for _, op := range operations {
+		if !seen[op.tag] {
+			seen[op.tag] = true
+			names = append(names, op.tag)
+		}
+	}
+	sort.Strings(names)
+
+	var result []interface{}
+	for _, name := range names {
+		result = append(result, map[string]interface{}{"name": name})
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package docs
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	gormModelType = reflect.TypeOf(gorm.Model{})
+)
+
+// schemaBuilder reflects Go types into JSON Schema, the way encoding/json
+// serialises them. Named structs become components and are referenced.
+type schemaBuilder struct {
+	components map[string]interface{}
+}
+
+func (b *schemaBuilder) ref(v interface{}) map[string]interface{} {
+	return b.schema(reflect.TypeOf(v))
+}
+
+func (b *schemaBuilder) schema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return nullable(b.schema(t.Elem()))
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return b.object(t)
+		}
+		if _, ok := b.components[t.Name()]; !ok {
+			// Reserve the name first so recursive types terminate.
+			b.components[t.Name()] = nil
+			b.components[t.Name()] = b.object(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+// object lists the JSON properties of a struct. Embedded structs are
+// flattened, as encoding/json does; gorm.Model contributes its fields under
+// their Go names.
+func (b *schemaBuilder) object(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	b.fields(t, properties, &required)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (b *schemaBuilder) fields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			b.fields(field.Type, properties, required)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if t == gormModelType && field.Name == "DeletedAt" {
+			properties[name] = nullable(map[string]interface{}{"type": "string", "format": "date-time"})
+		} else {
+			properties[name] = b.schema(field.Type)
+		}
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// nullable allows null besides the schema, using the OpenAPI 3.1 type arrays.
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	if _, ok := schema["$ref"]; ok {
+		return map[string]interface{}{"anyOf": []interface{}{schema, map[string]interface{}{"type": "null"}}}
+	}
+	if kind, ok := schema["type"].(string); ok {
+		copied := map[string]interface{}{}
+		for key, value := range schema {
+			copied[key] = value
+		}
+		copied["type"] = []string{kind, "null"}
+		return copied
+	}
+	return schema
+}
@@ -0,0 +1,217 @@
+// Package i18n holds the message catalogs of the API and negotiates the
+// locale of each request. Messages are keyed by the stable error and violation
+// codes, so clients get the same code in every language.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fallback is used when none of the requested locales is supported, and for
+// messages missing from a catalog.
+const Fallback = "en"
+
+// Params fill the {name} placeholders of a message. Dates, durations and
+// weekdays are formatted in the locale of the message.
+type Params map[string]interface{}
+
+type catalog struct {
+	Messages map[string]string `json:"messages"`
+	// Weekdays start on Sunday, like time.Weekday.
+	Weekdays []string `json:"weekdays"`
+	Months   []string `json:"months"`
+	// DateFormat uses the {weekday}, {day}, {month} and {year} placeholders.
+	DateFormat string `json:"date_format"`
+}
+
+//go:embed locales/*.json
+var catalogFiles embed.FS
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]*catalog {
+	entries, err := catalogFiles.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	result := map[string]*catalog{}
+	for _, entry := range entries {
+		data, err := catalogFiles.ReadFile("locales/" + entry.Name())
+		if err != nil {
+			panic(err)
+		}
+		var c catalog
+		if err := json.Unmarshal(data, &c); err != nil {
+			panic(fmt.Sprintf("invalid catalog %s: %v", entry.Name(), err))
+		}
+		result[strings.TrimSuffix(entry.Name(), ".json")] = &c
+	}
+	return result
+}
+
+// Locales lists the supported locales.
+func Locales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Supported reports whether there is a catalog for the locale.
+func Supported(locale string) bool {
+	_, ok := catalogs[locale]
+	return ok
+}
+
+// Keys lists the message keys of a locale.
+func Keys(locale string) []string {
+	c, ok := catalogs[locale]
+	if !ok {
+		return nil
+	}
+	keys := make([]string, 0, len(c.Messages))
+	for key := range c.Messages {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Negotiate picks the supported locale preferred by an Accept-Language
+// header, such as "es-AR,es;q=0.9,en;q=0.8". Region subtags match their
+// language.
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		locale string
+		q      float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		language, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if q > 0 && (Supported(language) || language == "*") {
+			candidates = append(candidates, candidate{locale: language, q: q})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	// A preferred wildcard accepts any locale, so it gets the fallback.
+	if len(candidates) > 0 && candidates[0].locale != "*" {
+		return candidates[0].locale
+	}
+	return Fallback
+}
+
+// Message renders the first of keys found in the locale catalog, or else in
+// the fallback catalog. ok is false when no catalog has any of the keys.
+func Message(locale string, params Params, keys ...string) (message string, ok bool) {
+	for _, l := range []string{locale, Fallback} {
+		c, found := catalogs[l]
+		if !found {
+			continue
+		}
+		for _, key := range keys {
+			if template, found := c.Messages[key]; found {
+				return render(l, template, params), true
+			}
+		}
+	}
+	return "", false
+}
+
+// Describe renders the message of an error or violation code. A message
+// keyed "code.field" takes precedence over the one keyed by the code alone,
+// and the field is available to both as {field}.
+func Describe(locale, code, field string, params Params) (string, bool) {
+	if field == "" {
+		return Message(locale, params, code)
+	}
+	withField := Params{"field": field}
+	for name, value := range params {
+		withField[name] = value
+	}
+	return Message(locale, withField, code+"."+field, code)
+}
+
+func render(locale, template string, params Params) string {
+	if len(params) == 0 {
+		return template
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", format(locale, value))
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
+
+func format(locale string, value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return FormatDate(locale, v)
+	case time.Duration:
+		return FormatDuration(locale, v)
+	case time.Weekday:
+		return weekday(locale, v)
+	case []time.Weekday:
+		names := make([]string, len(v))
+		for i, day := range v {
+			names[i] = weekday(locale, day)
+		}
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprint(value)
+}
+
+func catalogFor(locale string) *catalog {
+	if c, ok := catalogs[locale]; ok {
+		return c
+	}
+	return catalogs[Fallback]
+}
+
+func weekday(locale string, day time.Weekday) string {
+	return catalogFor(locale).Weekdays[day]
+}
+
+// FormatDate writes a date in the long format of the locale, such as
+// "viernes, 23 de mayo de 2025".
+func FormatDate(locale string, t time.Time) string {
+	c := catalogFor(locale)
+	return strings.NewReplacer(
+		"{weekday}", c.Weekdays[t.Weekday()],
+		"{day}", strconv.Itoa(t.Day()),
+		"{month}", c.Months[t.Month()-1],
+		"{year}", strconv.Itoa(t.Year()),
+	).Replace(c.DateFormat)
+}
+
+// FormatDuration writes whole hours as hours and anything else as minutes.
+func FormatDuration(locale string, d time.Duration) string {
+	if d%time.Hour == 0 {
+		hours := int(d / time.Hour)
+		if hours == 1 {
+			message, _ := Message(locale, nil, "duration.hour")
+			return message
+		}
+		message, _ := Message(locale, Params{"n": hours}, "duration.hours")
+		return message
+	}
+	message, _ := Message(locale, Params{"n": int(d / time.Minute)}, "duration.minutes")
+	return message
+}
@@ -0,0 +1,34 @@
+package jobs
+
+import (
+	"booking-api/services"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// StartCalendarSyncJob re-syncs external calendars every interval until ctx
+// is cancelled.
+func StartCalendarSyncJob(ctx context.Context, wg *sync.WaitGroup, service services.ExternalCalendarService, interval time.Duration) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runCtx, span := startRun(ctx, "CalendarSyncJob")
+				if err := service.SyncAll(runCtx); err != nil {
+					slog.ErrorContext(runCtx, "error al sincronizar calendarios externos", "error", err)
+				}
+				span.End()
+			}
+		}
+	}()
+}
@@ -0,0 +1,18 @@
+// Package jobs runs the background work of the server. Each job runs in its
+// own goroutine, counted in the WaitGroup it is given, until its context is
+// cancelled; a run in progress is finished first, so shutdown can wait for the
+// group without cutting a job off halfway.
+package jobs
+
+import (
+	"booking-api/tracing"
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// startRun returns the context of one run of a job, holding its root span.
+// The run context is not cancelled with ctx, so a run in progress is finished.
+func startRun(ctx context.Context, job string) (context.Context, trace.Span) {
+	return tracing.Start(context.WithoutCancel(ctx), job)
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"booking-api/services"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// StartNoShowJob releases no-show reservations every interval until ctx is
+// cancelled.
+func StartNoShowJob(ctx context.Context, wg *sync.WaitGroup, service services.CheckInService, interval time.Duration) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runCtx, span := startRun(ctx, "NoShowJob")
+				released, err := service.ReleaseNoShows(runCtx)
+				if err != nil {
+					slog.ErrorContext(runCtx, "error al liberar reservas no-show", "error", err)
+				}
+				if released > 0 {
+					slog.InfoContext(runCtx, "reservas liberadas por no-show", "count", released)
+				}
+				span.End()
+			}
+		}
+	}()
+}
@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"booking-api/services"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// StartNotificationJob sends the emails of new outbox events and the due
+// reminders every interval until ctx is cancelled.
+func StartNotificationJob(ctx context.Context, wg *sync.WaitGroup, service services.NotificationService, interval time.Duration) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runCtx, span := startRun(ctx, "NotificationJob")
+				if _, err := service.ProcessEvents(runCtx); err != nil {
+					slog.ErrorContext(runCtx, "error al enviar notificaciones", "error", err)
+				}
+				if _, err := service.SendReminders(runCtx); err != nil {
+					slog.ErrorContext(runCtx, "error al enviar recordatorios", "error", err)
+				}
+				span.End()
+			}
+		}
+	}()
+}
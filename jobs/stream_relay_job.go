@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"booking-api/services"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// StartStreamRelayJob publishes new outbox events to the live streams every
+// interval until ctx is cancelled.
+func StartStreamRelayJob(ctx context.Context, wg *sync.WaitGroup, service services.StreamService, interval time.Duration) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		if _, err := service.Relay(context.WithoutCancel(ctx)); err != nil {
+			slog.Error("error al iniciar el stream de reservas", "error", err)
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := service.Relay(context.WithoutCancel(ctx)); err != nil {
+					slog.Error("error al publicar eventos en el stream", "error", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"booking-api/services"
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// StartWebhookJob dispatches outbox events and sends due webhook deliveries
+// every interval until ctx is cancelled.
+func StartWebhookJob(ctx context.Context, wg *sync.WaitGroup, service services.WebhookService, interval time.Duration) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runCtx, span := startRun(ctx, "WebhookJob")
+				if _, err := service.ProcessOutbox(runCtx); err != nil {
+					slog.ErrorContext(runCtx, "error al procesar eventos del outbox", "error", err)
+				}
+				if _, err := service.DeliverDue(runCtx); err != nil {
+					slog.ErrorContext(runCtx, "error al enviar webhooks", "error", err)
+				}
+				span.End()
+			}
+		}
+	}()
+}
@@ -2,68 +2,112 @@ package main
 
 import (
 	"booking-api/config"
-	"booking-api/controllers"
 	"booking-api/database"
-	"booking-api/middlewares"
-	"booking-api/repositories"
-	"booking-api/services"
-	"booking-api/utils"
+	"booking-api/logging"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"os"
-
-	"github.com/gofiber/fiber/v2"
-	jwtware "github.com/gofiber/jwt/v3"
-	"github.com/joho/godotenv"
 )
 
+// command is a subcommand of the binary. run gets the arguments after its
+// name and returns the exit code.
+type command struct {
+	name    string
+	summary string
+	run     func(cfg config.Config, args []string) int
+	// ownSchema commands work on the migrations themselves, so they run
+	// without preparing the schema first.
+	ownSchema bool
+}
+
+// commands lists the subcommands. Running the binary without one serves the
+// API.
+var commands = []command{
+	{name: "serve", summary: "inicia el servidor (por defecto)", run: runServe},
+	{name: "migrate", summary: "aplica, revierte o lista las migraciones", run: runMigrate, ownSchema: true},
+	{name: "seed", summary: "crea recursos de ejemplo en una base de datos vacía", run: runSeed},
+	{name: "create-admin", summary: "crea un usuario administrador", run: runCreateAdmin},
+	{name: "reset-password", summary: "cambia la contraseña de un usuario", run: runResetPassword},
+	{name: "export-reservations", summary: "exporta las reservas de un rango de fechas a CSV", run: runExportReservations},
+	{name: "purge", summary: "borra el historial anterior a una fecha", run: runPurge},
+}
+
 func main() {
-	err := godotenv.Load()
+	cfg, args, err := config.Load(os.Args[1:])
+	if errors.Is(err, config.ErrHelp) {
+		printUsage(os.Stdout)
+		return
+	}
 	if err != nil {
-		log.Println("No .env file found, usando variables de entorno del sistema")
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(2)
 	}
-
-	secret := os.Getenv("JWT_SECRET")
-	utils.SetJWTSecret(secret)
-
-	cfg := config.LoadConfig()
-
-	if err := database.ConnectDatabase(cfg); err != nil {
-		log.Fatalf("❌ Error al conectar la base de datos: %v", err)
+	if err := logging.Setup(cfg.Logging.Format, cfg.Logging.Level); err != nil {
+		fmt.Fprintf(os.Stderr, "configuración de logs no válida: %v\n", err)
+		os.Exit(2)
+	}
+	for _, warning := range cfg.Warnings() {
+		slog.Warn(warning)
 	}
 
-	userRepo := repositories.NewUserRepository(database.DB)
-	reservationRepo := repositories.NewReservationRepository(database.DB)
-
-	authService := services.NewAuthService(userRepo)
-	reservationService := services.NewReservationService(reservationRepo)
-
-	authController := controllers.NewAuthController(authService)
-	reservationController := controllers.NewReservationController(reservationService)
-
-	app := fiber.New()
-
-	app.Post("/register", authController.Register)
-	app.Post("/login", authController.Login)
-
-	app.Use("/reservations", jwtware.New(jwtware.Config{
-		SigningKey:   []byte(cfg.JWTSecret),
-		ContextKey:   "user",
-		ErrorHandler: middlewares.JWTError,
-	}))
+	name := "serve"
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		printUsage(os.Stdout)
+		return
+	}
+	cmd, ok := findCommand(name)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "comando desconocido %q\n\n", name)
+		printUsage(os.Stderr)
+		os.Exit(2)
+	}
+	// Nothing would outlive the command in memory but the server.
+	if cfg.Database.Storage == config.StorageMemory && cmd.name != "serve" {
+		fmt.Fprintf(os.Stderr, "el comando %q no está disponible con STORAGE=memory\n", name)
+		os.Exit(2)
+	}
 
-	protected := app.Group("/reservations", middlewares.Protected())
+	// In memory every table lives in the store of the application.
+	if cfg.Database.Storage != config.StorageMemory {
+		if err := database.ConnectDatabase(cfg); err != nil {
+			slog.Error("error al conectar la base de datos", "error", err)
+			os.Exit(1)
+		}
+		if !cmd.ownSchema {
+			if err := database.PrepareSchema(cfg.Database.MigrateOnStart); err != nil {
+				slog.Error("error al preparar la base de datos", "error", err)
+				os.Exit(1)
+			}
+		}
+	}
 
-	protected.Post("/", middlewares.Protected(), reservationController.CreateReservation)
-	protected.Get("/", middlewares.Protected(), reservationController.GetReservationsByDate)
+	os.Exit(cmd.run(cfg, args))
+}
 
-	port := cfg.Port
-	if port == "" {
-		port = "3000"
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
 	}
+	return command{}, false
+}
 
-	fmt.Printf("Starting server on port %s...\n", port)
-	if err := app.Listen(":" + port); err != nil {
-		log.Fatalf("failed to start server: %v", err)
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "uso: booking-api [opciones globales] [comando] [opciones]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Comandos:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-20s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Opciones globales:")
+	config.PrintFlags(w)
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Usa \"booking-api <comando> -h\" para ver las opciones de cada comando.")
 }
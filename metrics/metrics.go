@@ -0,0 +1,139 @@
+// Package metrics holds the Prometheus metrics of the API, served at /metrics.
+// The rest of the code records through the functions of this package, so only
+// it depends on the Prometheus client.
+package metrics
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "booking"
+
+// Registry holds every metric of the process, including the Go runtime and
+// process collectors.
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+var (
+	httpRequests = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Time to answer HTTP requests by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	dbQueryDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Time of the database queries by operation and table.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"operation", "table"})
+
+	dbQueryErrors = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "db_query_errors_total",
+		Help:      "Failed database queries by operation and table. Lookups finding nothing are not errors.",
+	}, []string{"operation", "table"})
+
+	reservationsCreated = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reservations_created_total",
+		Help:      "Reservations booked, one per resource of a group.",
+	})
+
+	reservationsRejected = factory.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reservations_rejected_total",
+		Help:      "Bookings and reschedules rejected, by operation and violation code. A rejection with several violations counts once per code.",
+	}, []string{"operation", "reason"})
+
+	reservationsCancelled = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reservations_cancelled_total",
+		Help:      "Reservations cancelled, one per resource of a group.",
+	})
+
+	loginFailures = factory.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "login_failures_total",
+		Help:      "Logins rejected for a wrong email or password.",
+	})
+)
+
+// Operations of reservations_rejected_total.
+const (
+	OperationCreate     = "create"
+	OperationReschedule = "reschedule"
+	OperationImport     = "import"
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest records an answered HTTP request. route is the route
+// template, such as "/v1/reservations/:id", to keep the label set bounded.
+func ObserveRequest(method, route string, status int, elapsed time.Duration) {
+	code := strconv.Itoa(status)
+	httpRequests.WithLabelValues(method, route, code).Inc()
+	httpDuration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+// ObserveQuery records a database query.
+func ObserveQuery(operation, table string, elapsed time.Duration, failed bool) {
+	dbQueryDuration.WithLabelValues(operation, table).Observe(elapsed.Seconds())
+	if failed {
+		dbQueryErrors.WithLabelValues(operation, table).Inc()
+	}
+}
+
+// WatchPool exports the connection pool statistics of db. Watching the same
+// pool again is a no-op.
+func WatchPool(db *sql.DB, name string) error {
+	err := Registry.Register(collectors.NewDBStatsCollector(db, name))
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		return nil
+	}
+	return err
+}
+
+func ReservationsCreated(n int) {
+	reservationsCreated.Add(float64(n))
+}
+
+func ReservationRejected(operation, reason string) {
+	reservationsRejected.WithLabelValues(operation, reason).Inc()
+}
+
+func ReservationsCancelled(n int) {
+	reservationsCancelled.Add(float64(n))
+}
+
+func LoginFailed() {
+	loginFailures.Inc()
+}
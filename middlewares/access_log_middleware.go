@@ -0,0 +1,67 @@
+package middlewares
+
+import (
+	"booking-api/logging"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// quietRoutes are polled by the infrastructure; their requests are only
+// logged at debug level.
+var quietRoutes = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// AccessLog writes a record per request with its method, route template,
+// status, latency and authenticated user. It must be registered after
+// RequestID and before Metrics, which answers errors, so the record has the
+// final status. URLs are not logged, as some carry tokens.
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		route := c.Route().Path
+		if route == "/" && status == fiber.StatusNotFound {
+			route = unmatchedRoute
+		}
+
+		level := slog.LevelInfo
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			level = slog.LevelError
+		case quietRoutes[route]:
+			level = slog.LevelDebug
+		}
+		attrs := []slog.Attr{
+			slog.String("method", strings.Clone(c.Method())),
+			slog.String("route", route),
+			slog.Int("status", status),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.String("ip", c.IP()),
+		}
+		ctx := c.UserContext()
+		if userID, ok := tokenUserID(c); ok {
+			ctx = logging.With(ctx, slog.Uint64("user_id", uint64(userID)))
+		}
+		slog.LogAttrs(ctx, level, "petición atendida", attrs...)
+		return err
+	}
+}
+
+// tokenUserID returns the user of the token validated by Protected.
+func tokenUserID(c *fiber.Ctx) (uint, bool) {
+	token, ok := c.Locals("user").(*jwt.Token)
+	if !ok {
+		return 0, false
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, false
+	}
+	id, ok := claims["user_id"].(float64)
+	return uint(id), ok
+}
@@ -0,0 +1,31 @@
+package middlewares
+
+import (
+	"booking-api/audit"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxUserAgent bounds the user agent kept in audit entries.
+const maxUserAgent = 512
+
+// AuditActor records where the request comes from, for the audit entries of
+// the changes it makes: the client IP, user agent and request ID. The user is
+// added by Protected once the token is checked. It must run after RequestID.
+func AuditActor() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userAgent := c.Get(fiber.HeaderUserAgent)
+		if len(userAgent) > maxUserAgent {
+			userAgent = userAgent[:maxUserAgent]
+		}
+		requestID, _ := c.Locals(RequestIDKey).(string)
+
+		c.SetUserContext(audit.WithActor(c.UserContext(), audit.Actor{
+			IP:        strings.Clone(c.IP()),
+			UserAgent: strings.Clone(userAgent),
+			RequestID: requestID,
+		}))
+		return c.Next()
+	}
+}
@@ -0,0 +1,19 @@
+package middlewares
+
+import (
+	"booking-api/i18n"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Locale negotiates the language of the response from the Accept-Language
+// header and stores it in the "locale" local for the handlers.
+func Locale() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		locale := i18n.Negotiate(c.Get(fiber.HeaderAcceptLanguage))
+		c.Locals("locale", locale)
+		c.Set(fiber.HeaderContentLanguage, locale)
+		c.Vary(fiber.HeaderAcceptLanguage)
+		return c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package middlewares
+
+import (
+	"booking-api/metrics"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// unmatchedRoute labels requests no route handled, so scanners probing random
+// paths do not add a label value per path.
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count and latency of every request by method, route
+// template and status. It is registered after Tracing, RequestID, AuditActor
+// and AccessLog, which need the final status, and before the rest of the
+// chain, which it times with the handler. Errors are passed to the error
+// handler here, as the status is only known once the handler has written the
+// response.
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		route := c.Route().Path
+		// Without a matching route the last handler run is a middleware
+		// mounted on "/"; no route of the API is "/" itself.
+		if route == "/" && status == fiber.StatusNotFound {
+			route = unmatchedRoute
+		}
+		// Fiber reuses the buffer behind c.Method(); the label outlives it.
+		metrics.ObserveRequest(strings.Clone(c.Method()), route, status, time.Since(start))
+		return nil
+	}
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"booking-api/logging"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// RequestIDKey is the c.Locals key holding the ID of the request.
+const RequestIDKey = "requestid"
+
+// validRequestID limits the IDs accepted from clients, so they cannot forge
+// log lines or fill them with arbitrary data.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID gives every request an ID: the X-Request-ID header sent by the
+// client or a proxy when it is valid, a new UUID otherwise. The ID is echoed in
+// the response header, kept in c.Locals(RequestIDKey) and added to every log
+// record written with c.UserContext().
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+		if validRequestID.MatchString(id) {
+			id = strings.Clone(id)
+		} else {
+			id = uuid.NewString()
+		}
+
+		c.Set(fiber.HeaderXRequestID, id)
+		c.Locals(RequestIDKey, id)
+		c.SetUserContext(logging.With(c.UserContext(), slog.String("request_id", id)))
+		return c.Next()
+	}
+}
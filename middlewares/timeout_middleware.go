@@ -0,0 +1,24 @@
+package middlewares
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DBTimeout bounds the database work of a request: c.UserContext() gets a
+// deadline timeout from now, so queries still running when it passes are
+// cancelled and the request fails. Handlers streaming the body after they
+// return must detach their context from it. A timeout of 0 sets no deadline.
+func DBTimeout(timeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if timeout <= 0 {
+			return c.Next()
+		}
+		ctx, cancel := context.WithTimeout(c.UserContext(), timeout)
+		defer cancel()
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
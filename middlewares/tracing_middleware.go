@@ -0,0 +1,69 @@
+package middlewares
+
+import (
+	"booking-api/tracing"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing starts a server span per request, continuing the trace of the
+// traceparent header when there is one, and hands it to the handlers through
+// c.UserContext(). It must be registered before Metrics, which answers errors,
+// so the span sees the final status. The span is named after the route
+// template once the route is known. The path itself is never recorded: it may
+// carry secrets, such as the token of a calendar feed.
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := tracing.Extract(c.UserContext(), requestHeaders{c})
+
+		method := strings.Clone(c.Method())
+		ctx, span := tracing.StartKind(ctx, method, trace.SpanKindServer,
+			attribute.String("http.request.method", method),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		route := c.Route().Path
+		if route == "/" && status == fiber.StatusNotFound {
+			route = unmatchedRoute
+		} else {
+			span.SetAttributes(attribute.String("http.route", route))
+		}
+		span.SetName(method + " " + route)
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+		tracing.Fail(span, err)
+		return err
+	}
+}
+
+// requestHeaders reads the propagation headers of the request. Values are
+// copied, as Fiber reuses the buffers behind them.
+type requestHeaders struct {
+	c *fiber.Ctx
+}
+
+func (h requestHeaders) Get(key string) string {
+	return strings.Clone(h.c.Get(key))
+}
+
+func (h requestHeaders) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h requestHeaders) Keys() []string {
+	keys := []string{}
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// VersionAlias serves requests outside every versioned prefix from current, so
+// "/reservations" is handled as "/v1/reservations". The responses announce the
+// deprecation (RFC 9745), the sunset date (RFC 8594) and the successor URL.
+// It must be registered before the versioned routes.
+func VersionAlias(current string, versions []string, deprecated, sunset time.Time) fiber.Handler {
+	deprecation := "@" + strconv.FormatInt(deprecated.Unix(), 10)
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+
+	return func(c *fiber.Ctx) error {
+		path := c.Path()
+		for _, version := range versions {
+			if path == version || strings.HasPrefix(path, version+"/") {
+				return c.Next()
+			}
+		}
+
+		successor := current + path
+		c.Set("Deprecation", deprecation)
+		c.Set("Sunset", sunsetDate)
+		c.Append(fiber.HeaderLink, "<"+successor+`>; rel="successor-version"`)
+		c.Path(successor)
+		return c.Next()
+	}
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Audited actions on accounts. Actions on reservations share the names of
+// their events, such as EventReservationCancelled.
+const (
+	AuditUserRegistered       = "user.registered"
+	AuditUserAdminCreated     = "user.admin_created"
+	AuditUserPasswordReset    = "user.password_reset"
+	AuditUserFeedTokenRotated = "user.feed_token_rotated"
+	AuditLoginSucceeded       = "auth.login_succeeded"
+	AuditLoginFailed          = "auth.login_failed"
+)
+
+// Kinds of audited targets.
+const (
+	AuditTargetReservation = "reservation"
+	AuditTargetUser        = "user"
+)
+
+// AuditEntry records that an actor took an action on a target. Entries are
+// only ever appended, in the same transaction as the change they describe.
+// Changes holds the changed fields as a JSON object of
+// {"field": {"before": ..., "after": ...}}. ActorID is nil for changes made
+// by the system, such as the no-show job or the admin command, and for
+// anonymous requests.
+//
+// With the hash chain enabled, Hash is the Digest of the entry and PrevHash the
+// Hash of the chained entry before it, so altering or removing an entry is
+// noticed from that entry on.
+type AuditEntry struct {
+	ID         uint      `json:"id" gorm:"primarykey"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+	ActorID    *uint     `json:"actor_id" gorm:"index"`
+	Action     string    `json:"action" gorm:"index"`
+	TargetType string    `json:"target_type" gorm:"index:idx_audit_entries_target"`
+	TargetID   uint      `json:"target_id" gorm:"index:idx_audit_entries_target"`
+	Changes    string    `json:"changes,omitempty"`
+	IP         string    `json:"ip,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+	PrevHash   string    `json:"prev_hash,omitempty"`
+	Hash       string    `json:"hash,omitempty"`
+}
+
+// Digest returns the SHA-256 of every field of the entry but ID and Hash, in
+// hex. CreatedAt is taken in UTC, as databases do not keep the time zone.
+func (e AuditEntry) Digest() string {
+	data, _ := json.Marshal([]interface{}{
+		e.PrevHash,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.ActorID,
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		e.Changes,
+		e.IP,
+		e.UserAgent,
+		e.RequestID,
+	})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,11 @@
+package models
+
+import "gorm.io/gorm"
+
+// BookingGroup ties together reservations of several resources made in one
+// request. They are cancelled and rescheduled as a unit.
+type BookingGroup struct {
+	gorm.Model
+	UserID       uint          `json:"user_id"`
+	Reservations []Reservation `json:"reservations,omitempty" gorm:"foreignKey:GroupID"`
+}
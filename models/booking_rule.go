@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+// BookingRule configures one booking policy for a resource. Kind selects the
+// policy (e.g. "max_duration") and Value holds its parameter as text.
+type BookingRule struct {
+	gorm.Model
+	ResourceID uint   `json:"resource_id" gorm:"index"`
+	Kind       string `json:"kind"`
+	Value      string `json:"value"`
+}
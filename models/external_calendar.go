@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExternalCalendar is an iCalendar source whose events block a resource, for
+// resources that are also booked in another system. Sources with a URL or a
+// file path are re-synced periodically; uploaded ones only change when
+// uploaded again.
+type ExternalCalendar struct {
+	gorm.Model
+	ResourceID   uint       `json:"resource_id" gorm:"index"`
+	Name         string     `json:"name"`
+	URL          string     `json:"url,omitempty"`
+	FilePath     string     `json:"file_path,omitempty"`
+	LastSyncedAt *time.Time `json:"last_synced_at"`
+	LastError    string     `json:"last_error,omitempty"`
+	BlockCount   int        `json:"block_count"`
+}
+
+// ExternalBlock is the part of an imported event that falls on one day.
+type ExternalBlock struct {
+	ID         uint   `json:"id" gorm:"primarykey"`
+	CalendarID uint   `json:"calendar_id" gorm:"index"`
+	ResourceID uint   `json:"resource_id" gorm:"index:idx_external_blocks_slot"`
+	Date       string `json:"date" gorm:"index:idx_external_blocks_slot"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	UID        string `json:"uid"`
+	Summary    string `json:"summary"`
+}
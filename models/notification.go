@@ -0,0 +1,16 @@
+package models
+
+import "gorm.io/gorm"
+
+// NotificationPreferences are the emails a user wants to receive. Users
+// without preferences get every email in the default locale.
+type NotificationPreferences struct {
+	gorm.Model
+	UserID          uint   `json:"user_id" gorm:"uniqueIndex"`
+	Confirmation    bool   `json:"confirmation"`
+	Change          bool   `json:"change"`
+	Cancellation    bool   `json:"cancellation"`
+	Reminder        bool   `json:"reminder"`
+	ReminderMinutes int    `json:"reminder_minutes"`
+	Locale          string `json:"locale"`
+}
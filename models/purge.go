@@ -0,0 +1,10 @@
+package models
+
+// PurgeReport counts the rows removed, or that would be removed, by a purge.
+type PurgeReport struct {
+	Reservations      int64 `json:"reservations"`
+	BookingGroups     int64 `json:"booking_groups"`
+	ExternalBlocks    int64 `json:"external_blocks"`
+	OutboxEvents      int64 `json:"outbox_events"`
+	WebhookDeliveries int64 `json:"webhook_deliveries"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "gorm.io/gorm"
+
+// QuotaLimits holds fair-use limits. A zero value means unlimited.
+type QuotaLimits struct {
+	HoursPerWeek          int `json:"hours_per_week"`
+	MaxActiveReservations int `json:"max_active_reservations"`
+	BookingsPerDay        int `json:"bookings_per_day"`
+}
+
+// Quota overrides the default limits for every user of a role or for a
+// single user. Exactly one of Role and UserID is set.
+type Quota struct {
+	gorm.Model
+	Role   string `json:"role,omitempty" gorm:"index"`
+	UserID *uint  `json:"user_id,omitempty" gorm:"index"`
+	QuotaLimits
+}
+
+type QuotaCounter struct {
+	Limit     int      `json:"limit"`
+	Used      float64  `json:"used"`
+	Remaining *float64 `json:"remaining"`
+}
+
+type QuotaStatus struct {
+	HoursPerWeek       QuotaCounter `json:"hours_per_week"`
+	ActiveReservations QuotaCounter `json:"active_reservations"`
+	BookingsPerDay     QuotaCounter `json:"bookings_per_day"`
+}
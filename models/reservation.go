@@ -1,10 +1,41 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ReservationConfirmed = "confirmed"
+	ReservationCheckedIn = "checked_in"
+	ReservationNoShow    = "no_show"
+	ReservationCancelled = "cancelled"
+
+	// ReservationExternal marks busy time imported from an external calendar.
+	// It is never stored in the reservations table.
+	ReservationExternal = "external"
+)
 
 type Reservation struct {
 	gorm.Model
-	UserID    uint   `json:"user_id"`
+	UserID      uint       `json:"user_id"`
+	ResourceID  uint       `json:"resource_id" gorm:"index"`
+	GroupID     *uint      `json:"group_id,omitempty" gorm:"index"`
+	Date        string     `json:"date"`
+	StartTime   string     `json:"start_time"`
+	EndTime     string     `json:"end_time"`
+	Status      string     `json:"status" gorm:"default:confirmed;index"`
+	CheckedInAt *time.Time `json:"checked_in_at"`
+	Sequence    int        `json:"sequence" gorm:"not null;default:0"`
+	RemindedAt  *time.Time `json:"-"`
+
+	// Previous is the slot a rescheduled reservation moved from. It is not
+	// stored, only reported in responses and events.
+	Previous *ReservationSlot `json:"previous,omitempty" gorm:"-"`
+}
+
+type ReservationSlot struct {
 	Date      string `json:"date"`
 	StartTime string `json:"start_time"`
 	EndTime   string `json:"end_time"`
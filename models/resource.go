@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+type Resource struct {
+	gorm.Model
+	Name              string  `json:"name"`
+	PreBufferMinutes  int     `json:"pre_buffer_minutes"`
+	PostBufferMinutes int     `json:"post_buffer_minutes"`
+	CheckInCode       string  `json:"-"`
+	FeedToken         *string `json:"-" gorm:"uniqueIndex"`
+}
@@ -0,0 +1,6 @@
+package models
+
+type TimeSlot struct {
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
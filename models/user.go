@@ -1,11 +1,33 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"log/slog"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
 
 type User struct {
 	gorm.Model
 	Name         string        `json:"name"`
 	Email        string        `gorm:"uniqueIndex" json:"email"`
 	Password     string        `json:"password"`
+	Role         string        `json:"role" gorm:"default:user"`
+	NoShowCount  int           `json:"no_show_count" gorm:"not null;default:0"`
+	FeedToken    *string       `json:"-" gorm:"uniqueIndex"`
 	Reservations []Reservation `json:"-" gorm:"foreignKey:UserID"`
 }
+
+// LogValue keeps the password hash and feed token out of logs when a user is
+// logged as a whole.
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.Uint64("id", uint64(u.ID)),
+		slog.String("email", u.Email),
+		slog.String("role", u.Role),
+	)
+}
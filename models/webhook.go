@@ -0,0 +1,68 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reservation events published to webhooks.
+const (
+	EventReservationCreated     = "reservation.created"
+	EventReservationRescheduled = "reservation.rescheduled"
+	EventReservationCancelled   = "reservation.cancelled"
+	EventReservationCheckedIn   = "reservation.checked_in"
+	EventReservationNoShow      = "reservation.no_show"
+)
+
+// EventTypes lists every event a webhook can subscribe to.
+var EventTypes = []string{
+	EventReservationCreated,
+	EventReservationRescheduled,
+	EventReservationCancelled,
+	EventReservationCheckedIn,
+	EventReservationNoShow,
+}
+
+// Delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookSubscription receives the events listed in Events, comma separated,
+// or every event when Events is empty. Payloads are signed with Secret.
+type WebhookSubscription struct {
+	gorm.Model
+	URL    string `json:"url"`
+	Events string `json:"events"`
+	Secret string `json:"-"`
+}
+
+// OutboxEvent is an event written in the same transaction as the change it
+// describes, and consumed afterwards. ProcessedAt marks its dispatch to the
+// webhooks and NotifiedAt its handling by the email notifications.
+type OutboxEvent struct {
+	ID          uint       `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Type        string     `json:"type"`
+	Payload     string     `json:"payload"`
+	ProcessedAt *time.Time `json:"processed_at" gorm:"index"`
+	NotifiedAt  *time.Time `json:"notified_at" gorm:"index"`
+}
+
+// WebhookDelivery is an event sent, or to be sent, to one subscription.
+type WebhookDelivery struct {
+	gorm.Model
+	SubscriptionID uint       `json:"subscription_id" gorm:"index"`
+	EventID        uint       `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status" gorm:"default:pending;index"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	ResponseStatus int        `json:"response_status"`
+	LastError      string     `json:"last_error,omitempty"`
+}
@@ -0,0 +1,53 @@
+package notifications
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// FileNotifier writes every message as an .eml file in Dir, to inspect emails
+// during development without a mail server.
+type FileNotifier struct {
+	Dir  string
+	From string
+
+	count atomic.Int64
+}
+
+func (n *FileNotifier) Send(msg Message) error {
+	data, err := BuildMIME(n.From, msg)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %v", err)
+	}
+	if err := os.MkdirAll(n.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %v", n.Dir, err)
+	}
+
+	name := fmt.Sprintf("%s-%d-%s.eml", time.Now().Format("20060102T150405"), n.count.Add(1), sanitizeFilename(msg.To))
+	if err := os.WriteFile(filepath.Join(n.Dir, name), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write email: %v", err)
+	}
+	return nil
+}
+
+// LogNotifier only logs the recipient and subject of every message.
+type LogNotifier struct{}
+
+func (LogNotifier) Send(msg Message) error {
+	slog.Info("email", "to", msg.To, "subject", msg.Subject)
+	return nil
+}
+
+func sanitizeFilename(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+}
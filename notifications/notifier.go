@@ -0,0 +1,117 @@
+package notifications
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Notifier delivers messages to users. Implementations must be safe for
+// concurrent use.
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// Message is an email with a plain text and an HTML body.
+type Message struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// BuildMIME renders msg as a multipart MIME email: the text and HTML bodies as
+// alternatives, followed by the attachments.
+func BuildMIME(from string, msg Message) ([]byte, error) {
+	var b bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&b, "%s: %s\r\n", key, value)
+	}
+
+	mixed := multipart.NewWriter(&b)
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("Message-ID", messageID(from))
+	header("MIME-Version", "1.0")
+	header("Content-Type", `multipart/mixed; boundary="`+mixed.Boundary()+`"`)
+	b.WriteString("\r\n")
+
+	var alternative bytes.Buffer
+	alt := multipart.NewWriter(&alternative)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", msg.Text},
+		{"text/html; charset=utf-8", msg.HTML},
+	} {
+		w, err := alt.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(w, []byte(part.body))
+	}
+	if err := alt.Close(); err != nil {
+		return nil, err
+	}
+
+	w, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {`multipart/alternative; boundary="` + alt.Boundary() + `"`},
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.Write(alternative.Bytes())
+
+	for _, attachment := range msg.Attachments {
+		w, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		writeBase64(w, attachment.Data)
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// writeBase64 writes data base64 encoded in lines of 76 characters.
+func writeBase64(w interface{ Write([]byte) (int, error) }, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		w.Write([]byte(encoded[:76] + "\r\n"))
+		encoded = encoded[76:]
+	}
+	w.Write([]byte(encoded + "\r\n"))
+}
+
+func messageID(from string) string {
+	domain := "booking-api"
+	if at := strings.LastIndex(from, "@"); at >= 0 {
+		domain = strings.Trim(from[at+1:], "> ")
+	}
+	random := make([]byte, 12)
+	rand.Read(random)
+	return "<" + hex.EncodeToString(random) + "@" + domain + ">"
+}
@@ -0,0 +1,41 @@
+package notifications
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+)
+
+// SMTPNotifier sends messages through an SMTP server. Authentication is only
+// used when a username is set.
+type SMTPNotifier struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (n *SMTPNotifier) Send(msg Message) error {
+	data, err := BuildMIME(n.From, msg)
+	if err != nil {
+		return fmt.Errorf("failed to build email: %v", err)
+	}
+
+	from, err := mail.ParseAddress(n.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %v", n.From, err)
+	}
+
+	var auth smtp.Auth
+	if n.Username != "" {
+		auth = smtp.PlainAuth("", n.Username, n.Password, n.Host)
+	}
+	addr := net.JoinHostPort(n.Host, strconv.Itoa(n.Port))
+	if err := smtp.SendMail(addr, auth, from.Address, []string{msg.To}, data); err != nil {
+		return fmt.Errorf("failed to send email to %s: %v", msg.To, err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package notifications
+
+import (
+	"booking-api/i18n"
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Kinds of notification, each with a template per locale.
+const (
+	KindConfirmation = "confirmation"
+	KindChange       = "change"
+	KindCancellation = "cancellation"
+	KindReminder     = "reminder"
+)
+
+// DefaultLocale is used when a locale has no templates.
+const DefaultLocale = i18n.Fallback
+
+//go:embed templates
+var templateFiles embed.FS
+
+// TemplateData is what templates can show about a reservation. Date is
+// YYYY-MM-DD; Render fills LongDate with it written out in the locale.
+type TemplateData struct {
+	UserName      string
+	ResourceName  string
+	ReservationID uint
+	Date          string
+	LongDate      string
+	StartTime     string
+	EndTime       string
+	Minutes       int
+}
+
+// Render returns the subject, text and HTML bodies of a notification. Each
+// template file defines "subject", "text" and "html"; the HTML one is escaped
+// with html/template.
+func Render(locale, kind string, data TemplateData) (subject, text, html string, err error) {
+	path := fmt.Sprintf("templates/%s/%s.tmpl", locale, kind)
+	if _, err := templateFiles.Open(path); err != nil {
+		locale = DefaultLocale
+		path = fmt.Sprintf("templates/%s/%s.tmpl", locale, kind)
+	}
+	data.LongDate = data.Date
+	if date, err := time.Parse("2006-01-02", data.Date); err == nil {
+		data.LongDate = i18n.FormatDate(locale, date)
+	}
+
+	textTemplates, err := texttemplate.ParseFS(templateFiles, path)
+	if err != nil {
+		return "", "", "", fmt.Errorf("unknown notification %q: %v", kind, err)
+	}
+	htmlTemplates, err := htmltemplate.ParseFS(templateFiles, path)
+	if err != nil {
+		return "", "", "", fmt.Errorf("unknown notification %q: %v", kind, err)
+	}
+
+	var b bytes.Buffer
+	if err := textTemplates.ExecuteTemplate(&b, "subject", data); err != nil {
+		return "", "", "", err
+	}
+	subject = strings.TrimSpace(b.String())
+
+	b.Reset()
+	if err := textTemplates.ExecuteTemplate(&b, "text", data); err != nil {
+		return "", "", "", err
+	}
+	text = strings.TrimSpace(b.String()) + "\n"
+
+	b.Reset()
+	if err := htmlTemplates.ExecuteTemplate(&b, "html", data); err != nil {
+		return "", "", "", err
+	}
+	html = strings.TrimSpace(b.String()) + "\n"
+
+	return subject, text, html, nil
+}
+
+// Locales lists the locales with templates.
+func Locales() []string {
+	entries, _ := templateFiles.ReadDir("templates")
+	locales := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		locales = append(locales, entry.Name())
+	}
+	return locales
+}
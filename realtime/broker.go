@@ -0,0 +1,108 @@
+package realtime
+
+import (
+	"booking-api/models"
+	"sync"
+)
+
+// Event is a change of a reservation pushed to live subscribers. ID is the
+// outbox event ID, which increases with every change.
+type Event struct {
+	ID          uint
+	Type        string
+	Reservation models.Reservation
+}
+
+// Broker fans events out to subscribers. MemoryBroker only reaches
+// subscribers of the same process; a shared broker (Redis, NATS...) can
+// implement the same interface.
+type Broker interface {
+	Publish(event Event)
+	// Subscribe returns a channel receiving the events accepted by filter and
+	// a function to unsubscribe. The channel is closed on unsubscribe, or when
+	// the subscriber falls behind, and must then be re-subscribed.
+	Subscribe(filter func(Event) bool) (<-chan Event, func())
+	// Close ends every subscription, and those made afterwards, so open
+	// streams finish before the server shuts down.
+	Close()
+}
+
+// subscriberBuffer is how many events a subscriber can lag behind before it
+// is dropped.
+const subscriberBuffer = 64
+
+type subscriber struct {
+	events chan Event
+	filter func(Event) bool
+}
+
+type MemoryBroker struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{subscribers: map[*subscriber]struct{}{}}
+}
+
+// Publish never blocks: a subscriber whose buffer is full is dropped instead
+// of slowing down everybody else.
+func (b *MemoryBroker) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		if sub.filter != nil && !sub.filter(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+func (b *MemoryBroker) Subscribe(filter func(Event) bool) (<-chan Event, func()) {
+	sub := &subscriber{events: make(chan Event, subscriberBuffer), filter: filter}
+
+	b.mu.Lock()
+	if b.closed {
+		close(sub.events)
+	} else {
+		b.subscribers[sub] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.events, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subscribers[sub]; ok {
+				delete(b.subscribers, sub)
+				close(sub.events)
+			}
+		})
+	}
+}
+
+func (b *MemoryBroker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Subscribers returns how many subscribers are connected.
+func (b *MemoryBroker) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
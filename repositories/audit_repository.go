@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"booking-api/models"
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditFilter selects audit entries. Zero fields match every entry.
+type AuditFilter struct {
+	ActorID    *uint
+	Action     string
+	TargetType string
+	TargetID   uint
+	From       time.Time
+	To         time.Time
+	// BeforeID pages back from the last entry of the previous page.
+	BeforeID uint
+	Limit    int
+}
+
+// AuditRepository reads the audit log. Entries are appended by the
+// repositories whose changes they describe, in the same transaction.
+type AuditRepository interface {
+	Find(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error)
+	EachChained(ctx context.Context, fn func(models.AuditEntry) error) error
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// Find returns the entries matching filter, newest first.
+func (r *auditRepository) Find(ctx context.Context, filter AuditFilter) ([]models.AuditEntry, error) {
+	query := r.db.WithContext(ctx)
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.TargetType != "" {
+		query = query.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != 0 {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From.UTC())
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To.UTC())
+	}
+	if filter.BeforeID != 0 {
+		query = query.Where("id < ?", filter.BeforeID)
+	}
+
+	entries := []models.AuditEntry{}
+	err := query.Order("id DESC").Limit(filter.Limit).Find(&entries).Error
+	return entries, err
+}
+
+// EachChained calls fn with every entry carrying a hash, oldest first.
+func (r *auditRepository) EachChained(ctx context.Context, fn func(models.AuditEntry) error) error {
+	rows, err := r.db.WithContext(ctx).Model(&models.AuditEntry{}).Where("hash <> ''").Order("id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.AuditEntry
+		if err := r.db.WithContext(ctx).ScanRows(rows, &entry); err != nil {
+			return err
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
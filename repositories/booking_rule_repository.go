@@ -0,0 +1,52 @@
+package repositories
+
+import (
+	"booking-api/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type BookingRuleRepository interface {
+	Create(ctx context.Context, rule *models.BookingRule) error
+	FindByID(ctx context.Context, id uint) (*models.BookingRule, error)
+	FindByResource(ctx context.Context, resourceID uint) ([]models.BookingRule, error)
+	Update(ctx context.Context, rule *models.BookingRule) error
+	Delete(ctx context.Context, rule *models.BookingRule) error
+}
+
+type bookingRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewBookingRuleRepository(db *gorm.DB) BookingRuleRepository {
+	return &bookingRuleRepository{db: db}
+}
+
+func (r *bookingRuleRepository) Create(ctx context.Context, rule *models.BookingRule) error {
+	return r.db.WithContext(ctx).Create(rule).Error
+}
+
+func (r *bookingRuleRepository) FindByID(ctx context.Context, id uint) (*models.BookingRule, error) {
+	var rule models.BookingRule
+	result := r.db.WithContext(ctx).First(&rule, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, &NotFoundError{Entity: "rule"}
+	}
+	return &rule, result.Error
+}
+
+func (r *bookingRuleRepository) FindByResource(ctx context.Context, resourceID uint) ([]models.BookingRule, error) {
+	var rules []models.BookingRule
+	err := r.db.WithContext(ctx).Where("resource_id = ?", resourceID).Order("id").Find(&rules).Error
+	return rules, err
+}
+
+func (r *bookingRuleRepository) Update(ctx context.Context, rule *models.BookingRule) error {
+	return r.db.WithContext(ctx).Save(rule).Error
+}
+
+func (r *bookingRuleRepository) Delete(ctx context.Context, rule *models.BookingRule) error {
+	return r.db.WithContext(ctx).Delete(rule).Error
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import "errors"
+
+// ErrNotFound matches every lookup that found no row.
+var ErrNotFound = errors.New("not found")
+
+// NotFoundError reports the entity that was not found, as in
+// "reservation not found".
+type NotFoundError struct {
+	Entity string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Entity + " not found"
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
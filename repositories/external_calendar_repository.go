@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"booking-api/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type ExternalCalendarRepository interface {
+	Create(ctx context.Context, calendar *models.ExternalCalendar) error
+	FindByID(ctx context.Context, id uint) (*models.ExternalCalendar, error)
+	FindByResource(ctx context.Context, resourceID uint) ([]models.ExternalCalendar, error)
+	FindSyncable(ctx context.Context) ([]models.ExternalCalendar, error)
+	Update(ctx context.Context, calendar *models.ExternalCalendar) error
+	Delete(ctx context.Context, calendar *models.ExternalCalendar) error
+	ReplaceBlocks(ctx context.Context, calendar *models.ExternalCalendar, blocks []models.ExternalBlock) error
+}
+
+type externalCalendarRepository struct {
+	db *gorm.DB
+}
+
+func NewExternalCalendarRepository(db *gorm.DB) ExternalCalendarRepository {
+	return &externalCalendarRepository{db: db}
+}
+
+func (r *externalCalendarRepository) Create(ctx context.Context, calendar *models.ExternalCalendar) error {
+	return r.db.WithContext(ctx).Create(calendar).Error
+}
+
+func (r *externalCalendarRepository) FindByID(ctx context.Context, id uint) (*models.ExternalCalendar, error) {
+	var calendar models.ExternalCalendar
+	result := r.db.WithContext(ctx).First(&calendar, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, &NotFoundError{Entity: "external calendar"}
+	}
+	return &calendar, result.Error
+}
+
+func (r *externalCalendarRepository) FindByResource(ctx context.Context, resourceID uint) ([]models.ExternalCalendar, error) {
+	var calendars []models.ExternalCalendar
+	err := r.db.WithContext(ctx).Where("resource_id = ?", resourceID).Order("id").Find(&calendars).Error
+	return calendars, err
+}
+
+// FindSyncable returns the sources that can be fetched again.
+func (r *externalCalendarRepository) FindSyncable(ctx context.Context) ([]models.ExternalCalendar, error) {
+	var calendars []models.ExternalCalendar
+	err := r.db.WithContext(ctx).Where("url <> '' OR file_path <> ''").Order("id").Find(&calendars).Error
+	return calendars, err
+}
+
+func (r *externalCalendarRepository) Update(ctx context.Context, calendar *models.ExternalCalendar) error {
+	return r.db.WithContext(ctx).Save(calendar).Error
+}
+
+// Delete removes the source together with its blocks.
+func (r *externalCalendarRepository) Delete(ctx context.Context, calendar *models.ExternalCalendar) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_id = ?", calendar.ID).Delete(&models.ExternalBlock{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(calendar).Error
+	})
+}
+
+// ReplaceBlocks swaps the blocks of a source and saves it in one transaction,
+// so availability never sees a half-synced calendar.
+func (r *externalCalendarRepository) ReplaceBlocks(ctx context.Context, calendar *models.ExternalCalendar, blocks []models.ExternalBlock) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("calendar_id = ?", calendar.ID).Delete(&models.ExternalBlock{}).Error; err != nil {
+			return err
+		}
+		if len(blocks) > 0 {
+			if err := tx.CreateInBatches(blocks, 500).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(calendar).Error
+	})
+}
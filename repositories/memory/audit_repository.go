@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+	"slices"
+	"time"
+)
+
+type auditRepository struct {
+	session
+}
+
+// NewAuditRepository returns an AuditRepository reading the entries appended
+// by the other repositories of store.
+func NewAuditRepository(store *Store) repositories.AuditRepository {
+	return &auditRepository{session{store: store}}
+}
+
+// Find returns the entries matching filter, newest first.
+func (r *auditRepository) Find(ctx context.Context, filter repositories.AuditFilter) ([]models.AuditEntry, error) {
+	entries := []models.AuditEntry{}
+	err := r.do(ctx, func(t *tables) error {
+		for _, entry := range slices.Backward(t.audit) {
+			if len(entries) == filter.Limit {
+				break
+			}
+			if auditMatches(entry, filter) {
+				entry.ActorID = clonePtr(entry.ActorID)
+				entries = append(entries, entry)
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// EachChained calls fn with every entry carrying a hash, oldest first. The
+// entries are copied first, so fn may use the repositories.
+func (r *auditRepository) EachChained(ctx context.Context, fn func(models.AuditEntry) error) error {
+	var entries []models.AuditEntry
+	err := r.do(ctx, func(t *tables) error {
+		for _, entry := range t.audit {
+			if entry.Hash != "" {
+				entry.ActorID = clonePtr(entry.ActorID)
+				entries = append(entries, entry)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func auditMatches(entry models.AuditEntry, filter repositories.AuditFilter) bool {
+	switch {
+	case filter.ActorID != nil && (entry.ActorID == nil || *entry.ActorID != *filter.ActorID),
+		filter.Action != "" && entry.Action != filter.Action,
+		filter.TargetType != "" && entry.TargetType != filter.TargetType,
+		filter.TargetID != 0 && entry.TargetID != filter.TargetID,
+		!filter.From.IsZero() && entry.CreatedAt.Before(filter.From),
+		!filter.To.IsZero() && !entry.CreatedAt.Before(filter.To),
+		filter.BeforeID != 0 && entry.ID >= filter.BeforeID:
+		return false
+	}
+	return true
+}
+
+// chainAuditEntry hashes entry together with the hash of the last chained
+// entry, as database.ChainAudit does. Its time is kept in UTC to the
+// microsecond, as a database keeps it.
+func (t *tables) chainAuditEntry(entry *models.AuditEntry) {
+	entry.CreatedAt = entry.CreatedAt.UTC().Truncate(time.Microsecond)
+	entry.PrevHash = ""
+	for _, last := range slices.Backward(t.audit) {
+		if last.Hash != "" {
+			entry.PrevHash = last.Hash
+			break
+		}
+	}
+	entry.Hash = entry.Digest()
+}
@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"cmp"
+	"context"
+	"time"
+)
+
+type bookingRuleRepository struct {
+	session
+}
+
+// NewBookingRuleRepository returns a BookingRuleRepository on store.
+func NewBookingRuleRepository(store *Store) repositories.BookingRuleRepository {
+	return &bookingRuleRepository{session{store: store}}
+}
+
+func (r *bookingRuleRepository) Create(ctx context.Context, rule *models.BookingRule) error {
+	return r.do(ctx, func(t *tables) error {
+		return t.insertRule(rule)
+	})
+}
+
+func (r *bookingRuleRepository) FindByID(ctx context.Context, id uint) (*models.BookingRule, error) {
+	var rule models.BookingRule
+	err := r.do(ctx, func(t *tables) error {
+		stored, ok := t.rules[id]
+		if !ok {
+			return &repositories.NotFoundError{Entity: "rule"}
+		}
+		rule = stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *bookingRuleRepository) FindByResource(ctx context.Context, resourceID uint) ([]models.BookingRule, error) {
+	var rules []models.BookingRule
+	err := r.do(ctx, func(t *tables) error {
+		rules = sorted(t.rules, func(rule models.BookingRule) bool {
+			return rule.ResourceID == resourceID
+		}, func(a, b models.BookingRule) int {
+			return cmp.Compare(a.ID, b.ID)
+		}, -1)
+		return nil
+	})
+	return rules, err
+}
+
+// Update saves every field of the rule, creating it when it does not exist.
+func (r *bookingRuleRepository) Update(ctx context.Context, rule *models.BookingRule) error {
+	return r.do(ctx, func(t *tables) error {
+		stored, ok := t.rules[rule.ID]
+		if !ok {
+			return t.insertRule(rule)
+		}
+		if rule.CreatedAt.IsZero() {
+			rule.CreatedAt = stored.CreatedAt
+		}
+		rule.UpdatedAt = time.Now()
+		t.rules[rule.ID] = *rule
+		return nil
+	})
+}
+
+func (r *bookingRuleRepository) Delete(ctx context.Context, rule *models.BookingRule) error {
+	return r.do(ctx, func(t *tables) error {
+		delete(t.rules, rule.ID)
+		return nil
+	})
+}
+
+func (t *tables) insertRule(rule *models.BookingRule) error {
+	if _, ok := t.rules[rule.ID]; ok && rule.ID != 0 {
+		return errUniqueID
+	}
+	rule.ID = newID(&t.lastRule, rule.ID)
+	stampCreated(&rule.Model)
+	t.rules[rule.ID] = *rule
+	return nil
+}
@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"cmp"
+	"context"
+	"time"
+)
+
+type externalCalendarRepository struct {
+	session
+}
+
+// NewExternalCalendarRepository returns an ExternalCalendarRepository on
+// store. The blocks it keeps hold their slots for the reservation
+// repositories of the same store.
+func NewExternalCalendarRepository(store *Store) repositories.ExternalCalendarRepository {
+	return &externalCalendarRepository{session{store: store}}
+}
+
+func (r *externalCalendarRepository) Create(ctx context.Context, calendar *models.ExternalCalendar) error {
+	return r.do(ctx, func(t *tables) error {
+		return t.insertCalendar(calendar)
+	})
+}
+
+func (r *externalCalendarRepository) FindByID(ctx context.Context, id uint) (*models.ExternalCalendar, error) {
+	var calendar models.ExternalCalendar
+	err := r.do(ctx, func(t *tables) error {
+		stored, ok := t.calendars[id]
+		if !ok {
+			return &repositories.NotFoundError{Entity: "external calendar"}
+		}
+		calendar = cloneCalendar(stored)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &calendar, nil
+}
+
+func (r *externalCalendarRepository) FindByResource(ctx context.Context, resourceID uint) ([]models.ExternalCalendar, error) {
+	return r.find(ctx, func(c models.ExternalCalendar) bool {
+		return c.ResourceID == resourceID
+	})
+}
+
+// FindSyncable returns the sources that can be fetched again.
+func (r *externalCalendarRepository) FindSyncable(ctx context.Context) ([]models.ExternalCalendar, error) {
+	return r.find(ctx, func(c models.ExternalCalendar) bool {
+		return c.URL != "" || c.FilePath != ""
+	})
+}
+
+func (r *externalCalendarRepository) Update(ctx context.Context, calendar *models.ExternalCalendar) error {
+	return r.do(ctx, func(t *tables) error {
+		return t.saveCalendar(calendar)
+	})
+}
+
+// Delete removes the source together with its blocks.
+func (r *externalCalendarRepository) Delete(ctx context.Context, calendar *models.ExternalCalendar) error {
+	return r.transaction(ctx, func(tx session) error {
+		t := tx.tx
+		t.deleteBlocks(calendar.ID)
+		delete(t.calendars, calendar.ID)
+		return nil
+	})
+}
+
+// ReplaceBlocks swaps the blocks of a source and saves it in one transaction,
+// so availability never sees a half-synced calendar.
+func (r *externalCalendarRepository) ReplaceBlocks(ctx context.Context, calendar *models.ExternalCalendar, blocks []models.ExternalBlock) error {
+	return r.transaction(ctx, func(tx session) error {
+		t := tx.tx
+		t.deleteBlocks(calendar.ID)
+		for i := range blocks {
+			if _, ok := t.blocks[blocks[i].ID]; ok && blocks[i].ID != 0 {
+				return errUniqueID
+			}
+			blocks[i].ID = newID(&t.lastBlock, blocks[i].ID)
+			t.blocks[blocks[i].ID] = blocks[i]
+		}
+		return t.saveCalendar(calendar)
+	})
+}
+
+// find returns copies of the calendars matching match, in ID order.
+func (r *externalCalendarRepository) find(ctx context.Context, match func(models.ExternalCalendar) bool) ([]models.ExternalCalendar, error) {
+	var calendars []models.ExternalCalendar
+	err := r.do(ctx, func(t *tables) error {
+		calendars = sorted(t.calendars, match, func(a, b models.ExternalCalendar) int {
+			return cmp.Compare(a.ID, b.ID)
+		}, -1)
+		for i := range calendars {
+			calendars[i] = cloneCalendar(calendars[i])
+		}
+		return nil
+	})
+	return calendars, err
+}
+
+func (t *tables) insertCalendar(calendar *models.ExternalCalendar) error {
+	if _, ok := t.calendars[calendar.ID]; ok && calendar.ID != 0 {
+		return errUniqueID
+	}
+	calendar.ID = newID(&t.lastCalendar, calendar.ID)
+	stampCreated(&calendar.Model)
+	t.calendars[calendar.ID] = cloneCalendar(*calendar)
+	return nil
+}
+
+// saveCalendar saves every field of the calendar, creating it when it does
+// not exist.
+func (t *tables) saveCalendar(calendar *models.ExternalCalendar) error {
+	stored, ok := t.calendars[calendar.ID]
+	if !ok {
+		return t.insertCalendar(calendar)
+	}
+	if calendar.CreatedAt.IsZero() {
+		calendar.CreatedAt = stored.CreatedAt
+	}
+	calendar.UpdatedAt = time.Now()
+	t.calendars[calendar.ID] = cloneCalendar(*calendar)
+	return nil
+}
+
+func (t *tables) deleteBlocks(calendarID uint) {
+	for id, block := range t.blocks {
+		if block.CalendarID == calendarID {
+			delete(t.blocks, id)
+		}
+	}
+}
+
+// blocksAsReservations returns the blocks matching match, in the order given,
+// as reservations with status "external" and no ID.
+func (t *tables) blocksAsReservations(match func(models.ExternalBlock) bool, order func(a, b models.ExternalBlock) int) []models.Reservation {
+	blocks := sorted(t.blocks, match, order, -1)
+	reservations := make([]models.Reservation, len(blocks))
+	for i, block := range blocks {
+		reservations[i] = models.Reservation{
+			ResourceID: block.ResourceID,
+			Date:       block.Date,
+			StartTime:  block.StartTime,
+			EndTime:    block.EndTime,
+			Status:     models.ReservationExternal,
+		}
+	}
+	return reservations
+}
+
+func cloneCalendar(calendar models.ExternalCalendar) models.ExternalCalendar {
+	calendar.LastSyncedAt = clonePtr(calendar.LastSyncedAt)
+	return calendar
+}
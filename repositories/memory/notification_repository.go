@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+	"errors"
+	"time"
+)
+
+// errUniquePreferences is the unique index on the user of the
+// notification_preferences table.
+var errUniquePreferences = errors.New("UNIQUE constraint failed: notification_preferences.user_id")
+
+type notificationRepository struct {
+	session
+}
+
+// NewNotificationRepository returns a NotificationRepository on store,
+// handling the events and reservations of its reservation repositories.
+func NewNotificationRepository(store *Store) repositories.NotificationRepository {
+	return &notificationRepository{session{store: store}}
+}
+
+// FindPreferences returns nil without error when the user has no preferences.
+func (r *notificationRepository) FindPreferences(ctx context.Context, userID uint) (*models.NotificationPreferences, error) {
+	var found *models.NotificationPreferences
+	err := r.do(ctx, func(t *tables) error {
+		for _, p := range t.preferences {
+			if p.UserID == userID {
+				preferences := p
+				found = &preferences
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// SavePreferences saves every field of the preferences, creating them when
+// they have no ID or do not exist.
+func (r *notificationRepository) SavePreferences(ctx context.Context, preferences *models.NotificationPreferences) error {
+	return r.do(ctx, func(t *tables) error {
+		for _, other := range t.preferences {
+			if other.ID != preferences.ID && other.UserID == preferences.UserID {
+				return errUniquePreferences
+			}
+		}
+		stored, ok := t.preferences[preferences.ID]
+		if !ok || preferences.ID == 0 {
+			preferences.ID = newID(&t.lastPreferences, preferences.ID)
+			stampCreated(&preferences.Model)
+		} else {
+			if preferences.CreatedAt.IsZero() {
+				preferences.CreatedAt = stored.CreatedAt
+			}
+			preferences.UpdatedAt = time.Now()
+		}
+		t.preferences[preferences.ID] = *preferences
+		return nil
+	})
+}
+
+// FindUnnotifiedEvents returns the oldest events not yet handled by the email
+// notifications.
+func (r *notificationRepository) FindUnnotifiedEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	return r.findEvents(ctx, func(event models.OutboxEvent) bool {
+		return event.NotifiedAt == nil
+	}, limit)
+}
+
+func (r *notificationRepository) MarkEventNotified(ctx context.Context, event *models.OutboxEvent) error {
+	now := time.Now()
+	err := r.do(ctx, func(t *tables) error {
+		for i := range t.events {
+			if t.events[i].ID == event.ID {
+				t.events[i].NotifiedAt = clonePtr(&now)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	event.NotifiedAt = &now
+	return nil
+}
+
+// FindReminderCandidates returns the confirmed reservations between two dates
+// whose reminder has not been sent.
+func (r *notificationRepository) FindReminderCandidates(ctx context.Context, fromDate, toDate string) ([]models.Reservation, error) {
+	return (&reservationRepository{r.session}).find(ctx, func(res models.Reservation) bool {
+		return res.Status == models.ReservationConfirmed && res.RemindedAt == nil && res.Date >= fromDate && res.Date <= toDate
+	}, byStart)
+}
+
+// MarkReminded records that the reminder of a reservation is being sent. It
+// reports false when another instance already claimed it.
+func (r *notificationRepository) MarkReminded(ctx context.Context, reservation *models.Reservation) (bool, error) {
+	now := time.Now()
+	marked := false
+	err := r.do(ctx, func(t *tables) error {
+		stored, ok := t.reservations[reservation.ID]
+		if !ok || stored.RemindedAt != nil {
+			return nil
+		}
+		stored.RemindedAt = clonePtr(&now)
+		stored.UpdatedAt = now
+		t.reservations[stored.ID] = stored
+		marked = true
+		return nil
+	})
+	if marked {
+		reservation.RemindedAt = &now
+	}
+	return marked, err
+}
@@ -0,0 +1,61 @@
+package memory
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+)
+
+type outboxRepository struct {
+	session
+}
+
+// NewOutboxRepository returns an OutboxRepository reading the events recorded
+// by the reservation repositories of store.
+func NewOutboxRepository(store *Store) repositories.OutboxRepository {
+	return &outboxRepository{session{store: store}}
+}
+
+// FindAfter returns the events recorded after the given one, oldest first.
+// Events are appended in ID order.
+func (r *outboxRepository) FindAfter(ctx context.Context, id uint, limit int) ([]models.OutboxEvent, error) {
+	return r.findEvents(ctx, func(event models.OutboxEvent) bool {
+		return event.ID > id
+	}, limit)
+}
+
+// LatestID returns the ID of the last event, or 0 when there is none.
+func (r *outboxRepository) LatestID(ctx context.Context) (uint, error) {
+	var id uint
+	err := r.do(ctx, func(t *tables) error {
+		if n := len(t.events); n > 0 {
+			id = t.events[n-1].ID
+		}
+		return nil
+	})
+	return id, err
+}
+
+// findEvents returns copies of the events matching match, oldest first, at
+// most limit of them.
+func (s session) findEvents(ctx context.Context, match func(models.OutboxEvent) bool, limit int) ([]models.OutboxEvent, error) {
+	events := []models.OutboxEvent{}
+	err := s.do(ctx, func(t *tables) error {
+		for _, event := range t.events {
+			if len(events) == limit {
+				break
+			}
+			if match(event) {
+				events = append(events, cloneEvent(event))
+			}
+		}
+		return nil
+	})
+	return events, err
+}
+
+func cloneEvent(event models.OutboxEvent) models.OutboxEvent {
+	event.ProcessedAt = clonePtr(event.ProcessedAt)
+	event.NotifiedAt = clonePtr(event.NotifiedAt)
+	return event
+}
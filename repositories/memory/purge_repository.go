@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// errRollback undoes a dry-run purge once its rows are counted.
+var errRollback = errors.New("rollback")
+
+type purgeRepository struct {
+	session
+}
+
+// NewPurgeRepository returns a PurgeRepository on store.
+func NewPurgeRepository(store *Store) repositories.PurgeRepository {
+	return &purgeRepository{session{store: store}}
+}
+
+// Purge permanently deletes, in one transaction, the reservations and external
+// blocks dated before date, the booking groups left empty, and the events and
+// webhook deliveries older than before that every consumer is done with. A dry
+// run counts the same rows and rolls back.
+func (r *purgeRepository) Purge(ctx context.Context, date string, before time.Time, dryRun bool) (*models.PurgeReport, error) {
+	report := &models.PurgeReport{}
+	err := r.transaction(ctx, func(tx session) error {
+		t := tx.tx
+		report.Reservations = deleteWhere(t.reservations, func(res models.Reservation) bool {
+			return res.Date < date
+		})
+		report.BookingGroups = deleteWhere(t.groups, func(group models.BookingGroup) bool {
+			return group.CreatedAt.Before(before) && !t.groupHasReservations(group.ID)
+		})
+		report.ExternalBlocks = deleteWhere(t.blocks, func(block models.ExternalBlock) bool {
+			return block.Date < date
+		})
+		// Events still waiting for the webhooks or the notifications stay.
+		events := len(t.events)
+		t.events = slices.DeleteFunc(t.events, func(event models.OutboxEvent) bool {
+			return event.CreatedAt.Before(before) && event.ProcessedAt != nil && event.NotifiedAt != nil
+		})
+		report.OutboxEvents = int64(events - len(t.events))
+		report.WebhookDeliveries = deleteWhere(t.deliveries, func(delivery models.WebhookDelivery) bool {
+			return delivery.CreatedAt.Before(before) && delivery.Status != models.DeliveryPending
+		})
+
+		if dryRun {
+			return errRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRollback) {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (t *tables) groupHasReservations(groupID uint) bool {
+	for _, res := range t.reservations {
+		if res.GroupID != nil && *res.GroupID == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteWhere deletes the rows matching match and counts them.
+func deleteWhere[T any](rows map[uint]T, match func(T) bool) int64 {
+	var n int64
+	for id, row := range rows {
+		if match(row) {
+			delete(rows, id)
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,74 @@
+package memory
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+	"time"
+)
+
+type quotaRepository struct {
+	session
+}
+
+// NewQuotaRepository returns a QuotaRepository on store.
+func NewQuotaRepository(store *Store) repositories.QuotaRepository {
+	return &quotaRepository{session{store: store}}
+}
+
+// FindByUser returns nil without error when the user has no override.
+func (r *quotaRepository) FindByUser(ctx context.Context, userID uint) (*models.Quota, error) {
+	return r.findOne(ctx, func(q models.Quota) bool {
+		return q.UserID != nil && *q.UserID == userID
+	})
+}
+
+// FindByRole returns nil without error when the role has no override.
+func (r *quotaRepository) FindByRole(ctx context.Context, role string) (*models.Quota, error) {
+	return r.findOne(ctx, func(q models.Quota) bool {
+		return q.Role == role && q.UserID == nil
+	})
+}
+
+// Save saves every field of the quota, creating it when it has no ID or does
+// not exist.
+func (r *quotaRepository) Save(ctx context.Context, quota *models.Quota) error {
+	return r.do(ctx, func(t *tables) error {
+		stored, ok := t.quotas[quota.ID]
+		if !ok || quota.ID == 0 {
+			quota.ID = newID(&t.lastQuota, quota.ID)
+			stampCreated(&quota.Model)
+		} else {
+			if quota.CreatedAt.IsZero() {
+				quota.CreatedAt = stored.CreatedAt
+			}
+			quota.UpdatedAt = time.Now()
+		}
+		t.quotas[quota.ID] = cloneQuota(*quota)
+		return nil
+	})
+}
+
+// findOne returns a copy of the quota with the lowest ID matching match, or
+// nil.
+func (r *quotaRepository) findOne(ctx context.Context, match func(models.Quota) bool) (*models.Quota, error) {
+	var found *models.Quota
+	err := r.do(ctx, func(t *tables) error {
+		for _, q := range t.quotas {
+			if match(q) && (found == nil || q.ID < found.ID) {
+				quota := cloneQuota(q)
+				found = &quota
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+func cloneQuota(quota models.Quota) models.Quota {
+	quota.UserID = clonePtr(quota.UserID)
+	return quota
+}
@@ -0,0 +1,395 @@
+package memory
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type reservationRepository struct {
+	session
+}
+
+// NewReservationRepository returns a ReservationRepository on store.
+func NewReservationRepository(store *Store) repositories.ReservationRepository {
+	return &reservationRepository{session{store: store}}
+}
+
+func (r *reservationRepository) Create(ctx context.Context, reservation *models.Reservation) error {
+	return r.do(ctx, func(t *tables) error {
+		return t.insertReservation(reservation)
+	})
+}
+
+// FindOverlapping returns what keeps the slot busy: reservations and blocks
+// imported from external calendars. Blocks are returned as reservations with
+// status "external" and no ID.
+func (r *reservationRepository) FindOverlapping(ctx context.Context, resourceID uint, date, start, end string) ([]models.Reservation, error) {
+	reservations, err := r.find(ctx, func(res models.Reservation) bool {
+		return res.ResourceID == resourceID && res.Date == date && !released(res) &&
+			((res.StartTime < end && res.EndTime > start) || (res.StartTime >= start && res.StartTime < end))
+	}, byID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.do(ctx, func(t *tables) error {
+		reservations = append(reservations, t.blocksAsReservations(func(block models.ExternalBlock) bool {
+			return block.ResourceID == resourceID && block.Date == date && block.StartTime < end && block.EndTime > start
+		}, func(a, b models.ExternalBlock) int {
+			return cmp.Compare(a.ID, b.ID)
+		})...)
+		return nil
+	})
+	return reservations, err
+}
+
+func (r *reservationRepository) FindByDate(ctx context.Context, date string) ([]models.Reservation, error) {
+	return r.find(ctx, func(res models.Reservation) bool {
+		return res.Date == date
+	}, byID)
+}
+
+// FindByResourceAndDate returns the reservations of a resource on a date
+// together with its external blocks, as FindOverlapping does.
+func (r *reservationRepository) FindByResourceAndDate(ctx context.Context, resourceID uint, date string) ([]models.Reservation, error) {
+	reservations, err := r.find(ctx, func(res models.Reservation) bool {
+		return res.ResourceID == resourceID && res.Date == date && !released(res)
+	}, func(a, b models.Reservation) int {
+		return cmp.Or(cmp.Compare(a.StartTime, b.StartTime), cmp.Compare(a.ID, b.ID))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.do(ctx, func(t *tables) error {
+		reservations = append(reservations, t.blocksAsReservations(func(block models.ExternalBlock) bool {
+			return block.ResourceID == resourceID && block.Date == date
+		}, func(a, b models.ExternalBlock) int {
+			return cmp.Or(cmp.Compare(a.StartTime, b.StartTime), cmp.Compare(a.ID, b.ID))
+		})...)
+		return nil
+	})
+	return reservations, err
+}
+
+// CountActiveByUser counts the reservations of a user that have not ended yet
+// at the given date and clock time, a booking group once.
+func (r *reservationRepository) CountActiveByUser(ctx context.Context, userID uint, date, clock string) (int64, error) {
+	var count int64
+	err := r.do(ctx, func(t *tables) error {
+		groups := map[uint]bool{}
+		for _, res := range t.reservations {
+			if res.UserID != userID || released(res) || !(res.Date > date || (res.Date == date && res.EndTime > clock)) {
+				continue
+			}
+			if res.GroupID == nil {
+				count++
+			} else {
+				groups[*res.GroupID] = true
+			}
+		}
+		count += int64(len(groups))
+		return nil
+	})
+	return count, err
+}
+
+func (r *reservationRepository) FindByUserBetween(ctx context.Context, userID uint, from, to string) ([]models.Reservation, error) {
+	return r.find(ctx, func(res models.Reservation) bool {
+		return res.UserID == userID && res.Date >= from && res.Date <= to && res.Status != models.ReservationCancelled
+	}, byStart)
+}
+
+// LockUser checks that the user exists. The store is already locked for the
+// whole transaction.
+func (r *reservationRepository) LockUser(ctx context.Context, userID uint) error {
+	return r.do(ctx, func(t *tables) error {
+		if _, ok := t.users[userID]; !ok {
+			return &repositories.NotFoundError{Entity: "user"}
+		}
+		return nil
+	})
+}
+
+func (r *reservationRepository) WithTransaction(ctx context.Context, fn func(repo repositories.ReservationRepository) error) error {
+	return r.transaction(ctx, func(tx session) error {
+		return fn(&reservationRepository{tx})
+	})
+}
+
+func (r *reservationRepository) FindByID(ctx context.Context, id uint) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := r.do(ctx, func(t *tables) error {
+		res, ok := t.reservations[id]
+		if !ok {
+			return &repositories.NotFoundError{Entity: "reservation"}
+		}
+		reservation = cloneReservation(res)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// Update saves every field of the reservation, creating it when it does not
+// exist.
+func (r *reservationRepository) Update(ctx context.Context, reservation *models.Reservation) error {
+	return r.do(ctx, func(t *tables) error {
+		stored, ok := t.reservations[reservation.ID]
+		if !ok {
+			return t.insertReservation(reservation)
+		}
+		if err := t.checkReservationKeys(reservation); err != nil {
+			return err
+		}
+		if reservation.CreatedAt.IsZero() {
+			reservation.CreatedAt = stored.CreatedAt
+		}
+		reservation.UpdatedAt = time.Now()
+		t.reservations[reservation.ID] = storedReservation(reservation)
+		return nil
+	})
+}
+
+func (r *reservationRepository) FindUncheckedStartedBefore(ctx context.Context, date, clock string) ([]models.Reservation, error) {
+	return r.find(ctx, func(res models.Reservation) bool {
+		return res.Status == models.ReservationConfirmed && (res.Date < date || (res.Date == date && res.StartTime <= clock))
+	}, byID)
+}
+
+// MarkNoShow flags a still unchecked reservation as no-show, bumping its
+// sequence so calendars pick up the change. It reports false when the
+// reservation was checked in or released concurrently.
+func (r *reservationRepository) MarkNoShow(ctx context.Context, reservation *models.Reservation) (bool, error) {
+	marked := false
+	err := r.do(ctx, func(t *tables) error {
+		stored, ok := t.reservations[reservation.ID]
+		if !ok || stored.Status != models.ReservationConfirmed {
+			return nil
+		}
+		stored.Status = models.ReservationNoShow
+		stored.Sequence++
+		stored.UpdatedAt = time.Now()
+		t.reservations[stored.ID] = stored
+		marked = true
+		return nil
+	})
+	if marked {
+		reservation.Status = models.ReservationNoShow
+		reservation.Sequence++
+	}
+	return marked, err
+}
+
+// MarkCheckedIn checks in a still confirmed reservation at the given time,
+// bumping its sequence. It reports false when the reservation was cancelled,
+// released or checked in concurrently.
+func (r *reservationRepository) MarkCheckedIn(ctx context.Context, reservation *models.Reservation, at time.Time) (bool, error) {
+	marked := false
+	err := r.do(ctx, func(t *tables) error {
+		stored, ok := t.reservations[reservation.ID]
+		if !ok || stored.Status != models.ReservationConfirmed {
+			return nil
+		}
+		stored.Status = models.ReservationCheckedIn
+		stored.CheckedInAt = clonePtr(&at)
+		stored.Sequence++
+		stored.UpdatedAt = time.Now()
+		t.reservations[stored.ID] = stored
+		marked = true
+		return nil
+	})
+	if marked {
+		reservation.Status = models.ReservationCheckedIn
+		reservation.CheckedInAt = &at
+		reservation.Sequence++
+	}
+	return marked, err
+}
+
+func (r *reservationRepository) CreateGroup(ctx context.Context, group *models.BookingGroup) error {
+	return r.do(ctx, func(t *tables) error {
+		if _, ok := t.users[group.UserID]; !ok {
+			return errForeignKey
+		}
+		if _, ok := t.groups[group.ID]; ok && group.ID != 0 {
+			return errUniqueID
+		}
+		group.ID = newID(&t.lastGroup, group.ID)
+		stampCreated(&group.Model)
+		stored := *group
+		stored.Reservations = nil
+		t.groups[group.ID] = stored
+		return nil
+	})
+}
+
+func (r *reservationRepository) FindByGroup(ctx context.Context, groupID uint) ([]models.Reservation, error) {
+	return r.find(ctx, func(res models.Reservation) bool {
+		return res.GroupID != nil && *res.GroupID == groupID
+	}, func(a, b models.Reservation) int {
+		return cmp.Or(cmp.Compare(a.ResourceID, b.ResourceID), cmp.Compare(a.ID, b.ID))
+	})
+}
+
+func (r *reservationRepository) FindByUserFrom(ctx context.Context, userID uint, from string) ([]models.Reservation, error) {
+	return r.find(ctx, func(res models.Reservation) bool {
+		return res.UserID == userID && res.Date >= from
+	}, byStart)
+}
+
+func (r *reservationRepository) FindByResourceFrom(ctx context.Context, resourceID uint, from string) ([]models.Reservation, error) {
+	return r.find(ctx, func(res models.Reservation) bool {
+		return res.ResourceID == resourceID && res.Date >= from
+	}, byStart)
+}
+
+// EachBetween calls fn for every reservation dated from `from` to `to`. The
+// matching rows are copied first, so fn may use the repositories.
+func (r *reservationRepository) EachBetween(ctx context.Context, from, to string, userID uint, fn func(models.Reservation) error) error {
+	reservations, err := r.find(ctx, func(res models.Reservation) bool {
+		return res.Date >= from && res.Date <= to && (userID == 0 || res.UserID == userID)
+	}, byStart)
+	if err != nil {
+		return err
+	}
+	for _, reservation := range reservations {
+		if err := fn(reservation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *reservationRepository) CreateOutboxEvent(ctx context.Context, event *models.OutboxEvent) error {
+	return r.do(ctx, func(t *tables) error {
+		return t.insertEvent(event)
+	})
+}
+
+func (r *reservationRepository) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	return r.do(ctx, func(t *tables) error {
+		return t.insertAudit(entry)
+	})
+}
+
+// find returns copies of the reservations matching match, sorted by order.
+func (r *reservationRepository) find(ctx context.Context, match func(models.Reservation) bool, order func(a, b models.Reservation) int) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.do(ctx, func(t *tables) error {
+		for _, res := range t.reservations {
+			if match(res) {
+				reservations = append(reservations, cloneReservation(res))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(reservations, order)
+	return reservations, nil
+}
+
+func (t *tables) insertReservation(reservation *models.Reservation) error {
+	if err := t.checkReservationKeys(reservation); err != nil {
+		return err
+	}
+	if _, ok := t.reservations[reservation.ID]; ok && reservation.ID != 0 {
+		return errUniqueID
+	}
+	reservation.ID = newID(&t.lastReservation, reservation.ID)
+	stampCreated(&reservation.Model)
+	if reservation.Status == "" {
+		reservation.Status = models.ReservationConfirmed
+	}
+	t.reservations[reservation.ID] = storedReservation(reservation)
+	return nil
+}
+
+// checkReservationKeys enforces the foreign keys of the reservations table.
+func (t *tables) checkReservationKeys(reservation *models.Reservation) error {
+	if _, ok := t.users[reservation.UserID]; !ok {
+		return errForeignKey
+	}
+	if reservation.GroupID != nil {
+		if _, ok := t.groups[*reservation.GroupID]; !ok {
+			return errForeignKey
+		}
+	}
+	return nil
+}
+
+func (t *tables) insertEvent(event *models.OutboxEvent) error {
+	event.ID = newID(&t.lastEvent, event.ID)
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	t.events = append(t.events, cloneEvent(*event))
+	return nil
+}
+
+// insertAudit appends an entry, chained to the one before it when the store
+// keeps a hash chain.
+func (t *tables) insertAudit(entry *models.AuditEntry) error {
+	entry.ID = newID(&t.lastAudit, entry.ID)
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	if t.chainAudit {
+		t.chainAuditEntry(entry)
+	}
+	stored := *entry
+	stored.ActorID = clonePtr(entry.ActorID)
+	t.audit = append(t.audit, stored)
+	return nil
+}
+
+// released reports reservations that no longer hold their time slot.
+func released(res models.Reservation) bool {
+	return res.Status == models.ReservationNoShow || res.Status == models.ReservationCancelled
+}
+
+func byID(a, b models.Reservation) int {
+	return cmp.Compare(a.ID, b.ID)
+}
+
+// byStart orders reservations by date and start time.
+func byStart(a, b models.Reservation) int {
+	return cmp.Or(cmp.Compare(a.Date, b.Date), cmp.Compare(a.StartTime, b.StartTime), cmp.Compare(a.ID, b.ID))
+}
+
+// stampCreated sets the timestamps of a new row, as GORM does.
+func stampCreated(model *gorm.Model) {
+	now := time.Now()
+	if model.CreatedAt.IsZero() {
+		model.CreatedAt = now
+	}
+	if model.UpdatedAt.IsZero() {
+		model.UpdatedAt = now
+	}
+}
+
+// storedReservation is the row kept for reservation: Previous is not a
+// column.
+func storedReservation(reservation *models.Reservation) models.Reservation {
+	stored := cloneReservation(*reservation)
+	stored.Previous = nil
+	return stored
+}
+
+func cloneReservation(res models.Reservation) models.Reservation {
+	res.GroupID = clonePtr(res.GroupID)
+	res.CheckedInAt = clonePtr(res.CheckedInAt)
+	res.RemindedAt = clonePtr(res.RemindedAt)
+	res.Previous = clonePtr(res.Previous)
+	return res
+}
@@ -0,0 +1,127 @@
+package memory
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"cmp"
+	"context"
+	"errors"
+	"time"
+)
+
+// errUniqueResourceFeedToken is the unique index on the feed token of the
+// resources table.
+var errUniqueResourceFeedToken = errors.New("UNIQUE constraint failed: resources.feed_token")
+
+type resourceRepository struct {
+	session
+}
+
+// NewResourceRepository returns a ResourceRepository on store.
+func NewResourceRepository(store *Store) repositories.ResourceRepository {
+	return &resourceRepository{session{store: store}}
+}
+
+func (r *resourceRepository) Create(ctx context.Context, resource *models.Resource) error {
+	return r.do(ctx, func(t *tables) error {
+		return t.insertResource(resource)
+	})
+}
+
+func (r *resourceRepository) FindByID(ctx context.Context, id uint) (*models.Resource, error) {
+	return r.findOne(ctx, func(res models.Resource) bool {
+		return res.ID == id
+	})
+}
+
+func (r *resourceRepository) FindAll(ctx context.Context) ([]models.Resource, error) {
+	var resources []models.Resource
+	err := r.do(ctx, func(t *tables) error {
+		resources = sorted(t.resources, func(models.Resource) bool { return true }, func(a, b models.Resource) int {
+			return cmp.Compare(a.ID, b.ID)
+		}, -1)
+		for i := range resources {
+			resources[i] = cloneResource(resources[i])
+		}
+		return nil
+	})
+	return resources, err
+}
+
+func (r *resourceRepository) FindByFeedToken(ctx context.Context, token string) (*models.Resource, error) {
+	return r.findOne(ctx, func(res models.Resource) bool {
+		return res.FeedToken != nil && *res.FeedToken == token
+	})
+}
+
+// Update saves every field of the resource, creating it when it does not
+// exist.
+func (r *resourceRepository) Update(ctx context.Context, resource *models.Resource) error {
+	return r.do(ctx, func(t *tables) error {
+		stored, ok := t.resources[resource.ID]
+		if !ok {
+			return t.insertResource(resource)
+		}
+		if err := t.checkResourceKeys(resource); err != nil {
+			return err
+		}
+		if resource.CreatedAt.IsZero() {
+			resource.CreatedAt = stored.CreatedAt
+		}
+		resource.UpdatedAt = time.Now()
+		t.resources[resource.ID] = cloneResource(*resource)
+		return nil
+	})
+}
+
+// findOne returns a copy of the resource with the lowest ID matching match.
+func (r *resourceRepository) findOne(ctx context.Context, match func(models.Resource) bool) (*models.Resource, error) {
+	var found *models.Resource
+	err := r.do(ctx, func(t *tables) error {
+		for _, res := range t.resources {
+			if match(res) && (found == nil || res.ID < found.ID) {
+				resource := cloneResource(res)
+				found = &resource
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, &repositories.NotFoundError{Entity: "resource"}
+	}
+	return found, nil
+}
+
+func (t *tables) insertResource(resource *models.Resource) error {
+	if err := t.checkResourceKeys(resource); err != nil {
+		return err
+	}
+	if _, ok := t.resources[resource.ID]; ok && resource.ID != 0 {
+		return errUniqueID
+	}
+	resource.ID = newID(&t.lastResource, resource.ID)
+	stampCreated(&resource.Model)
+	t.resources[resource.ID] = cloneResource(*resource)
+	return nil
+}
+
+// checkResourceKeys enforces the unique index of the resources table.
+func (t *tables) checkResourceKeys(resource *models.Resource) error {
+	if resource.FeedToken == nil {
+		return nil
+	}
+	for _, other := range t.resources {
+		if other.ID != resource.ID && other.FeedToken != nil && *other.FeedToken == *resource.FeedToken {
+			return errUniqueResourceFeedToken
+		}
+	}
+	return nil
+}
+
+func cloneResource(resource models.Resource) models.Resource {
+	resource.FeedToken = clonePtr(resource.FeedToken)
+	return resource
+}
@@ -0,0 +1,184 @@
+// Package memory implements repositories that keep their rows in memory, for
+// fast tests and for trying the API without a database. They behave as the
+// GORM repositories on the same schema: the same filters, ordering, defaults,
+// unique and foreign keys, and transactions that commit or roll back as a
+// whole. Everything is lost when the process ends.
+package memory
+
+import (
+	"booking-api/models"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// Constraint violations, as reported by the database for the same rows.
+var (
+	errForeignKey = errors.New("FOREIGN KEY constraint failed")
+	errUniqueID   = errors.New("UNIQUE constraint failed: primary key")
+)
+
+// Store holds the rows of the in-memory repositories. Repositories created on
+// the same Store see each other's rows, as tables of one database do. It is
+// safe for concurrent use: transactions are serialized, as in SQLite.
+type Store struct {
+	mu     sync.Mutex
+	tables tables
+}
+
+func NewStore() *Store {
+	return &Store{tables: tables{
+		users:         map[uint]models.User{},
+		reservations:  map[uint]models.Reservation{},
+		groups:        map[uint]models.BookingGroup{},
+		resources:     map[uint]models.Resource{},
+		rules:         map[uint]models.BookingRule{},
+		quotas:        map[uint]models.Quota{},
+		calendars:     map[uint]models.ExternalCalendar{},
+		blocks:        map[uint]models.ExternalBlock{},
+		subscriptions: map[uint]models.WebhookSubscription{},
+		deliveries:    map[uint]models.WebhookDelivery{},
+		preferences:   map[uint]models.NotificationPreferences{},
+	}}
+}
+
+// ChainAudit makes every audit entry appended to the store carry the hash of
+// the entry before it, as database.ChainAudit does for a database.
+func (s *Store) ChainAudit() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables.chainAudit = true
+}
+
+// tables are the rows of a Store. Rows are stored by value and copied in and
+// out, so callers never share memory with the store.
+type tables struct {
+	users         map[uint]models.User
+	reservations  map[uint]models.Reservation
+	groups        map[uint]models.BookingGroup
+	resources     map[uint]models.Resource
+	rules         map[uint]models.BookingRule
+	quotas        map[uint]models.Quota
+	calendars     map[uint]models.ExternalCalendar
+	blocks        map[uint]models.ExternalBlock
+	subscriptions map[uint]models.WebhookSubscription
+	deliveries    map[uint]models.WebhookDelivery
+	preferences   map[uint]models.NotificationPreferences
+	events        []models.OutboxEvent
+	audit         []models.AuditEntry
+
+	// The last ID given in each table.
+	lastUser, lastReservation, lastGroup, lastEvent, lastAudit uint
+	lastResource, lastRule, lastQuota, lastCalendar, lastBlock uint
+	lastSubscription, lastDelivery, lastPreferences            uint
+
+	// chainAudit hashes the audit entries, see Store.ChainAudit.
+	chainAudit bool
+}
+
+// clone copies the tables for a transaction to roll back to. Rows are stored
+// by value, so copying the maps and slices is enough.
+func (t *tables) clone() tables {
+	c := *t
+	c.users = maps.Clone(t.users)
+	c.reservations = maps.Clone(t.reservations)
+	c.groups = maps.Clone(t.groups)
+	c.resources = maps.Clone(t.resources)
+	c.rules = maps.Clone(t.rules)
+	c.quotas = maps.Clone(t.quotas)
+	c.calendars = maps.Clone(t.calendars)
+	c.blocks = maps.Clone(t.blocks)
+	c.subscriptions = maps.Clone(t.subscriptions)
+	c.deliveries = maps.Clone(t.deliveries)
+	c.preferences = maps.Clone(t.preferences)
+	c.events = slices.Clone(t.events)
+	c.audit = slices.Clone(t.audit)
+	return c
+}
+
+// session is how a repository reaches its Store: outside a transaction each
+// call takes the lock, inside one the transaction already holds it.
+type session struct {
+	store *Store
+	tx    *tables
+}
+
+// do runs fn on the tables. A done context fails the call, as it fails a
+// query.
+func (s session) do(ctx context.Context, fn func(t *tables) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if s.tx != nil {
+		return fn(s.tx)
+	}
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	return fn(&s.store.tables)
+}
+
+// transaction runs fn with a session bound to a transaction: the changes made
+// through it are undone when fn fails or panics. Nested in another
+// transaction it only undoes its own changes, as a savepoint does.
+func (s session) transaction(ctx context.Context, fn func(tx session) error) (err error) {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	t := s.tx
+	if t == nil {
+		s.store.mu.Lock()
+		defer s.store.mu.Unlock()
+		t = &s.store.tables
+	}
+
+	saved := t.clone()
+	defer func() {
+		if p := recover(); p != nil {
+			*t = saved
+			panic(p)
+		}
+		if err != nil {
+			*t = saved
+		}
+	}()
+	return fn(session{store: s.store, tx: t})
+}
+
+// newID returns the ID of a new row, given or next in the sequence of last.
+func newID(last *uint, id uint) uint {
+	if id == 0 {
+		*last++
+		return *last
+	}
+	if id > *last {
+		*last = id
+	}
+	return id
+}
+
+// sorted returns the rows matching match ordered by order, at most limit of
+// them when limit is not negative, as a LIMIT clause does. Like a query, it
+// returns an empty slice rather than nil.
+func sorted[T any](rows map[uint]T, match func(T) bool, order func(a, b T) int, limit int) []T {
+	found := []T{}
+	for _, row := range rows {
+		if match(row) {
+			found = append(found, row)
+		}
+	}
+	slices.SortFunc(found, order)
+	if limit >= 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+func clonePtr[T any](p *T) *T {
+	if p == nil {
+		return nil
+	}
+	v := *p
+	return &v
+}
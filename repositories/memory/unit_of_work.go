@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"booking-api/repositories"
+	"context"
+)
+
+type unitOfWork struct {
+	store *Store
+}
+
+// NewUnitOfWork returns a UnitOfWork on store.
+func NewUnitOfWork(store *Store) repositories.UnitOfWork {
+	return &unitOfWork{store: store}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(tx repositories.Tx) error) error {
+	return session{store: u.store}.transaction(ctx, func(tx session) error {
+		return fn(repositories.Tx{
+			Reservations: &reservationRepository{tx},
+			Users:        &userRepository{tx},
+			Resources:    &resourceRepository{tx},
+			Rules:        &bookingRuleRepository{tx},
+			Quotas:       &quotaRepository{tx},
+		})
+	})
+}
@@ -0,0 +1,156 @@
+package memory
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+	"errors"
+	"time"
+)
+
+// Unique indexes of the users table.
+var (
+	errUniqueEmail     = errors.New("UNIQUE constraint failed: users.email")
+	errUniqueFeedToken = errors.New("UNIQUE constraint failed: users.feed_token")
+)
+
+type userRepository struct {
+	session
+}
+
+// NewUserRepository returns a UserRepository on store.
+func NewUserRepository(store *Store) repositories.UserRepository {
+	return &userRepository{session{store: store}}
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.do(ctx, func(t *tables) error {
+		return t.insertUser(user)
+	})
+}
+
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.findOne(ctx, func(u models.User) bool {
+		return u.Email == email
+	})
+}
+
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	return r.findOne(ctx, func(u models.User) bool {
+		return u.ID == id
+	})
+}
+
+func (r *userRepository) FindByFeedToken(ctx context.Context, token string) (*models.User, error) {
+	return r.findOne(ctx, func(u models.User) bool {
+		return u.FeedToken != nil && *u.FeedToken == token
+	})
+}
+
+// Update saves every field of the user, creating it when it does not exist.
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	return r.do(ctx, func(t *tables) error {
+		stored, ok := t.users[user.ID]
+		if !ok {
+			return t.insertUser(user)
+		}
+		if err := t.checkUserKeys(user); err != nil {
+			return err
+		}
+		if user.CreatedAt.IsZero() {
+			user.CreatedAt = stored.CreatedAt
+		}
+		user.UpdatedAt = time.Now()
+		t.users[user.ID] = storedUser(user)
+		return nil
+	})
+}
+
+func (r *userRepository) IncrementNoShows(ctx context.Context, id uint) error {
+	return r.do(ctx, func(t *tables) error {
+		if user, ok := t.users[id]; ok {
+			user.NoShowCount++
+			user.UpdatedAt = time.Now()
+			t.users[id] = user
+		}
+		return nil
+	})
+}
+
+func (r *userRepository) WithTransaction(ctx context.Context, fn func(repo repositories.UserRepository) error) error {
+	return r.transaction(ctx, func(tx session) error {
+		return fn(&userRepository{tx})
+	})
+}
+
+func (r *userRepository) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	return r.do(ctx, func(t *tables) error {
+		return t.insertAudit(entry)
+	})
+}
+
+// findOne returns a copy of the user with the lowest ID matching match.
+func (r *userRepository) findOne(ctx context.Context, match func(models.User) bool) (*models.User, error) {
+	var found *models.User
+	err := r.do(ctx, func(t *tables) error {
+		for _, u := range t.users {
+			if match(u) && (found == nil || u.ID < found.ID) {
+				user := cloneUser(u)
+				found = &user
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, &repositories.NotFoundError{Entity: "user"}
+	}
+	return found, nil
+}
+
+func (t *tables) insertUser(user *models.User) error {
+	if err := t.checkUserKeys(user); err != nil {
+		return err
+	}
+	if _, ok := t.users[user.ID]; ok && user.ID != 0 {
+		return errUniqueID
+	}
+	user.ID = newID(&t.lastUser, user.ID)
+	stampCreated(&user.Model)
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	t.users[user.ID] = storedUser(user)
+	return nil
+}
+
+// checkUserKeys enforces the unique indexes of the users table.
+func (t *tables) checkUserKeys(user *models.User) error {
+	for _, other := range t.users {
+		if other.ID == user.ID {
+			continue
+		}
+		if other.Email == user.Email {
+			return errUniqueEmail
+		}
+		if user.FeedToken != nil && other.FeedToken != nil && *other.FeedToken == *user.FeedToken {
+			return errUniqueFeedToken
+		}
+	}
+	return nil
+}
+
+// storedUser is the row kept for user: its reservations are rows of their
+// own table.
+func storedUser(user *models.User) models.User {
+	stored := cloneUser(*user)
+	stored.Reservations = nil
+	return stored
+}
+
+func cloneUser(user models.User) models.User {
+	user.FeedToken = clonePtr(user.FeedToken)
+	return user
+}
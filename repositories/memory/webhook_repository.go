@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"cmp"
+	"context"
+	"slices"
+	"time"
+)
+
+type webhookRepository struct {
+	session
+}
+
+// NewWebhookRepository returns a WebhookRepository on store, dispatching the
+// events recorded by its reservation repositories.
+func NewWebhookRepository(store *Store) repositories.WebhookRepository {
+	return &webhookRepository{session{store: store}}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return r.do(ctx, func(t *tables) error {
+		if _, ok := t.subscriptions[subscription.ID]; ok && subscription.ID != 0 {
+			return errUniqueID
+		}
+		subscription.ID = newID(&t.lastSubscription, subscription.ID)
+		stampCreated(&subscription.Model)
+		t.subscriptions[subscription.ID] = *subscription
+		return nil
+	})
+}
+
+func (r *webhookRepository) FindSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.do(ctx, func(t *tables) error {
+		subscriptions = sorted(t.subscriptions, func(models.WebhookSubscription) bool { return true }, func(a, b models.WebhookSubscription) int {
+			return cmp.Compare(a.ID, b.ID)
+		}, -1)
+		return nil
+	})
+	return subscriptions, err
+}
+
+func (r *webhookRepository) FindSubscriptionByID(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := r.do(ctx, func(t *tables) error {
+		stored, ok := t.subscriptions[id]
+		if !ok {
+			return &repositories.NotFoundError{Entity: "webhook"}
+		}
+		subscription = stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return r.do(ctx, func(t *tables) error {
+		delete(t.subscriptions, subscription.ID)
+		return nil
+	})
+}
+
+// FindPendingEvents returns the oldest events not yet dispatched.
+func (r *webhookRepository) FindPendingEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	return r.findEvents(ctx, func(event models.OutboxEvent) bool {
+		return event.ProcessedAt == nil
+	}, limit)
+}
+
+// DispatchEvent stores the deliveries of an event and marks it as processed in
+// a single transaction, so an event is fanned out exactly once.
+func (r *webhookRepository) DispatchEvent(ctx context.Context, event *models.OutboxEvent, deliveries []models.WebhookDelivery) error {
+	return r.transaction(ctx, func(tx session) error {
+		t := tx.tx
+		i := slices.IndexFunc(t.events, func(e models.OutboxEvent) bool {
+			return e.ID == event.ID && e.ProcessedAt == nil
+		})
+		if i < 0 {
+			// Dispatched concurrently by another instance.
+			return nil
+		}
+		now := time.Now()
+		t.events[i].ProcessedAt = &now
+		event.ProcessedAt = clonePtr(&now)
+
+		for i := range deliveries {
+			if err := t.insertDelivery(&deliveries[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindDueDeliveries returns pending deliveries whose next attempt is due.
+func (r *webhookRepository) FindDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	return r.findDeliveries(ctx, func(d models.WebhookDelivery) bool {
+		return d.Status == models.DeliveryPending && d.NextAttemptAt != nil && !d.NextAttemptAt.After(now)
+	}, func(a, b models.WebhookDelivery) int {
+		return cmp.Or(a.NextAttemptAt.Compare(*b.NextAttemptAt), cmp.Compare(a.ID, b.ID))
+	}, limit)
+}
+
+func (r *webhookRepository) FindDeliveryByID(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.do(ctx, func(t *tables) error {
+		stored, ok := t.deliveries[id]
+		if !ok {
+			return &repositories.NotFoundError{Entity: "delivery"}
+		}
+		delivery = cloneDelivery(stored)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// FindDeliveriesBySubscription returns the latest deliveries of a subscription.
+func (r *webhookRepository) FindDeliveriesBySubscription(ctx context.Context, subscriptionID uint, limit int) ([]models.WebhookDelivery, error) {
+	return r.findDeliveries(ctx, func(d models.WebhookDelivery) bool {
+		return d.SubscriptionID == subscriptionID
+	}, func(a, b models.WebhookDelivery) int {
+		return cmp.Compare(b.ID, a.ID)
+	}, limit)
+}
+
+// UpdateDelivery saves every field of the delivery, creating it when it does
+// not exist.
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.do(ctx, func(t *tables) error {
+		stored, ok := t.deliveries[delivery.ID]
+		if !ok {
+			return t.insertDelivery(delivery)
+		}
+		if delivery.CreatedAt.IsZero() {
+			delivery.CreatedAt = stored.CreatedAt
+		}
+		delivery.UpdatedAt = time.Now()
+		t.deliveries[delivery.ID] = cloneDelivery(*delivery)
+		return nil
+	})
+}
+
+// findDeliveries returns copies of the deliveries matching match, sorted by
+// order, at most limit of them.
+func (r *webhookRepository) findDeliveries(ctx context.Context, match func(models.WebhookDelivery) bool, order func(a, b models.WebhookDelivery) int, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.do(ctx, func(t *tables) error {
+		deliveries = sorted(t.deliveries, match, order, limit)
+		for i := range deliveries {
+			deliveries[i] = cloneDelivery(deliveries[i])
+		}
+		return nil
+	})
+	return deliveries, err
+}
+
+// insertDelivery stores a new delivery with the column defaults of the
+// webhook_deliveries table.
+func (t *tables) insertDelivery(delivery *models.WebhookDelivery) error {
+	if _, ok := t.deliveries[delivery.ID]; ok && delivery.ID != 0 {
+		return errUniqueID
+	}
+	delivery.ID = newID(&t.lastDelivery, delivery.ID)
+	stampCreated(&delivery.Model)
+	if delivery.Status == "" {
+		delivery.Status = models.DeliveryPending
+	}
+	t.deliveries[delivery.ID] = cloneDelivery(*delivery)
+	return nil
+}
+
+func cloneDelivery(delivery models.WebhookDelivery) models.WebhookDelivery {
+	delivery.NextAttemptAt = clonePtr(delivery.NextAttemptAt)
+	delivery.LastAttemptAt = clonePtr(delivery.LastAttemptAt)
+	return delivery
+}
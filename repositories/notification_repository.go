@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"booking-api/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	FindPreferences(ctx context.Context, userID uint) (*models.NotificationPreferences, error)
+	SavePreferences(ctx context.Context, preferences *models.NotificationPreferences) error
+	FindUnnotifiedEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error)
+	MarkEventNotified(ctx context.Context, event *models.OutboxEvent) error
+	FindReminderCandidates(ctx context.Context, fromDate, toDate string) ([]models.Reservation, error)
+	MarkReminded(ctx context.Context, reservation *models.Reservation) (bool, error)
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// FindPreferences returns nil without error when the user has no preferences.
+func (r *notificationRepository) FindPreferences(ctx context.Context, userID uint) (*models.NotificationPreferences, error) {
+	var preferences models.NotificationPreferences
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&preferences)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &preferences, result.Error
+}
+
+func (r *notificationRepository) SavePreferences(ctx context.Context, preferences *models.NotificationPreferences) error {
+	return r.db.WithContext(ctx).Save(preferences).Error
+}
+
+// FindUnnotifiedEvents returns the oldest events not yet handled by the email
+// notifications.
+func (r *notificationRepository) FindUnnotifiedEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.WithContext(ctx).Where("notified_at IS NULL").Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+func (r *notificationRepository) MarkEventNotified(ctx context.Context, event *models.OutboxEvent) error {
+	now := time.Now()
+	if err := r.db.WithContext(ctx).Model(event).Update("notified_at", now).Error; err != nil {
+		return err
+	}
+	event.NotifiedAt = &now
+	return nil
+}
+
+// FindReminderCandidates returns the confirmed reservations between two dates
+// whose reminder has not been sent.
+func (r *notificationRepository) FindReminderCandidates(ctx context.Context, fromDate, toDate string) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Where("status = ? AND reminded_at IS NULL AND date >= ? AND date <= ?", models.ReservationConfirmed, fromDate, toDate).
+		Order("date, start_time").Find(&reservations).Error
+	return reservations, err
+}
+
+// MarkReminded records that the reminder of a reservation is being sent. It
+// reports false when another instance already claimed it.
+func (r *notificationRepository) MarkReminded(ctx context.Context, reservation *models.Reservation) (bool, error) {
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&models.Reservation{}).
+		Where("id = ? AND reminded_at IS NULL", reservation.ID).
+		Update("reminded_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+	reservation.RemindedAt = &now
+	return true, nil
+}
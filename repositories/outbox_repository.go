@@ -0,0 +1,36 @@
+package repositories
+
+import (
+	"booking-api/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	FindAfter(ctx context.Context, id uint, limit int) ([]models.OutboxEvent, error)
+	LatestID(ctx context.Context) (uint, error)
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// FindAfter returns the events recorded after the given one, oldest first,
+// whether or not they were already dispatched.
+func (r *outboxRepository) FindAfter(ctx context.Context, id uint, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.WithContext(ctx).Where("id > ?", id).Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// LatestID returns the ID of the last event, or 0 when there is none.
+func (r *outboxRepository) LatestID(ctx context.Context) (uint, error) {
+	var id uint
+	err := r.db.WithContext(ctx).Model(&models.OutboxEvent{}).Select("COALESCE(MAX(id), 0)").Scan(&id).Error
+	return id, err
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"booking-api/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PurgeRepository interface {
+	Purge(ctx context.Context, date string, before time.Time, dryRun bool) (*models.PurgeReport, error)
+}
+
+// errRollback undoes a dry-run purge once its rows are counted.
+var errRollback = errors.New("rollback")
+
+type purgeRepository struct {
+	db *gorm.DB
+}
+
+func NewPurgeRepository(db *gorm.DB) PurgeRepository {
+	return &purgeRepository{db: db}
+}
+
+// Purge permanently deletes, in one transaction, the reservations and external
+// blocks dated before date, the booking groups left empty, and the events and
+// webhook deliveries older than before that every consumer is done with. A dry
+// run counts the same rows and rolls back.
+func (r *purgeRepository) Purge(ctx context.Context, date string, before time.Time, dryRun bool) (*models.PurgeReport, error) {
+	report := &models.PurgeReport{}
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		count := func(n *int64, result *gorm.DB) error {
+			*n = result.RowsAffected
+			return result.Error
+		}
+
+		if err := count(&report.Reservations, tx.Unscoped().
+			Where("date < ?", date).
+			Delete(&models.Reservation{})); err != nil {
+			return err
+		}
+		if err := count(&report.BookingGroups, tx.Unscoped().
+			Where("created_at < ?", before).
+			Where("NOT EXISTS (SELECT 1 FROM reservations WHERE reservations.group_id = booking_groups.id)").
+			Delete(&models.BookingGroup{})); err != nil {
+			return err
+		}
+		if err := count(&report.ExternalBlocks, tx.
+			Where("date < ?", date).
+			Delete(&models.ExternalBlock{})); err != nil {
+			return err
+		}
+		// Events still waiting for the webhooks or the notifications stay.
+		if err := count(&report.OutboxEvents, tx.
+			Where("created_at < ? AND processed_at IS NOT NULL AND notified_at IS NOT NULL", before).
+			Delete(&models.OutboxEvent{})); err != nil {
+			return err
+		}
+		if err := count(&report.WebhookDeliveries, tx.Unscoped().
+			Where("created_at < ? AND status <> ?", before, models.DeliveryPending).
+			Delete(&models.WebhookDelivery{})); err != nil {
+			return err
+		}
+
+		if dryRun {
+			return errRollback
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errRollback) {
+		return nil, err
+	}
+	return report, nil
+}
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"booking-api/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type QuotaRepository interface {
+	FindByUser(ctx context.Context, userID uint) (*models.Quota, error)
+	FindByRole(ctx context.Context, role string) (*models.Quota, error)
+	Save(ctx context.Context, quota *models.Quota) error
+}
+
+type quotaRepository struct {
+	db *gorm.DB
+}
+
+func NewQuotaRepository(db *gorm.DB) QuotaRepository {
+	return &quotaRepository{db: db}
+}
+
+// FindByUser returns nil without error when the user has no override.
+func (r *quotaRepository) FindByUser(ctx context.Context, userID uint) (*models.Quota, error) {
+	var quota models.Quota
+	result := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&quota)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &quota, result.Error
+}
+
+// FindByRole returns nil without error when the role has no override.
+func (r *quotaRepository) FindByRole(ctx context.Context, role string) (*models.Quota, error) {
+	var quota models.Quota
+	result := r.db.WithContext(ctx).Where("role = ? AND user_id IS NULL", role).First(&quota)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &quota, result.Error
+}
+
+func (r *quotaRepository) Save(ctx context.Context, quota *models.Quota) error {
+	return r.db.WithContext(ctx).Save(quota).Error
+}
@@ -2,16 +2,40 @@ package repositories
 
 import (
 	"booking-api/models"
+	"context"
+	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ReservationRepository interface {
-	Create(reservation *models.Reservation) error
-	FindOverlapping(date, start, end string) ([]models.Reservation, error)
-	FindByDate(date string) ([]models.Reservation, error)
+	Create(ctx context.Context, reservation *models.Reservation) error
+	FindOverlapping(ctx context.Context, resourceID uint, date, start, end string) ([]models.Reservation, error)
+	FindByDate(ctx context.Context, date string) ([]models.Reservation, error)
+	FindByResourceAndDate(ctx context.Context, resourceID uint, date string) ([]models.Reservation, error)
+	CountActiveByUser(ctx context.Context, userID uint, date, clock string) (int64, error)
+	FindByUserBetween(ctx context.Context, userID uint, from, to string) ([]models.Reservation, error)
+	LockUser(ctx context.Context, userID uint) error
+	WithTransaction(ctx context.Context, fn func(repo ReservationRepository) error) error
+	FindByID(ctx context.Context, id uint) (*models.Reservation, error)
+	Update(ctx context.Context, reservation *models.Reservation) error
+	FindUncheckedStartedBefore(ctx context.Context, date, clock string) ([]models.Reservation, error)
+	MarkNoShow(ctx context.Context, reservation *models.Reservation) (bool, error)
+	MarkCheckedIn(ctx context.Context, reservation *models.Reservation, at time.Time) (bool, error)
+	CreateGroup(ctx context.Context, group *models.BookingGroup) error
+	FindByGroup(ctx context.Context, groupID uint) ([]models.Reservation, error)
+	FindByUserFrom(ctx context.Context, userID uint, from string) ([]models.Reservation, error)
+	FindByResourceFrom(ctx context.Context, resourceID uint, from string) ([]models.Reservation, error)
+	EachBetween(ctx context.Context, from, to string, userID uint, fn func(models.Reservation) error) error
+	CreateOutboxEvent(ctx context.Context, event *models.OutboxEvent) error
+	AppendAudit(ctx context.Context, entry *models.AuditEntry) error
 }
 
+// releasedStatuses are reservations that no longer hold their time slot.
+var releasedStatuses = []string{models.ReservationNoShow, models.ReservationCancelled}
+
 type reservationRepository struct {
 	db *gorm.DB
 }
@@ -20,19 +44,222 @@ func NewReservationRepository(db *gorm.DB) ReservationRepository {
 	return &reservationRepository{db: db}
 }
 
-func (r *reservationRepository) Create(reservation *models.Reservation) error {
-	return r.db.Create(reservation).Error
+func (r *reservationRepository) Create(ctx context.Context, reservation *models.Reservation) error {
+	return r.db.WithContext(ctx).Create(reservation).Error
+}
+
+// FindOverlapping returns what keeps the slot busy: reservations and blocks
+// imported from external calendars. Blocks are returned as reservations with
+// status "external" and no ID.
+func (r *reservationRepository) FindOverlapping(ctx context.Context, resourceID uint, date, start, end string) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Where("resource_id = ? AND date = ? AND status NOT IN ? AND ((start_time < ? AND end_time > ?) OR (start_time >= ? AND start_time < ?))",
+		resourceID, date, releasedStatuses, end, start, start, end).Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []models.ExternalBlock
+	err = r.db.WithContext(ctx).Where("resource_id = ? AND date = ? AND start_time < ? AND end_time > ?",
+		resourceID, date, end, start).Find(&blocks).Error
+	return append(reservations, blocksAsReservations(blocks)...), err
+}
+
+func (r *reservationRepository) FindByDate(ctx context.Context, date string) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Where("date = ?", date).Find(&reservations).Error
+	return reservations, err
+}
+
+// FindByResourceAndDate returns the reservations of a resource on a date
+// together with its external blocks, as FindOverlapping does.
+func (r *reservationRepository) FindByResourceAndDate(ctx context.Context, resourceID uint, date string) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Where("resource_id = ? AND date = ? AND status NOT IN ?", resourceID, date, releasedStatuses).
+		Order("start_time").Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var blocks []models.ExternalBlock
+	err = r.db.WithContext(ctx).Where("resource_id = ? AND date = ?", resourceID, date).Order("start_time").Find(&blocks).Error
+	return append(reservations, blocksAsReservations(blocks)...), err
+}
+
+// CountActiveByUser counts the reservations of a user that have not ended yet
+// at the given date and clock time. A booking group counts once. Released
+// no-shows and cancelled reservations are not active.
+func (r *reservationRepository) CountActiveByUser(ctx context.Context, userID uint, date, clock string) (int64, error) {
+	active := func() *gorm.DB {
+		return r.db.WithContext(ctx).Model(&models.Reservation{}).
+			Where("user_id = ? AND status NOT IN ? AND (date > ? OR (date = ? AND end_time > ?))",
+				userID, releasedStatuses, date, date, clock)
+	}
+
+	var single, groups int64
+	if err := active().Where("group_id IS NULL").Count(&single).Error; err != nil {
+		return 0, err
+	}
+	if err := active().Where("group_id IS NOT NULL").Distinct("group_id").Count(&groups).Error; err != nil {
+		return 0, err
+	}
+	return single + groups, nil
+}
+
+func (r *reservationRepository) FindByUserBetween(ctx context.Context, userID uint, from, to string) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Where("user_id = ? AND date >= ? AND date <= ? AND status <> ?", userID, from, to, models.ReservationCancelled).
+		Order("date, start_time").Find(&reservations).Error
+	return reservations, err
+}
+
+// LockUser takes a row lock on the user so concurrent bookings of the same user
+// are serialized. SQLite ignores the lock and serializes writers on its own.
+func (r *reservationRepository) LockUser(ctx context.Context, userID uint) error {
+	var user models.User
+	result := r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userID)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return &NotFoundError{Entity: "user"}
+	}
+	return result.Error
+}
+
+// WithTransaction runs fn with a repository bound to a single transaction.
+func (r *reservationRepository) WithTransaction(ctx context.Context, fn func(repo ReservationRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&reservationRepository{db: tx})
+	})
+}
+
+func (r *reservationRepository) FindByID(ctx context.Context, id uint) (*models.Reservation, error) {
+	var reservation models.Reservation
+	result := r.db.WithContext(ctx).First(&reservation, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, &NotFoundError{Entity: "reservation"}
+	}
+	return &reservation, result.Error
 }
 
-func (r *reservationRepository) FindOverlapping(date, start, end string) ([]models.Reservation, error) {
+func (r *reservationRepository) Update(ctx context.Context, reservation *models.Reservation) error {
+	return r.db.WithContext(ctx).Save(reservation).Error
+}
+
+// FindUncheckedStartedBefore returns confirmed reservations that started at or
+// before the given date and clock time without a check-in.
+func (r *reservationRepository) FindUncheckedStartedBefore(ctx context.Context, date, clock string) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Where("status = ? AND (date < ? OR (date = ? AND start_time <= ?))",
+		models.ReservationConfirmed, date, date, clock).Find(&reservations).Error
+	return reservations, err
+}
+
+// MarkNoShow flags a still unchecked reservation as no-show, bumping its
+// sequence so calendars pick up the change. It reports false when the
+// reservation was checked in or released concurrently.
+func (r *reservationRepository) MarkNoShow(ctx context.Context, reservation *models.Reservation) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Reservation{}).
+		Where("id = ? AND status = ?", reservation.ID, models.ReservationConfirmed).
+		Updates(map[string]interface{}{"status": models.ReservationNoShow, "sequence": gorm.Expr("sequence + 1")})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	reservation.Status = models.ReservationNoShow
+	reservation.Sequence++
+	return true, nil
+}
+
+// MarkCheckedIn checks in a still confirmed reservation at the given time,
+// bumping its sequence. It reports false when the reservation was cancelled,
+// released or checked in concurrently.
+func (r *reservationRepository) MarkCheckedIn(ctx context.Context, reservation *models.Reservation, at time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.Reservation{}).
+		Where("id = ? AND status = ?", reservation.ID, models.ReservationConfirmed).
+		Updates(map[string]interface{}{"status": models.ReservationCheckedIn, "checked_in_at": at, "sequence": gorm.Expr("sequence + 1")})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	reservation.Status = models.ReservationCheckedIn
+	reservation.CheckedInAt = &at
+	reservation.Sequence++
+	return true, nil
+}
+
+func (r *reservationRepository) CreateGroup(ctx context.Context, group *models.BookingGroup) error {
+	return r.db.WithContext(ctx).Create(group).Error
+}
+
+func (r *reservationRepository) FindByGroup(ctx context.Context, groupID uint) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Where("group_id = ?", groupID).Order("resource_id").Find(&reservations).Error
+	return reservations, err
+}
+
+// FindByUserFrom returns every reservation of a user from the given date on,
+// including cancelled ones.
+func (r *reservationRepository) FindByUserFrom(ctx context.Context, userID uint, from string) ([]models.Reservation, error) {
 	var reservations []models.Reservation
-	err := r.db.Where("date = ? AND ((start_time < ? AND end_time > ?) OR (start_time >= ? AND start_time < ?))",
-		date, end, start, start, end).Find(&reservations).Error
+	err := r.db.WithContext(ctx).Where("user_id = ? AND date >= ?", userID, from).Order("date, start_time").Find(&reservations).Error
 	return reservations, err
 }
 
-func (r *reservationRepository) FindByDate(date string) ([]models.Reservation, error) {
+// FindByResourceFrom returns every reservation of a resource from the given
+// date on, including cancelled ones.
+func (r *reservationRepository) FindByResourceFrom(ctx context.Context, resourceID uint, from string) ([]models.Reservation, error) {
 	var reservations []models.Reservation
-	err := r.db.Where("date = ?", date).Find(&reservations).Error
+	err := r.db.WithContext(ctx).Where("resource_id = ? AND date >= ?", resourceID, from).Order("date, start_time").Find(&reservations).Error
 	return reservations, err
 }
+
+// EachBetween calls fn for every reservation dated from `from` to `to`, both
+// included, ordered by date and time. Rows are read one at a time from the
+// cursor, so large ranges are not loaded into memory. A userID other than 0
+// limits the reservations to that user.
+func (r *reservationRepository) EachBetween(ctx context.Context, from, to string, userID uint, fn func(models.Reservation) error) error {
+	query := r.db.WithContext(ctx).Model(&models.Reservation{}).Where("date >= ? AND date <= ?", from, to)
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+
+	rows, err := query.Order("date, start_time, id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var reservation models.Reservation
+		if err := r.db.WithContext(ctx).ScanRows(rows, &reservation); err != nil {
+			return err
+		}
+		if err := fn(reservation); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// CreateOutboxEvent records an event to be published. Called on a repository
+// from WithTransaction, the event is only stored if the change is committed.
+func (r *reservationRepository) CreateOutboxEvent(ctx context.Context, event *models.OutboxEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// AppendAudit adds an entry to the audit log. Called on a repository from
+// WithTransaction, the entry is only stored if the change is committed.
+func (r *reservationRepository) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func blocksAsReservations(blocks []models.ExternalBlock) []models.Reservation {
+	reservations := make([]models.Reservation, len(blocks))
+	for i, block := range blocks {
+		reservations[i] = models.Reservation{
+			ResourceID: block.ResourceID,
+			Date:       block.Date,
+			StartTime:  block.StartTime,
+			EndTime:    block.EndTime,
+			Status:     models.ReservationExternal,
+		}
+	}
+	return reservations
+}
@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"booking-api/models"
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+type ResourceRepository interface {
+	Create(ctx context.Context, resource *models.Resource) error
+	FindByID(ctx context.Context, id uint) (*models.Resource, error)
+	FindAll(ctx context.Context) ([]models.Resource, error)
+	FindByFeedToken(ctx context.Context, token string) (*models.Resource, error)
+	Update(ctx context.Context, resource *models.Resource) error
+}
+
+type resourceRepository struct {
+	db *gorm.DB
+}
+
+func NewResourceRepository(db *gorm.DB) ResourceRepository {
+	return &resourceRepository{db: db}
+}
+
+func (r *resourceRepository) Create(ctx context.Context, resource *models.Resource) error {
+	return r.db.WithContext(ctx).Create(resource).Error
+}
+
+func (r *resourceRepository) FindByID(ctx context.Context, id uint) (*models.Resource, error) {
+	var resource models.Resource
+	result := r.db.WithContext(ctx).First(&resource, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, &NotFoundError{Entity: "resource"}
+	}
+	return &resource, result.Error
+}
+
+func (r *resourceRepository) FindAll(ctx context.Context) ([]models.Resource, error) {
+	var resources []models.Resource
+	err := r.db.WithContext(ctx).Order("id").Find(&resources).Error
+	return resources, err
+}
+
+func (r *resourceRepository) FindByFeedToken(ctx context.Context, token string) (*models.Resource, error) {
+	var resource models.Resource
+	result := r.db.WithContext(ctx).Where("feed_token = ?", token).First(&resource)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, &NotFoundError{Entity: "resource"}
+	}
+	return &resource, result.Error
+}
+
+func (r *resourceRepository) Update(ctx context.Context, resource *models.Resource) error {
+	return r.db.WithContext(ctx).Save(resource).Error
+}
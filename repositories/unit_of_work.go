@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Tx holds repositories bound to the same transaction.
+type Tx struct {
+	Reservations ReservationRepository
+	Users        UserRepository
+	Resources    ResourceRepository
+	Rules        BookingRuleRepository
+	Quotas       QuotaRepository
+}
+
+// UnitOfWork runs changes spanning several repositories atomically: fn gets
+// repositories bound to one transaction, committed when fn returns nil and
+// rolled back otherwise. Changes to a single repository can use its own
+// WithTransaction instead.
+type UnitOfWork interface {
+	Do(ctx context.Context, fn func(tx Tx) error) error
+}
+
+type unitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) UnitOfWork {
+	return &unitOfWork{db: db}
+}
+
+func (u *unitOfWork) Do(ctx context.Context, fn func(tx Tx) error) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(Tx{
+			Reservations: NewReservationRepository(tx),
+			Users:        NewUserRepository(tx),
+			Resources:    NewResourceRepository(tx),
+			Rules:        NewBookingRuleRepository(tx),
+			Quotas:       NewQuotaRepository(tx),
+		})
+	})
+}
@@ -2,15 +2,21 @@ package repositories
 
 import (
 	"booking-api/models"
+	"context"
 	"errors"
 
 	"gorm.io/gorm"
 )
 
 type UserRepository interface {
-	Create(user *models.User) error
-	FindByEmail(email string) (*models.User, error)
-	FindByID(id uint) (*models.User, error)
+	Create(ctx context.Context, user *models.User) error
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	FindByFeedToken(ctx context.Context, token string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	IncrementNoShows(ctx context.Context, id uint) error
+	WithTransaction(ctx context.Context, fn func(repo UserRepository) error) error
+	AppendAudit(ctx context.Context, entry *models.AuditEntry) error
 }
 
 type userRepository struct {
@@ -21,24 +27,57 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *userRepository) FindByEmail(email string) (*models.User, error) {
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	result := r.db.Where("email = ?", email).First(&user)
+	result := r.db.WithContext(ctx).Where("email = ?", email).First(&user)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return nil, errors.New("user not found")
+		return nil, &NotFoundError{Entity: "user"}
 	}
 	return &user, result.Error
 }
 
-func (r *userRepository) FindByID(id uint) (*models.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	result := r.db.First(&user, id)
+	result := r.db.WithContext(ctx).First(&user, id)
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-		return nil, errors.New("user not found")
+		return nil, &NotFoundError{Entity: "user"}
 	}
 	return &user, result.Error
 }
+
+func (r *userRepository) FindByFeedToken(ctx context.Context, token string) (*models.User, error) {
+	var user models.User
+	result := r.db.WithContext(ctx).Where("feed_token = ?", token).First(&user)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, &NotFoundError{Entity: "user"}
+	}
+	return &user, result.Error
+}
+
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
+}
+
+// IncrementNoShows bumps the no-show counter of the user in place, so
+// concurrent increments are not lost.
+func (r *userRepository) IncrementNoShows(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", id).
+		Update("no_show_count", gorm.Expr("no_show_count + 1")).Error
+}
+
+// WithTransaction runs fn with a repository bound to a single transaction.
+func (r *userRepository) WithTransaction(ctx context.Context, fn func(repo UserRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&userRepository{db: tx})
+	})
+}
+
+// AppendAudit adds an entry to the audit log. Called on a repository from
+// WithTransaction, the entry is only stored if the change is committed.
+func (r *userRepository) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
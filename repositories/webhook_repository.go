@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"booking-api/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	FindSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	FindSubscriptionByID(ctx context.Context, id uint) (*models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	FindPendingEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error)
+	DispatchEvent(ctx context.Context, event *models.OutboxEvent, deliveries []models.WebhookDelivery) error
+	FindDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error)
+	FindDeliveryByID(ctx context.Context, id uint) (*models.WebhookDelivery, error)
+	FindDeliveriesBySubscription(ctx context.Context, subscriptionID uint, limit int) ([]models.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(subscription).Error
+}
+
+func (r *webhookRepository) FindSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.db.WithContext(ctx).Order("id").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *webhookRepository) FindSubscriptionByID(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	result := r.db.WithContext(ctx).First(&subscription, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, &NotFoundError{Entity: "webhook"}
+	}
+	return &subscription, result.Error
+}
+
+func (r *webhookRepository) DeleteSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Delete(subscription).Error
+}
+
+// FindPendingEvents returns the oldest events not yet dispatched.
+func (r *webhookRepository) FindPendingEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	var events []models.OutboxEvent
+	err := r.db.WithContext(ctx).Where("processed_at IS NULL").Order("id").Limit(limit).Find(&events).Error
+	return events, err
+}
+
+// DispatchEvent stores the deliveries of an event and marks it as processed in
+// a single transaction, so an event is fanned out exactly once.
+func (r *webhookRepository) DispatchEvent(ctx context.Context, event *models.OutboxEvent, deliveries []models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&models.OutboxEvent{}).
+			Where("id = ? AND processed_at IS NULL", event.ID).
+			Update("processed_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Dispatched concurrently by another instance.
+			return nil
+		}
+		event.ProcessedAt = &now
+
+		if len(deliveries) == 0 {
+			return nil
+		}
+		return tx.Create(&deliveries).Error
+	})
+}
+
+// FindDueDeliveries returns pending deliveries whose next attempt is due.
+func (r *webhookRepository) FindDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+		Order("next_attempt_at").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepository) FindDeliveryByID(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	result := r.db.WithContext(ctx).First(&delivery, id)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return nil, &NotFoundError{Entity: "delivery"}
+	}
+	return &delivery, result.Error
+}
+
+// FindDeliveriesBySubscription returns the latest deliveries of a subscription.
+func (r *webhookRepository) FindDeliveriesBySubscription(ctx context.Context, subscriptionID uint, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.WithContext(ctx).Where("subscription_id = ?", subscriptionID).Order("id DESC").Limit(limit).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *webhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
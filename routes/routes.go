@@ -0,0 +1,73 @@
+package routes
+
+import (
+	"booking-api/controllers"
+	"booking-api/metrics"
+	"booking-api/middlewares"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// Current is the version served by the unversioned aliases.
+const Current = "/v1"
+
+// versions lists the prefix of every mounted version. A /v2 is added here and
+// mounted in Setup next to /v1, with its own controllers on the same services.
+var versions = []string{"/v1"}
+
+// aliasesDeprecated is when the unversioned routes were deprecated in favour
+// of /v1.
+var aliasesDeprecated = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// Controllers holds the handlers of version 1.
+type Controllers struct {
+	Auth             *controllers.AuthController
+	Reservation      *controllers.ReservationController
+	Resource         *controllers.ResourceController
+	Quota            *controllers.QuotaController
+	CheckIn          *controllers.CheckInController
+	Calendar         *controllers.CalendarController
+	ExternalCalendar *controllers.ExternalCalendarController
+	ReservationCSV   *controllers.ReservationCSVController
+	Webhook          *controllers.WebhookController
+	Notification     *controllers.NotificationController
+	Stream           *controllers.StreamController
+	Docs             *controllers.DocsController
+	Health           *controllers.HealthController
+	Audit            *controllers.AuditController
+}
+
+// Options configures Setup.
+type Options struct {
+	JWTSecret string
+	// DBTimeout bounds the database work of each request; 0 leaves it
+	// unbounded.
+	DBTimeout time.Duration
+	// Sunset is when the unversioned aliases stop being served.
+	Sunset time.Time
+}
+
+// Setup mounts the probes, the metrics and every version of the API. Other
+// requests outside the versioned prefixes are served by the Current version,
+// marked as deprecated.
+func Setup(app *fiber.App, c Controllers, opts Options) {
+	app.Use(middlewares.Tracing())
+	app.Use(middlewares.RequestID())
+	app.Use(middlewares.AuditActor())
+	app.Use(middlewares.AccessLog())
+	app.Use(middlewares.Metrics())
+	app.Use(middlewares.Locale())
+	app.Use(middlewares.DBTimeout(opts.DBTimeout))
+
+	// The probes and metrics belong to the deployment, not to a version of
+	// the API.
+	app.Get("/healthz", c.Health.Liveness)
+	app.Get("/readyz", c.Health.Readiness)
+	app.Get("/metrics", adaptor.HTTPHandler(metrics.Handler()))
+
+	app.Use(middlewares.VersionAlias(Current, versions, aliasesDeprecated, opts.Sunset))
+
+	registerV1(app.Group("/v1"), c, opts.JWTSecret)
+}
@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"booking-api/middlewares"
+	"booking-api/models"
+
+	"github.com/gofiber/fiber/v2"
+	jwtware "github.com/gofiber/jwt/v3"
+)
+
+// registerV1 registers version 1 of the API on r. The OpenAPI document served
+// at /v1/openapi.json describes these routes and must be kept in step with them.
+func registerV1(r fiber.Router, c Controllers, jwtSecret string) {
+	r.Get("/openapi.json", c.Docs.GetSpec)
+	r.Get("/docs", c.Docs.GetUI)
+
+	r.Post("/register", c.Auth.Register)
+	r.Post("/login", c.Auth.Login)
+	r.Get("/feeds/:token/calendar.ics", c.Calendar.GetFeed)
+
+	// Registered before the /reservations middleware, which only accepts the
+	// token in the Authorization header.
+	r.Get("/reservations/stream", middlewares.ProtectedStream(jwtSecret), c.Stream.StreamReservations)
+
+	r.Use("/reservations", jwtware.New(jwtware.Config{
+		SigningKey:   []byte(jwtSecret),
+		ContextKey:   "user",
+		ErrorHandler: middlewares.JWTError,
+	}))
+
+	protected := r.Group("/reservations", middlewares.Protected(jwtSecret))
+
+	protected.Post("/", middlewares.Protected(jwtSecret), c.Reservation.CreateReservation)
+	protected.Get("/", middlewares.Protected(jwtSecret), c.Reservation.GetReservationsByDate)
+	protected.Get("/availability", c.Reservation.GetAvailability)
+	protected.Get("/export", c.ReservationCSV.ExportReservations)
+	protected.Get("/:id.ics", c.Calendar.GetReservationCalendar)
+	protected.Post("/:id/check-in", c.CheckIn.CheckIn)
+	protected.Post("/:id/cancel", c.Reservation.CancelReservation)
+	protected.Patch("/:id", c.Reservation.RescheduleReservation)
+
+	resources := r.Group("/resources", middlewares.Protected(jwtSecret))
+	resources.Post("/", middlewares.RequireRole(models.RoleAdmin), c.Resource.CreateResource)
+	resources.Get("/", c.Resource.GetResources)
+	resources.Post("/:id/rules", middlewares.RequireRole(models.RoleAdmin), c.Resource.AddRule)
+	resources.Get("/:id/rules", middlewares.RequireRole(models.RoleAdmin), c.Resource.GetRules)
+	resources.Get("/:id/feed", middlewares.RequireRole(models.RoleAdmin), c.Calendar.GetResourceFeed)
+	resources.Post("/:id/feed", middlewares.RequireRole(models.RoleAdmin), c.Calendar.RotateResourceFeed)
+	resources.Get("/:id/calendars", middlewares.RequireRole(models.RoleAdmin), c.ExternalCalendar.GetCalendars)
+	resources.Post("/:id/calendars", middlewares.RequireRole(models.RoleAdmin), c.ExternalCalendar.AddCalendar)
+	resources.Post("/:id/calendars/upload", middlewares.RequireRole(models.RoleAdmin), c.ExternalCalendar.UploadCalendar)
+
+	externalCalendars := r.Group("/calendars", middlewares.Protected(jwtSecret), middlewares.RequireRole(models.RoleAdmin))
+	externalCalendars.Post("/:id/sync", c.ExternalCalendar.SyncCalendar)
+	externalCalendars.Delete("/:id", c.ExternalCalendar.DeleteCalendar)
+
+	me := r.Group("/me", middlewares.Protected(jwtSecret))
+	me.Get("/quota", c.Quota.GetMyQuota)
+	me.Get("/feed", c.Calendar.GetMyFeed)
+	me.Post("/feed", c.Calendar.RotateMyFeed)
+	me.Get("/notifications", c.Notification.GetMyPreferences)
+	me.Put("/notifications", c.Notification.UpdateMyPreferences)
+
+	admin := r.Group("/admin", middlewares.Protected(jwtSecret), middlewares.RequireRole(models.RoleAdmin))
+	admin.Post("/reservations/import", c.ReservationCSV.ImportReservations)
+	admin.Put("/rules/:id", c.Resource.UpdateRule)
+	admin.Delete("/rules/:id", c.Resource.DeleteRule)
+
+	webhooks := r.Group("/webhooks", middlewares.Protected(jwtSecret), middlewares.RequireRole(models.RoleAdmin))
+	webhooks.Post("/", c.Webhook.CreateSubscription)
+	webhooks.Get("/", c.Webhook.GetSubscriptions)
+	webhooks.Delete("/:id", c.Webhook.DeleteSubscription)
+	webhooks.Get("/:id/deliveries", c.Webhook.GetDeliveries)
+	webhooks.Post("/deliveries/:id/redeliver", c.Webhook.Redeliver)
+
+	quotas := r.Group("/quotas", middlewares.Protected(jwtSecret), middlewares.RequireRole(models.RoleAdmin))
+	quotas.Put("/roles/:role", c.Quota.SetRoleQuota)
+	quotas.Put("/users/:id", c.Quota.SetUserQuota)
+
+	auditLog := r.Group("/audit", middlewares.Protected(jwtSecret), middlewares.RequireRole(models.RoleAdmin))
+	auditLog.Get("/", c.Audit.GetEntries)
+	auditLog.Get("/verify", c.Audit.VerifyChain)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"booking-api/config"
+	"booking-api/controllers"
+	"booking-api/database"
+	"booking-api/jobs"
+	"booking-api/routes"
+	"booking-api/services"
+	"booking-api/tracing"
+	"context"
+	"log/slog"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// runServe starts the background jobs and serves the API until SIGINT or
+// SIGTERM, then shuts down gracefully: the instance turns unready, open
+// streams end, in-flight requests and job runs get up to
+// SHUTDOWN_TIMEOUT_SECONDS to finish, the database pool is closed and the
+// pending spans are flushed.
+func runServe(cfg config.Config, args []string) int {
+	flags := newFlagSet("serve", "serve")
+	if code, ok := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	// Validated by config.Load.
+	sunset, _ := time.Parse("2006-01-02", cfg.Server.LegacyRoutesSunset)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.Exporter)
+	if err != nil {
+		slog.Error("no se pudo configurar el trazado", "error", err)
+		return 1
+	}
+
+	a := newApplication(cfg)
+	if cfg.Database.Storage == config.StorageMemory {
+		created, err := seed(ctx, a)
+		if err != nil {
+			slog.Error("no se pudieron crear los recursos de ejemplo", "error", err)
+			return 1
+		}
+		slog.Info("modo demo: recursos de ejemplo creados", "resources", len(created))
+	}
+	var checks []services.HealthCheck
+	if cfg.Database.Storage != config.StorageMemory {
+		checks = append(checks,
+			services.HealthCheck{Name: "database", Check: database.Ping},
+			services.HealthCheck{Name: "migrations", Check: database.CheckMigrations},
+		)
+	}
+	health := services.NewHealthService(checks...)
+
+	var workers sync.WaitGroup
+	jobs.StartNoShowJob(ctx, &workers, a.checkIn, time.Duration(cfg.Jobs.NoShowIntervalSeconds)*time.Second)
+	jobs.StartCalendarSyncJob(ctx, &workers, a.externalCalendar, time.Duration(cfg.Jobs.ExternalSyncIntervalMinutes)*time.Minute)
+	jobs.StartWebhookJob(ctx, &workers, a.webhook, time.Duration(cfg.Jobs.WebhookIntervalSeconds)*time.Second)
+	jobs.StartNotificationJob(ctx, &workers, a.notification, time.Duration(cfg.Jobs.NotificationIntervalSeconds)*time.Second)
+	jobs.StartStreamRelayJob(ctx, &workers, a.stream, time.Duration(cfg.Jobs.StreamPollMilliseconds)*time.Millisecond)
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler: controllers.ErrorHandler,
+		// The banner is not a log record; the start is logged below instead.
+		DisableStartupMessage: true,
+	})
+
+	routes.Setup(app, routes.Controllers{
+		Auth:             controllers.NewAuthController(a.auth),
+		Reservation:      controllers.NewReservationController(a.reservation),
+		Resource:         controllers.NewResourceController(a.resource),
+		Quota:            controllers.NewQuotaController(a.quota),
+		CheckIn:          controllers.NewCheckInController(a.checkIn),
+		Calendar:         controllers.NewCalendarController(a.calendar),
+		ExternalCalendar: controllers.NewExternalCalendarController(a.externalCalendar),
+		ReservationCSV:   controllers.NewReservationCSVController(a.reservationCSV),
+		Webhook:          controllers.NewWebhookController(a.webhook),
+		Notification:     controllers.NewNotificationController(a.notification),
+		Stream:           controllers.NewStreamController(a.stream),
+		Docs:             controllers.NewDocsController(),
+		Health:           controllers.NewHealthController(health),
+		Audit:            controllers.NewAuditController(a.audit),
+	}, routes.Options{
+		JWTSecret: cfg.Auth.JWTSecret,
+		DBTimeout: cfg.DBRequestTimeout(),
+		Sunset:    sunset,
+	})
+
+	port := strconv.Itoa(cfg.Server.Port)
+
+	listenErr := make(chan error, 1)
+	go func() {
+		slog.Info("servidor iniciado", "port", port)
+		listenErr <- app.Listen(":" + port)
+	}()
+
+	select {
+	case err := <-listenErr:
+		slog.Error("no se pudo iniciar el servidor", "error", err)
+		return 1
+	case <-ctx.Done():
+	}
+	stop()
+
+	timeout := cfg.ShutdownTimeout()
+	slog.Info("cerrando el servidor", "timeout", timeout.String())
+	deadline := time.Now().Add(timeout)
+
+	health.Drain()
+	a.stream.Close()
+	code := 0
+	if err := app.ShutdownWithTimeout(timeout); err != nil {
+		slog.Error("quedaron conexiones abiertas al cerrar el servidor", "error", err)
+		code = 1
+	}
+	if !waitUntil(&workers, deadline) {
+		slog.Error("los procesos en segundo plano no terminaron a tiempo")
+		code = 1
+	}
+	if cfg.Database.Storage != config.StorageMemory {
+		if err := database.Close(); err != nil {
+			slog.Error("error al cerrar la base de datos", "error", err)
+			code = 1
+		}
+	}
+	flushCtx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	if err := shutdownTracing(flushCtx); err != nil {
+		slog.Error("no se pudieron enviar las últimas trazas", "error", err)
+		code = 1
+	}
+	if code == 0 {
+		slog.Info("servidor cerrado correctamente")
+	}
+	return code
+}
+
+// waitUntil waits for wg until deadline and reports whether it finished.
+func waitUntil(wg *sync.WaitGroup, deadline time.Time) bool {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(time.Until(deadline)):
+		return false
+	}
+}
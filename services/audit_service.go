@@ -0,0 +1,111 @@
+package services
+
+import (
+	"booking-api/audit"
+	"booking-api/i18n"
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Page sizes of the audit log.
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+)
+
+type AuditService interface {
+	List(ctx context.Context, filter repositories.AuditFilter) ([]models.AuditEntry, error)
+	Verify(ctx context.Context) (*AuditVerification, error)
+}
+
+// AuditVerification is the result of checking the hash chain of the audit
+// log. BrokenAt is the first entry that was altered, or whose predecessor was
+// removed. LastHash lets a later check tell whether entries were removed from
+// the end: it must still be in the chain.
+type AuditVerification struct {
+	Valid    bool   `json:"valid"`
+	Checked  int    `json:"checked"`
+	LastHash string `json:"last_hash,omitempty"`
+	BrokenAt *uint  `json:"broken_at,omitempty"`
+}
+
+// errChainBroken stops the walk of Verify at the first broken entry.
+var errChainBroken = errors.New("audit chain broken")
+
+type auditService struct {
+	repo repositories.AuditRepository
+}
+
+func NewAuditService(repo repositories.AuditRepository) AuditService {
+	return &auditService{repo: repo}
+}
+
+// List returns a page of the entries matching filter, newest first.
+func (s *auditService) List(ctx context.Context, filter repositories.AuditFilter) ([]models.AuditEntry, error) {
+	switch {
+	case filter.Limit == 0:
+		filter.Limit = DefaultAuditLimit
+	case filter.Limit < 0 || filter.Limit > MaxAuditLimit:
+		return nil, invalidField("limit", CodeInvalidValue, i18n.Params{"max": MaxAuditLimit})
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		return nil, invalidField("to", CodeInvalidValue, nil)
+	}
+
+	entries, err := s.repo.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load audit log: %v", err)
+	}
+	return entries, nil
+}
+
+// Verify walks the hash chain from its first entry and stops at the first one
+// that does not match. Entries written while the chain was disabled carry no
+// hash and are not checked.
+func (s *auditService) Verify(ctx context.Context) (*AuditVerification, error) {
+	result := &AuditVerification{Valid: true}
+	err := s.repo.EachChained(ctx, func(entry models.AuditEntry) error {
+		if entry.PrevHash != result.LastHash || entry.Hash != entry.Digest() {
+			result.Valid = false
+			result.BrokenAt = &entry.ID
+			return errChainBroken
+		}
+		result.Checked++
+		result.LastHash = entry.Hash
+		return nil
+	})
+	if err != nil && !errors.Is(err, errChainBroken) {
+		return nil, fmt.Errorf("failed to verify audit log: %v", err)
+	}
+	return result, nil
+}
+
+// auditReservation appends to the audit log of repo, which should be bound
+// to the transaction of the change, the action of the actor of ctx on a
+// reservation. before is nil when the reservation is being created.
+func auditReservation(ctx context.Context, repo repositories.ReservationRepository, action string, before, after *models.Reservation) error {
+	entry, err := audit.NewEntry(ctx, action, models.AuditTargetReservation, after.ID, before, after)
+	if err != nil {
+		return err
+	}
+	if err := repo.AppendAudit(ctx, entry); err != nil {
+		return fmt.Errorf("failed to audit %s: %v", action, err)
+	}
+	return nil
+}
+
+// auditUser is auditReservation for an account. before and after are nil for
+// actions that change nothing, such as logging in.
+func auditUser(ctx context.Context, repo repositories.UserRepository, action string, userID uint, before, after interface{}) error {
+	entry, err := audit.NewEntry(ctx, action, models.AuditTargetUser, userID, before, after)
+	if err != nil {
+		return err
+	}
+	if err := repo.AppendAudit(ctx, entry); err != nil {
+		return fmt.Errorf("failed to audit %s: %v", action, err)
+	}
+	return nil
+}
@@ -1,56 +1,156 @@
 package services
 
 import (
+	"booking-api/audit"
+	"booking-api/metrics"
 	"booking-api/models"
 	"booking-api/repositories"
+	"booking-api/tracing"
 	"booking-api/utils"
-	"errors"
+	"context"
 	"fmt"
+	"time"
 )
 
 type AuthService interface {
-	Register(user *models.User) error
-	Login(email, password string) (string, error)
+	Register(ctx context.Context, user *models.User) error
+	Login(ctx context.Context, email, password string) (string, error)
+	CreateAdmin(ctx context.Context, user *models.User) error
+	ResetPassword(ctx context.Context, email, password string) error
+	IssueToken(user *models.User) (string, error)
+}
+
+// AuthSettings configures the tokens: the secret they are signed with and
+// how long they stay valid.
+type AuthSettings struct {
+	JWTSecret string
+	TokenTTL  time.Duration
 }
 
 type authService struct {
 	userRepo repositories.UserRepository
+	settings AuthSettings
+}
+
+func NewAuthService(userRepo repositories.UserRepository, settings AuthSettings) AuthService {
+	return &authService{userRepo: userRepo, settings: settings}
 }
 
-func NewAuthService(userRepo repositories.UserRepository) AuthService {
-	return &authService{userRepo: userRepo}
+func (s *authService) Register(ctx context.Context, user *models.User) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Register")
+	defer tracing.End(span, &err)
+
+	return s.create(ctx, user, models.RoleUser, models.AuditUserRegistered)
 }
 
-func (s *authService) Register(user *models.User) error {
+// CreateAdmin registers a user with the admin role. It is only reachable from
+// the command line, never from the API.
+func (s *authService) CreateAdmin(ctx context.Context, user *models.User) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.CreateAdmin")
+	defer tracing.End(span, &err)
+
+	return s.create(ctx, user, models.RoleAdmin, models.AuditUserAdminCreated)
+}
 
-	existing, err := s.userRepo.FindByEmail(user.Email)
+func (s *authService) create(ctx context.Context, user *models.User, role, action string) error {
+	existing, err := s.userRepo.FindByEmail(ctx, user.Email)
 	if err == nil && existing != nil {
-		return errors.New("email already in use")
+		return &Error{Err: ErrConflict, Code: CodeEmailInUse, Field: "email", Message: describe(CodeEmailInUse, "email", nil)}
 	}
 
-	hashedPassword, err := utils.HashPassword(user.Password)
+	hashedPassword, err := hashPassword(ctx, user.Password)
 	if err != nil {
 		return fmt.Errorf("failed to hash password: %v", err)
 	}
 	user.Password = hashedPassword
+	user.Role = role
 
-	return s.userRepo.Create(user)
+	return s.userRepo.WithTransaction(ctx, func(tx repositories.UserRepository) error {
+		if err := tx.Create(ctx, user); err != nil {
+			return err
+		}
+		return auditUser(ctx, tx, action, user.ID, nil, user)
+	})
 }
 
-func (s *authService) Login(email, password string) (string, error) {
-	user, err := s.userRepo.FindByEmail(email)
+func (s *authService) Login(ctx context.Context, email, password string) (_ string, err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.Login")
+	defer tracing.End(span, &err)
+
+	user, err := s.userRepo.FindByEmail(ctx, email)
 	if err != nil || user == nil {
-		return "", errors.New("invalid email or password")
+		metrics.LoginFailed()
+		return "", newError(ErrUnauthorized, CodeInvalidCredentials, nil)
 	}
-
-	if !utils.CheckPasswordHash(password, user.Password) {
-		return "", errors.New("invalid email or password")
+	if !checkPassword(ctx, password, user.Password) {
+		metrics.LoginFailed()
+		if err := s.auditLogin(ctx, models.AuditLoginFailed, user.ID); err != nil {
+			return "", err
+		}
+		return "", newError(ErrUnauthorized, CodeInvalidCredentials, nil)
+	}
+	if err := s.auditLogin(audit.WithUser(ctx, user.ID), models.AuditLoginSucceeded, user.ID); err != nil {
+		return "", err
 	}
 
-	token, err := utils.GenerateJWT(user.ID)
+	return s.IssueToken(user)
+}
+
+// auditLogin records a login attempt. The entry is appended in a transaction
+// of its own, like every other audit entry, so it is chained under the lock.
+func (s *authService) auditLogin(ctx context.Context, action string, userID uint) error {
+	return s.userRepo.WithTransaction(ctx, func(tx repositories.UserRepository) error {
+		return auditUser(ctx, tx, action, userID, nil, nil)
+	})
+}
+
+// IssueToken signs a token for user, as returned by Login.
+func (s *authService) IssueToken(user *models.User) (string, error) {
+	token, err := utils.GenerateJWT(s.settings.JWTSecret, s.settings.TokenTTL, user.ID, user.Role)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate token: %v", err)
 	}
 
 	return token, nil
 }
+
+// ResetPassword replaces the password of the user with the given email.
+func (s *authService) ResetPassword(ctx context.Context, email, password string) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthService.ResetPassword")
+	defer tracing.End(span, &err)
+
+	if password == "" {
+		return invalidField("password", CodeRequired, nil)
+	}
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := hashPassword(ctx, password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %v", err)
+	}
+	before := *user
+	user.Password = hashedPassword
+	return s.userRepo.WithTransaction(ctx, func(tx repositories.UserRepository) error {
+		if err := tx.Update(ctx, user); err != nil {
+			return err
+		}
+		return auditUser(ctx, tx, models.AuditUserPasswordReset, user.ID, before, user)
+	})
+}
+
+// hashPassword and checkPassword run bcrypt in their own span, as it takes
+// most of the time of registering and logging in.
+func hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := tracing.Start(ctx, "bcrypt.hash")
+	defer span.End()
+	return utils.HashPassword(password)
+}
+
+func checkPassword(ctx context.Context, password, hash string) bool {
+	_, span := tracing.Start(ctx, "bcrypt.compare")
+	defer span.End()
+	return utils.CheckPasswordHash(password, hash)
+}
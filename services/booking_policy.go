@@ -0,0 +1,325 @@
+package services
+
+import (
+	"booking-api/i18n"
+	"booking-api/models"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Violation codes returned to clients. They are part of the API contract and
+// must not change once published.
+const (
+	CodeInvalidFormat       = "invalid_format"
+	CodeInvalidTimeRange    = "invalid_time_range"
+	CodeOutsideOpeningHours = "outside_opening_hours"
+	CodeDurationTooShort    = "duration_too_short"
+	CodeDurationTooLong     = "duration_too_long"
+	CodeTooFarInAdvance     = "too_far_in_advance"
+	CodeInsufficientNotice  = "insufficient_notice"
+	CodeTooManyActive       = "too_many_active_bookings"
+	CodeWeekdayNotAllowed   = "weekday_not_allowed"
+	CodeOverlap             = "overlap"
+)
+
+// Rule kinds accepted in models.BookingRule.
+const (
+	RuleOpeningHours      = "opening_hours"
+	RuleMinDuration       = "min_duration"
+	RuleMaxDuration       = "max_duration"
+	RuleAdvanceWindow     = "advance_window"
+	RuleMinNotice         = "min_notice"
+	RuleMaxActiveBookings = "max_active_bookings"
+	RuleAllowedWeekdays   = "allowed_weekdays"
+)
+
+type Violation struct {
+	Code       string      `json:"code"`
+	Field      string      `json:"field,omitempty"`
+	ResourceID uint        `json:"resource_id,omitempty"`
+	Message    string      `json:"message"`
+	Params     i18n.Params `json:"-"`
+}
+
+// PolicyViolationError carries every violation found for a booking request.
+type PolicyViolationError struct {
+	Violations []Violation
+}
+
+func (e *PolicyViolationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = v.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// BookingRequest is what a BookingPolicy evaluates. Start and End are full
+// timestamps in the server location.
+type BookingRequest struct {
+	Reservation *models.Reservation
+	Start       time.Time
+	End         time.Time
+	Now         time.Time
+
+	// ActiveBookings counts the not yet finished reservations of the user.
+	ActiveBookings func() (int64, error)
+
+	// Rescheduling is set when an existing reservation is moved, which does
+	// not add an active booking.
+	Rescheduling bool
+}
+
+// BookingPolicy checks a single rule. It returns nil when the request complies.
+type BookingPolicy interface {
+	Evaluate(req *BookingRequest) (*Violation, error)
+}
+
+type policyFactory func(value string) (BookingPolicy, error)
+
+var policyFactories = map[string]policyFactory{
+	RuleOpeningHours:      newOpeningHoursPolicy,
+	RuleMinDuration:       newMinDurationPolicy,
+	RuleMaxDuration:       newMaxDurationPolicy,
+	RuleAdvanceWindow:     newAdvanceWindowPolicy,
+	RuleMinNotice:         newMinNoticePolicy,
+	RuleMaxActiveBookings: newMaxActiveBookingsPolicy,
+	RuleAllowedWeekdays:   newAllowedWeekdaysPolicy,
+}
+
+// defaultRules apply to every resource, unless it configures a rule of the
+// same kind.
+var defaultRules = []models.BookingRule{
+	{Kind: RuleOpeningHours, Value: "09:00-18:00"},
+	{Kind: RuleMinDuration, Value: "60"},
+}
+
+// NewPolicy builds the policy configured by a rule kind and value.
+func NewPolicy(kind, value string) (BookingPolicy, error) {
+	factory, ok := policyFactories[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown rule kind %q", kind)
+	}
+	return factory(value)
+}
+
+func policiesFromRules(rules []models.BookingRule) ([]BookingPolicy, error) {
+	rules = withDefaultRules(rules)
+	policies := make([]BookingPolicy, 0, len(rules))
+	for _, rule := range rules {
+		policy, err := NewPolicy(rule.Kind, rule.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid booking rule %d: %v", rule.ID, err)
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// withDefaultRules adds, ahead of rules, the default rules of the kinds rules
+// does not configure.
+func withDefaultRules(rules []models.BookingRule) []models.BookingRule {
+	var merged []models.BookingRule
+	for _, rule := range defaultRules {
+		if !slices.ContainsFunc(rules, func(r models.BookingRule) bool { return r.Kind == rule.Kind }) {
+			merged = append(merged, rule)
+		}
+	}
+	return append(merged, rules...)
+}
+
+type timeRangePolicy struct{}
+
+func (timeRangePolicy) Evaluate(req *BookingRequest) (*Violation, error) {
+	if !req.End.After(req.Start) {
+		return violation(CodeInvalidTimeRange, "end_time", nil), nil
+	}
+	return nil, nil
+}
+
+type openingHoursPolicy struct {
+	open, close string
+}
+
+func newOpeningHoursPolicy(value string) (BookingPolicy, error) {
+	open, close, found := strings.Cut(value, "-")
+	if !found {
+		return nil, fmt.Errorf("expected HH:MM-HH:MM, got %q", value)
+	}
+	openTime, err := time.Parse("15:04", open)
+	if err != nil {
+		return nil, fmt.Errorf("invalid opening time %q", open)
+	}
+	closeTime, err := time.Parse("15:04", close)
+	if err != nil {
+		return nil, fmt.Errorf("invalid closing time %q", close)
+	}
+	if !closeTime.After(openTime) {
+		return nil, fmt.Errorf("closing time must be after opening time")
+	}
+	return &openingHoursPolicy{open: openTime.Format("15:04"), close: closeTime.Format("15:04")}, nil
+}
+
+func (p *openingHoursPolicy) Evaluate(req *BookingRequest) (*Violation, error) {
+	if req.Start.Format("15:04") < p.open || req.End.Format("15:04") > p.close {
+		return violation(CodeOutsideOpeningHours, "", i18n.Params{"open": p.open, "close": p.close}), nil
+	}
+	return nil, nil
+}
+
+type minDurationPolicy struct {
+	min time.Duration
+}
+
+func newMinDurationPolicy(value string) (BookingPolicy, error) {
+	minutes, err := positiveInt(value)
+	if err != nil {
+		return nil, err
+	}
+	return &minDurationPolicy{min: time.Duration(minutes) * time.Minute}, nil
+}
+
+func (p *minDurationPolicy) Evaluate(req *BookingRequest) (*Violation, error) {
+	if req.End.After(req.Start) && req.End.Sub(req.Start) < p.min {
+		return violation(CodeDurationTooShort, "end_time", i18n.Params{"duration": p.min}), nil
+	}
+	return nil, nil
+}
+
+type maxDurationPolicy struct {
+	max time.Duration
+}
+
+func newMaxDurationPolicy(value string) (BookingPolicy, error) {
+	minutes, err := positiveInt(value)
+	if err != nil {
+		return nil, err
+	}
+	return &maxDurationPolicy{max: time.Duration(minutes) * time.Minute}, nil
+}
+
+func (p *maxDurationPolicy) Evaluate(req *BookingRequest) (*Violation, error) {
+	if req.End.Sub(req.Start) > p.max {
+		return violation(CodeDurationTooLong, "end_time", i18n.Params{"duration": p.max}), nil
+	}
+	return nil, nil
+}
+
+type advanceWindowPolicy struct {
+	days int
+}
+
+func newAdvanceWindowPolicy(value string) (BookingPolicy, error) {
+	days, err := positiveInt(value)
+	if err != nil {
+		return nil, err
+	}
+	return &advanceWindowPolicy{days: days}, nil
+}
+
+func (p *advanceWindowPolicy) Evaluate(req *BookingRequest) (*Violation, error) {
+	if req.Start.After(req.Now.AddDate(0, 0, p.days)) {
+		return violation(CodeTooFarInAdvance, "date", i18n.Params{"days": p.days}), nil
+	}
+	return nil, nil
+}
+
+type minNoticePolicy struct {
+	notice time.Duration
+}
+
+func newMinNoticePolicy(value string) (BookingPolicy, error) {
+	minutes, err := positiveInt(value)
+	if err != nil {
+		return nil, err
+	}
+	return &minNoticePolicy{notice: time.Duration(minutes) * time.Minute}, nil
+}
+
+func (p *minNoticePolicy) Evaluate(req *BookingRequest) (*Violation, error) {
+	if req.Start.Before(req.Now.Add(p.notice)) {
+		return violation(CodeInsufficientNotice, "start_time", i18n.Params{"duration": p.notice}), nil
+	}
+	return nil, nil
+}
+
+type maxActiveBookingsPolicy struct {
+	max int64
+}
+
+func newMaxActiveBookingsPolicy(value string) (BookingPolicy, error) {
+	max, err := positiveInt(value)
+	if err != nil {
+		return nil, err
+	}
+	return &maxActiveBookingsPolicy{max: int64(max)}, nil
+}
+
+func (p *maxActiveBookingsPolicy) Evaluate(req *BookingRequest) (*Violation, error) {
+	if req.Rescheduling {
+		return nil, nil
+	}
+	active, err := req.ActiveBookings()
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active bookings: %v", err)
+	}
+	if active >= p.max {
+		return violation(CodeTooManyActive, "", i18n.Params{"max": p.max}), nil
+	}
+	return nil, nil
+}
+
+type allowedWeekdaysPolicy struct {
+	allowed map[time.Weekday]bool
+	days    []time.Weekday
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// newAllowedWeekdaysPolicy expects a comma separated list such as "mon,tue,wed".
+func newAllowedWeekdaysPolicy(value string) (BookingPolicy, error) {
+	allowed := map[time.Weekday]bool{}
+	var days []time.Weekday
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		day, ok := weekdayNames[name]
+		if !ok {
+			return nil, fmt.Errorf("invalid weekday %q", name)
+		}
+		allowed[day] = true
+		days = append(days, day)
+	}
+	return &allowedWeekdaysPolicy{allowed: allowed, days: days}, nil
+}
+
+func (p *allowedWeekdaysPolicy) Evaluate(req *BookingRequest) (*Violation, error) {
+	if !p.allowed[req.Start.Weekday()] {
+		return violation(CodeWeekdayNotAllowed, "date", i18n.Params{"weekdays": p.days}), nil
+	}
+	return nil, nil
+}
+
+func positiveInt(value string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("expected a positive integer, got %q", value)
+	}
+	return n, nil
+}
+
+// violation is the result of a failed Evaluate.
+func violation(code, field string, params i18n.Params) *Violation {
+	v := newViolation(code, field, params)
+	return &v
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"booking-api/i18n"
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/utils"
+	"context"
+	"fmt"
+	"time"
+)
+
+// feedHistoryDays is how far back calendar feeds go, so cancellations of
+// recent bookings still reach subscribed calendars.
+const feedHistoryDays = 30
+
+type CalendarService interface {
+	ReservationCalendar(ctx context.Context, reservationID, userID uint, locale string) (string, error)
+	FeedCalendar(ctx context.Context, token, locale string) (string, error)
+	UserFeedToken(ctx context.Context, userID uint, rotate bool) (string, error)
+	ResourceFeedToken(ctx context.Context, resourceID uint, rotate bool) (string, error)
+}
+
+type calendarService struct {
+	reservationRepo repositories.ReservationRepository
+	resourceRepo    repositories.ResourceRepository
+	userRepo        repositories.UserRepository
+	now             func() time.Time
+}
+
+func NewCalendarService(reservationRepo repositories.ReservationRepository, resourceRepo repositories.ResourceRepository, userRepo repositories.UserRepository) CalendarService {
+	return &calendarService{reservationRepo: reservationRepo, resourceRepo: resourceRepo, userRepo: userRepo, now: time.Now}
+}
+
+// ReservationCalendar renders a reservation of the user, its texts in locale.
+func (s *calendarService) ReservationCalendar(ctx context.Context, reservationID, userID uint, locale string) (string, error) {
+	res, err := s.reservationRepo.FindByID(ctx, reservationID)
+	if err != nil {
+		return "", err
+	}
+	if res.UserID != userID {
+		return "", newError(ErrForbidden, CodeNotOwner, nil)
+	}
+
+	events, err := s.events(ctx, []models.Reservation{*res}, locale)
+	if err != nil {
+		return "", err
+	}
+	return utils.BuildICalendar("", events), nil
+}
+
+// FeedCalendar renders the feed identified by a secret token, which belongs
+// either to a user (their bookings) or to a resource (for room displays). Its
+// texts are in locale.
+func (s *calendarService) FeedCalendar(ctx context.Context, token, locale string) (string, error) {
+	if token == "" {
+		return "", newError(ErrNotFound, CodeFeedNotFound, nil)
+	}
+	from := s.now().AddDate(0, 0, -feedHistoryDays).Format("2006-01-02")
+
+	if user, err := s.userRepo.FindByFeedToken(ctx, token); err == nil {
+		reservations, err := s.reservationRepo.FindByUserFrom(ctx, user.ID, from)
+		if err != nil {
+			return "", fmt.Errorf("failed to load reservations: %v", err)
+		}
+		events, err := s.events(ctx, reservations, locale)
+		if err != nil {
+			return "", err
+		}
+		name, _ := i18n.Message(locale, i18n.Params{"name": user.Name}, "calendar.user_feed")
+		return utils.BuildICalendar(name, events), nil
+	}
+
+	resource, err := s.resourceRepo.FindByFeedToken(ctx, token)
+	if err != nil {
+		return "", newError(ErrNotFound, CodeFeedNotFound, nil)
+	}
+	reservations, err := s.reservationRepo.FindByResourceFrom(ctx, resource.ID, from)
+	if err != nil {
+		return "", fmt.Errorf("failed to load reservations: %v", err)
+	}
+	events, err := s.events(ctx, reservations, locale)
+	if err != nil {
+		return "", err
+	}
+	return utils.BuildICalendar(resource.Name, events), nil
+}
+
+// feedToken is what the audit log records of a feed token change, as the
+// token is left out of the JSON of a user.
+type feedToken struct {
+	FeedToken *string `json:"feed_token"`
+}
+
+// UserFeedToken returns the feed token of a user, creating it on first use.
+// Rotating it invalidates previously shared feed URLs.
+func (s *calendarService) UserFeedToken(ctx context.Context, userID uint, rotate bool) (string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if user.FeedToken != nil && !rotate {
+		return *user.FeedToken, nil
+	}
+
+	token, err := utils.RandomToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate feed token: %v", err)
+	}
+	before := feedToken{user.FeedToken}
+	user.FeedToken = &token
+	err = s.userRepo.WithTransaction(ctx, func(tx repositories.UserRepository) error {
+		if err := tx.Update(ctx, user); err != nil {
+			return fmt.Errorf("failed to save feed token: %v", err)
+		}
+		return auditUser(ctx, tx, models.AuditUserFeedTokenRotated, user.ID, before, feedToken{user.FeedToken})
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ResourceFeedToken returns the feed token of a resource, creating it on first
+// use.
+func (s *calendarService) ResourceFeedToken(ctx context.Context, resourceID uint, rotate bool) (string, error) {
+	resource, err := s.resourceRepo.FindByID(ctx, resourceID)
+	if err != nil {
+		return "", err
+	}
+	if resource.FeedToken != nil && !rotate {
+		return *resource.FeedToken, nil
+	}
+
+	token, err := utils.RandomToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate feed token: %v", err)
+	}
+	resource.FeedToken = &token
+	if err := s.resourceRepo.Update(ctx, resource); err != nil {
+		return "", fmt.Errorf("failed to save feed token: %v", err)
+	}
+	return token, nil
+}
+
+func (s *calendarService) events(ctx context.Context, reservations []models.Reservation, locale string) ([]utils.ICalEvent, error) {
+	names := map[uint]string{}
+	events := make([]utils.ICalEvent, 0, len(reservations))
+	for _, res := range reservations {
+		name, ok := names[res.ResourceID]
+		if !ok && res.ResourceID != 0 {
+			if resource, err := s.resourceRepo.FindByID(ctx, res.ResourceID); err == nil {
+				name = resource.Name
+			}
+			names[res.ResourceID] = name
+		}
+
+		event, err := reservationEvent(res, name, locale)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// reservationEvent maps a reservation on the named resource to a VEVENT with
+// its summary in locale. The UID never changes, so calendar apps update the
+// same event when SEQUENCE grows.
+func reservationEvent(res models.Reservation, resourceName, locale string) (utils.ICalEvent, error) {
+	start, err := time.ParseInLocation("2006-01-02 15:04", res.Date+" "+res.StartTime, time.Local)
+	if err != nil {
+		return utils.ICalEvent{}, fmt.Errorf("reservation %d has an invalid start: %v", res.ID, err)
+	}
+	end, err := time.ParseInLocation("2006-01-02 15:04", res.Date+" "+res.EndTime, time.Local)
+	if err != nil {
+		return utils.ICalEvent{}, fmt.Errorf("reservation %d has an invalid end: %v", res.ID, err)
+	}
+
+	status := "CONFIRMED"
+	if res.Status == models.ReservationCancelled || res.Status == models.ReservationNoShow {
+		status = "CANCELLED"
+	}
+
+	summary, _ := i18n.Message(locale, nil, "calendar.summary")
+	if resourceName != "" {
+		summary, _ = i18n.Message(locale, i18n.Params{"resource": resourceName}, "calendar.summary_resource")
+	}
+
+	return utils.ICalEvent{
+		UID:      fmt.Sprintf("reservation-%d@booking-api", res.ID),
+		Summary:  summary,
+		Location: resourceName,
+		Start:    start,
+		End:      end,
+		Stamp:    res.UpdatedAt,
+		Sequence: res.Sequence,
+		Status:   status,
+	}, nil
+}
@@ -0,0 +1,155 @@
+package services
+
+import (
+	"booking-api/i18n"
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+	"fmt"
+	"time"
+)
+
+type CheckInService interface {
+	CheckIn(ctx context.Context, reservationID, userID uint, code string) (*models.Reservation, error)
+	ReleaseNoShows(ctx context.Context) (int, error)
+}
+
+// CheckInSettings bounds the check-in window: it opens Before the start of a
+// reservation and closes Grace after it, when the reservation becomes a no-show.
+type CheckInSettings struct {
+	Before time.Duration
+	Grace  time.Duration
+}
+
+type checkInService struct {
+	repo         repositories.ReservationRepository
+	resourceRepo repositories.ResourceRepository
+	uow          repositories.UnitOfWork
+	settings     CheckInSettings
+	now          func() time.Time
+}
+
+func NewCheckInService(repo repositories.ReservationRepository, resourceRepo repositories.ResourceRepository, uow repositories.UnitOfWork, settings CheckInSettings) CheckInService {
+	return &checkInService{repo: repo, resourceRepo: resourceRepo, uow: uow, settings: settings, now: time.Now}
+}
+
+// CheckIn marks the reservation as used. When the resource has a check-in
+// code (printed as a QR code in the room or shown on a kiosk), the same code
+// must be provided.
+func (s *checkInService) CheckIn(ctx context.Context, reservationID, userID uint, code string) (*models.Reservation, error) {
+	res, err := s.repo.FindByID(ctx, reservationID)
+	if err != nil {
+		return nil, err
+	}
+	if res.UserID != userID {
+		return nil, newError(ErrForbidden, CodeNotOwner, nil)
+	}
+
+	if res.Status != models.ReservationConfirmed {
+		return nil, checkInConflict(res.Status)
+	}
+
+	if res.ResourceID != 0 {
+		resource, err := s.resourceRepo.FindByID(ctx, res.ResourceID)
+		if err != nil {
+			return nil, err
+		}
+		if resource.CheckInCode != "" && resource.CheckInCode != code {
+			return nil, invalidField("code", CodeInvalidCheckInCode, nil)
+		}
+	}
+
+	start, err := reservationStart(res)
+	if err != nil {
+		return nil, err
+	}
+	now := s.now()
+	if now.Before(start.Add(-s.settings.Before)) {
+		return nil, newError(ErrConflict, CodeCheckInNotOpen, i18n.Params{"time": start.Add(-s.settings.Before).Format("15:04")})
+	}
+	if now.After(start.Add(s.settings.Grace)) {
+		return nil, newError(ErrConflict, CodeCheckInClosed, nil)
+	}
+
+	before := *res
+	err = s.repo.WithTransaction(ctx, func(tx repositories.ReservationRepository) error {
+		marked, err := tx.MarkCheckedIn(ctx, res, now)
+		if err != nil {
+			return fmt.Errorf("failed to check in: %v", err)
+		}
+		if !marked {
+			// Cancelled or released since it was read.
+			current, err := tx.FindByID(ctx, res.ID)
+			if err != nil {
+				return err
+			}
+			return checkInConflict(current.Status)
+		}
+		if err := auditReservation(ctx, tx, models.EventReservationCheckedIn, &before, res); err != nil {
+			return err
+		}
+		return publish(ctx, tx, models.EventReservationCheckedIn, res)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// checkInConflict explains why a reservation with the given status, other
+// than confirmed, cannot be checked in.
+func checkInConflict(status string) error {
+	switch status {
+	case models.ReservationCheckedIn:
+		return newError(ErrConflict, CodeAlreadyCheckedIn, nil)
+	case models.ReservationNoShow:
+		return newError(ErrConflict, CodeReservationNoShow, nil)
+	}
+	return newError(ErrConflict, CodeReservationNotConfirmed, i18n.Params{"status": status})
+}
+
+// ReleaseNoShows marks every reservation whose grace period has passed without
+// a check-in as no-show, which frees the rest of its time slot, and counts it
+// against its user in the same transaction.
+func (s *checkInService) ReleaseNoShows(ctx context.Context) (int, error) {
+	cutoff := s.now().Add(-s.settings.Grace)
+	reservations, err := s.repo.FindUncheckedStartedBefore(ctx, cutoff.Format("2006-01-02"), cutoff.Format("15:04"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load reservations: %v", err)
+	}
+
+	released := 0
+	for i := range reservations {
+		marked := false
+		err := s.uow.Do(ctx, func(tx repositories.Tx) error {
+			before := reservations[i]
+			var err error
+			marked, err = tx.Reservations.MarkNoShow(ctx, &reservations[i])
+			if err != nil || !marked {
+				return err
+			}
+			if err := tx.Users.IncrementNoShows(ctx, reservations[i].UserID); err != nil {
+				return err
+			}
+			if err := auditReservation(ctx, tx.Reservations, models.EventReservationNoShow, &before, &reservations[i]); err != nil {
+				return err
+			}
+			return publish(ctx, tx.Reservations, models.EventReservationNoShow, &reservations[i])
+		})
+		if err != nil {
+			return released, fmt.Errorf("failed to release reservation %d: %v", reservations[i].ID, err)
+		}
+		if marked {
+			released++
+		}
+	}
+	return released, nil
+}
+
+func reservationStart(res *models.Reservation) (time.Time, error) {
+	start, err := time.ParseInLocation("2006-01-02 15:04", res.Date+" "+res.StartTime, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("reservation %d has an invalid start: %v", res.ID, err)
+	}
+	return start, nil
+}
@@ -0,0 +1,124 @@
+package services
+
+import (
+	"booking-api/i18n"
+	"booking-api/repositories"
+	"errors"
+)
+
+// Sentinels classifying domain errors. Match them with errors.Is; the HTTP
+// layer maps each one to a status.
+var (
+	// ErrNotFound is returned when a referenced entity does not exist.
+	ErrNotFound = repositories.ErrNotFound
+	// ErrInvalid is returned for input that is well formed but not valid.
+	ErrInvalid = errors.New("invalid input")
+	// ErrConflict is returned when the request clashes with the current
+	// state, such as changing a cancelled reservation.
+	ErrConflict = errors.New("conflict")
+	// ErrForbidden is returned when the user may not act on the entity.
+	ErrForbidden = errors.New("forbidden")
+	// ErrUnauthorized is returned when the credentials are wrong.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrUnavailable is returned when an external dependency fails.
+	ErrUnavailable = errors.New("unavailable")
+
+	// ErrOverlap matches booking violations with CodeOverlap.
+	ErrOverlap = errors.New("overlaps another reservation")
+	// ErrOutsideHours matches booking violations with CodeOutsideOpeningHours.
+	ErrOutsideHours = errors.New("outside opening hours")
+)
+
+// Error codes of the errors below. Like the violation codes they are part of
+// the API contract.
+const (
+	CodeEmailInUse              = "email_in_use"
+	CodeInvalidCredentials      = "invalid_credentials"
+	CodeNotOwner                = "not_owner"
+	CodeReservationNoShow       = "reservation_no_show"
+	CodeAlreadyCheckedIn        = "already_checked_in"
+	CodeCheckInNotOpen          = "check_in_not_open"
+	CodeCheckInClosed           = "check_in_closed"
+	CodeInvalidCheckInCode      = "invalid_check_in_code"
+	CodeRequired                = "required"
+	CodeInvalidValue            = "invalid_value"
+	CodeInvalidRule             = "invalid_rule"
+	CodeInvalidCalendar         = "invalid_calendar"
+	CodeCalendarTooLarge        = "calendar_too_large"
+	CodeCalendarNotSyncable     = "calendar_not_syncable"
+	CodeCalendarUnavailable     = "calendar_unavailable"
+	CodeCalendarFilesDisabled   = "calendar_files_disabled"
+	CodeInvalidCSV              = "invalid_csv"
+	CodeCSVEmpty                = "csv_empty"
+	CodeCSVMissingColumn        = "csv_missing_column"
+	CodeCSVTooManyRows          = "csv_too_many_rows"
+	CodeFeedNotFound            = "feed_not_found"
+	CodeDuplicateResource       = "duplicate_resource"
+	CodeUnsupportedLocale       = "unsupported_locale"
+	CodeUnknownEvent            = "unknown_event"
+	CodeInvalidURL              = "invalid_url"
+	CodeReservationStarted      = "reservation_started"
+	CodeReservationNotConfirmed = "reservation_not_confirmed"
+)
+
+// Error is a domain error with a stable code. Err is one of the sentinels
+// above and Field names the offending input field, if any. Message is the
+// English text; Params let the HTTP layer render it in other locales.
+type Error struct {
+	Err     error
+	Code    string
+	Field   string
+	Message string
+	Params  i18n.Params
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func newError(kind error, code string, params i18n.Params) *Error {
+	return &Error{Err: kind, Code: code, Message: describe(code, "", params), Params: params}
+}
+
+// invalidField reports an invalid value of an input field.
+func invalidField(field, code string, params i18n.Params) *Error {
+	return &Error{Err: ErrInvalid, Code: code, Field: field, Message: describe(code, field, params), Params: params}
+}
+
+func newViolation(code, field string, params i18n.Params) Violation {
+	return Violation{Code: code, Field: field, Message: describe(code, field, params), Params: params}
+}
+
+// describe renders the English message of a code from the catalog.
+func describe(code, field string, params i18n.Params) string {
+	if message, ok := i18n.Describe(i18n.Fallback, code, field, params); ok {
+		return message
+	}
+	return code
+}
+
+func (e *PolicyViolationError) Is(target error) bool {
+	switch target {
+	case ErrOverlap:
+		return e.has(CodeOverlap)
+	case ErrOutsideHours:
+		return e.has(CodeOutsideOpeningHours)
+	}
+	return false
+}
+
+func (e *PolicyViolationError) has(code string) bool {
+	for _, v := range e.Violations {
+		if v.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// NotFoundError reports a missing entity; it matches ErrNotFound.
+type NotFoundError = repositories.NotFoundError
@@ -0,0 +1,381 @@
+package services
+
+import (
+	"booking-api/i18n"
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/utils"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// externalHorizonDays is how far ahead imported events are expanded.
+const externalHorizonDays = 180
+
+// maxCalendarSize bounds downloaded and uploaded calendars.
+const maxCalendarSize = 10 << 20
+
+var errCalendarTooLarge = newError(ErrInvalid, CodeCalendarTooLarge, nil)
+
+// errPrivateHost refuses calendar and webhook URLs reaching inside the
+// network.
+var errPrivateHost = errors.New("private and loopback addresses are not allowed")
+
+type ExternalCalendarService interface {
+	AddCalendar(ctx context.Context, calendar *models.ExternalCalendar) error
+	UploadCalendar(ctx context.Context, resourceID uint, name, data string) (*models.ExternalCalendar, error)
+	GetCalendars(ctx context.Context, resourceID uint) ([]models.ExternalCalendar, error)
+	SyncCalendar(ctx context.Context, id uint) (*models.ExternalCalendar, error)
+	SyncAll(ctx context.Context) error
+	DeleteCalendar(ctx context.Context, id uint) error
+}
+
+// ExternalCalendarSettings limit what the server reads on behalf of an
+// administrator. File paths are relative to FileDir, and file sources are
+// refused while it is empty. URLs may only reach public addresses unless
+// AllowPrivateHosts is set.
+type ExternalCalendarSettings struct {
+	FileDir           string
+	AllowPrivateHosts bool
+}
+
+type externalCalendarService struct {
+	repo         repositories.ExternalCalendarRepository
+	resourceRepo repositories.ResourceRepository
+	settings     ExternalCalendarSettings
+	client       *http.Client
+	now          func() time.Time
+}
+
+func NewExternalCalendarService(repo repositories.ExternalCalendarRepository, resourceRepo repositories.ResourceRepository, settings ExternalCalendarSettings) ExternalCalendarService {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !settings.AllowPrivateHosts {
+		dialer.Control = publicOnly
+	}
+	return &externalCalendarService{
+		repo:         repo,
+		resourceRepo: resourceRepo,
+		settings:     settings,
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			// No proxy: the addresses checked must be the ones connected to.
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+		},
+		now: time.Now,
+	}
+}
+
+// AddCalendar registers a source read from a URL or a file path and syncs it
+// right away. The source is kept even if the first sync fails, with the error
+// recorded, so it can be fixed on the remote side.
+func (s *externalCalendarService) AddCalendar(ctx context.Context, calendar *models.ExternalCalendar) error {
+	if (calendar.URL == "") == (calendar.FilePath == "") {
+		return invalidField("url", CodeRequired, nil)
+	}
+	if err := s.checkSource(calendar); err != nil {
+		return err
+	}
+	if _, err := s.resourceRepo.FindByID(ctx, calendar.ResourceID); err != nil {
+		return err
+	}
+	if err := s.repo.Create(ctx, calendar); err != nil {
+		return fmt.Errorf("failed to save external calendar: %v", err)
+	}
+
+	data, err := s.fetch(ctx, calendar)
+	if err != nil {
+		return s.recordFailure(ctx, calendar, err)
+	}
+	return s.importData(ctx, calendar, data)
+}
+
+// UploadCalendar imports a one-off calendar file.
+func (s *externalCalendarService) UploadCalendar(ctx context.Context, resourceID uint, name, data string) (*models.ExternalCalendar, error) {
+	if len(data) > maxCalendarSize {
+		return nil, errCalendarTooLarge
+	}
+	if _, err := s.resourceRepo.FindByID(ctx, resourceID); err != nil {
+		return nil, err
+	}
+
+	calendar := &models.ExternalCalendar{ResourceID: resourceID, Name: name}
+	blocks, skipped, err := s.parse(calendar, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(ctx, calendar); err != nil {
+		return nil, fmt.Errorf("failed to save external calendar: %v", err)
+	}
+	return calendar, s.store(ctx, calendar, blocks, skipped)
+}
+
+func (s *externalCalendarService) GetCalendars(ctx context.Context, resourceID uint) ([]models.ExternalCalendar, error) {
+	if _, err := s.resourceRepo.FindByID(ctx, resourceID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindByResource(ctx, resourceID)
+}
+
+func (s *externalCalendarService) SyncCalendar(ctx context.Context, id uint) (*models.ExternalCalendar, error) {
+	calendar, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if calendar.URL == "" && calendar.FilePath == "" {
+		return nil, newError(ErrConflict, CodeCalendarNotSyncable, nil)
+	}
+
+	data, err := s.fetch(ctx, calendar)
+	if err != nil {
+		return calendar, s.recordFailure(ctx, calendar, err)
+	}
+	return calendar, s.importData(ctx, calendar, data)
+}
+
+// SyncAll re-syncs every URL or file source. A failing source does not stop
+// the others; the returned error reports how many failed.
+func (s *externalCalendarService) SyncAll(ctx context.Context) error {
+	calendars, err := s.repo.FindSyncable(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load external calendars: %v", err)
+	}
+
+	failed := 0
+	for i := range calendars {
+		data, err := s.fetch(ctx, &calendars[i])
+		if err == nil {
+			err = s.importData(ctx, &calendars[i], data)
+		} else {
+			err = s.recordFailure(ctx, &calendars[i], err)
+		}
+		if err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d external calendars failed to sync", failed, len(calendars))
+	}
+	return nil
+}
+
+func (s *externalCalendarService) DeleteCalendar(ctx context.Context, id uint) error {
+	calendar, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.Delete(ctx, calendar)
+}
+
+// checkSource refuses URLs other than http and https, and file paths outside
+// the calendar directory.
+func (s *externalCalendarService) checkSource(calendar *models.ExternalCalendar) error {
+	if calendar.FilePath != "" {
+		if s.settings.FileDir == "" {
+			return invalidField("file_path", CodeCalendarFilesDisabled, nil)
+		}
+		if !filepath.IsLocal(calendar.FilePath) {
+			return invalidField("file_path", CodeInvalidValue, nil)
+		}
+		return nil
+	}
+	target, err := url.Parse(calendar.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return invalidField("url", CodeInvalidURL, nil)
+	}
+	return nil
+}
+
+func (s *externalCalendarService) fetch(ctx context.Context, calendar *models.ExternalCalendar) (string, error) {
+	if calendar.FilePath != "" {
+		return s.readFile(calendar.FilePath)
+	}
+	if err := s.checkSource(calendar); err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, calendar.URL, nil)
+	if err != nil {
+		return "", invalidField("url", CodeInvalidURL, nil)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", unavailable("%v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", unavailable("status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCalendarSize+1))
+	if err != nil {
+		return "", unavailable("%v", err)
+	}
+	if len(data) > maxCalendarSize {
+		return "", errCalendarTooLarge
+	}
+	return string(data), nil
+}
+
+// readFile reads a calendar from the calendar directory. The directory is
+// opened as a root, so neither ".." nor symlinks lead out of it.
+func (s *externalCalendarService) readFile(path string) (string, error) {
+	if s.settings.FileDir == "" {
+		return "", newError(ErrInvalid, CodeCalendarFilesDisabled, nil)
+	}
+	root, err := os.OpenRoot(s.settings.FileDir)
+	if err != nil {
+		return "", unavailable("calendar directory unavailable")
+	}
+	defer root.Close()
+	file, err := root.Open(path)
+	if err != nil {
+		return "", unavailable("cannot read %s", path)
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxCalendarSize+1))
+	if err != nil {
+		return "", unavailable("cannot read %s", path)
+	}
+	if len(data) > maxCalendarSize {
+		return "", errCalendarTooLarge
+	}
+	return string(data), nil
+}
+
+func (s *externalCalendarService) importData(ctx context.Context, calendar *models.ExternalCalendar, data string) error {
+	blocks, skipped, err := s.parse(calendar, data)
+	if err != nil {
+		return s.recordFailure(ctx, calendar, err)
+	}
+	return s.store(ctx, calendar, blocks, skipped)
+}
+
+// parse expands the calendar into per-day blocks from yesterday up to the
+// horizon.
+func (s *externalCalendarService) parse(calendar *models.ExternalCalendar, data string) ([]models.ExternalBlock, []error, error) {
+	now := s.now()
+	from := time.Date(now.Year(), now.Month(), now.Day()-1, 0, 0, 0, 0, time.Local)
+	to := from.AddDate(0, 0, externalHorizonDays+1)
+
+	occurrences, skipped, err := utils.ParseICalBusyTimes(data, from, to, time.Local)
+	if err != nil {
+		return nil, nil, newError(ErrInvalid, CodeInvalidCalendar, i18n.Params{"reason": err.Error()})
+	}
+
+	var blocks []models.ExternalBlock
+	for _, occurrence := range occurrences {
+		blocks = append(blocks, splitByDay(calendar, occurrence)...)
+	}
+	return blocks, skipped, nil
+}
+
+// store saves the blocks of a successful sync. The events that could not be
+// read are left in LastError, while the rest of the calendar still blocks.
+func (s *externalCalendarService) store(ctx context.Context, calendar *models.ExternalCalendar, blocks []models.ExternalBlock, skipped []error) error {
+	for i := range blocks {
+		blocks[i].CalendarID = calendar.ID
+	}
+
+	now := s.now()
+	calendar.LastSyncedAt = &now
+	calendar.LastError = ""
+	if len(skipped) > 0 {
+		reasons := make([]string, len(skipped))
+		for i, err := range skipped {
+			reasons[i] = err.Error()
+		}
+		calendar.LastError = "events skipped: " + strings.Join(reasons, "; ")
+	}
+	calendar.BlockCount = len(blocks)
+	if err := s.repo.ReplaceBlocks(ctx, calendar, blocks); err != nil {
+		return fmt.Errorf("failed to store external blocks: %v", err)
+	}
+	return nil
+}
+
+// recordFailure keeps the previous blocks, which are better than nothing, and
+// saves the error on the source.
+func (s *externalCalendarService) recordFailure(ctx context.Context, calendar *models.ExternalCalendar, cause error) error {
+	calendar.LastError = cause.Error()
+	if err := s.repo.Update(ctx, calendar); err != nil {
+		return fmt.Errorf("%v (and failed to record it: %v)", cause, err)
+	}
+	return cause
+}
+
+// splitByDay turns an occurrence into one block per local day it touches.
+// Blocks reaching midnight end at 23:59, the last minute of the day model.
+func splitByDay(calendar *models.ExternalCalendar, occurrence utils.ICalOccurrence) []models.ExternalBlock {
+	start := occurrence.Start.In(time.Local)
+	end := occurrence.End.In(time.Local)
+
+	var blocks []models.ExternalBlock
+	for day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.Local); day.Before(end); day = day.AddDate(0, 0, 1) {
+		next := day.AddDate(0, 0, 1)
+
+		from := day
+		if start.After(from) {
+			from = start
+		}
+		to := next
+		if end.Before(to) {
+			to = end
+		}
+		if !to.After(from) {
+			continue
+		}
+
+		endTime := to.Format("15:04")
+		if !to.Before(next) {
+			endTime = "23:59"
+		}
+		blocks = append(blocks, models.ExternalBlock{
+			CalendarID: calendar.ID,
+			ResourceID: calendar.ResourceID,
+			Date:       day.Format("2006-01-02"),
+			StartTime:  from.Format("15:04"),
+			EndTime:    endTime,
+			UID:        occurrence.UID,
+			Summary:    occurrence.Summary,
+		})
+	}
+	return blocks
+}
+
+// publicOnly is a net.Dialer Control refusing addresses outside the public
+// internet. It runs on the resolved address of every connection, redirects
+// included, so a public name pointing inside the network is refused too.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	ip = ip.Unmap()
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() || sharedAddressSpace.Contains(ip) {
+		return errPrivateHost
+	}
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, private in practice.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// unavailable reports a calendar source that could not be read.
+func unavailable(format string, args ...interface{}) error {
+	return newError(ErrUnavailable, CodeCalendarUnavailable, i18n.Params{"reason": fmt.Sprintf(format, args...)})
+}
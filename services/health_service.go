@@ -0,0 +1,66 @@
+package services
+
+import (
+	"log/slog"
+	"sync/atomic"
+)
+
+// Health statuses.
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+	HealthFailing     = "failing"
+	HealthDraining    = "draining"
+)
+
+// HealthCheck is a dependency the API needs to serve requests. Check returns
+// nil while it works.
+type HealthCheck struct {
+	Name  string
+	Check func() error
+}
+
+type HealthReport struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+type HealthService interface {
+	Readiness() (*HealthReport, bool)
+	Drain()
+}
+
+type healthService struct {
+	checks   []HealthCheck
+	draining atomic.Bool
+}
+
+func NewHealthService(checks ...HealthCheck) HealthService {
+	return &healthService{checks: checks}
+}
+
+// Readiness runs every check and reports whether the instance can take
+// traffic. Failures are logged rather than returned, as the probe is public.
+func (s *healthService) Readiness() (*HealthReport, bool) {
+	if s.draining.Load() {
+		return &HealthReport{Status: HealthDraining}, false
+	}
+
+	report := &HealthReport{Status: HealthOK, Checks: map[string]string{}}
+	for _, check := range s.checks {
+		if err := check.Check(); err != nil {
+			slog.Warn("comprobación de salud fallida", "check", check.Name, "error", err)
+			report.Checks[check.Name] = HealthFailing
+			report.Status = HealthUnavailable
+			continue
+		}
+		report.Checks[check.Name] = HealthOK
+	}
+	return report, report.Status == HealthOK
+}
+
+// Drain makes the instance unready for good, so the load balancer stops
+// sending it requests while it shuts down.
+func (s *healthService) Drain() {
+	s.draining.Store(true)
+}
@@ -0,0 +1,268 @@
+package services
+
+import (
+	"booking-api/i18n"
+	"booking-api/models"
+	"booking-api/notifications"
+	"booking-api/repositories"
+	"booking-api/utils"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// maxReminderMinutes bounds how long before a reservation a reminder can be
+// sent.
+const maxReminderMinutes = 24 * 60
+
+// notificationKinds maps outbox events to the email they trigger.
+var notificationKinds = map[string]string{
+	models.EventReservationCreated:     notifications.KindConfirmation,
+	models.EventReservationRescheduled: notifications.KindChange,
+	models.EventReservationCancelled:   notifications.KindCancellation,
+}
+
+type NotificationService interface {
+	GetPreferences(ctx context.Context, userID uint) (*models.NotificationPreferences, error)
+	UpdatePreferences(ctx context.Context, userID uint, preferences models.NotificationPreferences) (*models.NotificationPreferences, error)
+	ProcessEvents(ctx context.Context) (int, error)
+	SendReminders(ctx context.Context) (int, error)
+}
+
+// NotificationSettings are the defaults for users without preferences.
+type NotificationSettings struct {
+	ReminderMinutes int
+	Locale          string
+}
+
+type notificationService struct {
+	repo         repositories.NotificationRepository
+	userRepo     repositories.UserRepository
+	resourceRepo repositories.ResourceRepository
+	notifier     notifications.Notifier
+	settings     NotificationSettings
+	now          func() time.Time
+}
+
+func NewNotificationService(repo repositories.NotificationRepository, userRepo repositories.UserRepository, resourceRepo repositories.ResourceRepository, notifier notifications.Notifier, settings NotificationSettings) NotificationService {
+	return &notificationService{
+		repo:         repo,
+		userRepo:     userRepo,
+		resourceRepo: resourceRepo,
+		notifier:     notifier,
+		settings:     settings,
+		now:          time.Now,
+	}
+}
+
+// GetPreferences returns the preferences of the user, or the defaults when
+// they were never changed.
+func (s *notificationService) GetPreferences(ctx context.Context, userID uint) (*models.NotificationPreferences, error) {
+	preferences, err := s.repo.FindPreferences(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification preferences: %v", err)
+	}
+	if preferences == nil {
+		preferences = &models.NotificationPreferences{
+			UserID:          userID,
+			Confirmation:    true,
+			Change:          true,
+			Cancellation:    true,
+			Reminder:        s.settings.ReminderMinutes > 0,
+			ReminderMinutes: s.settings.ReminderMinutes,
+			Locale:          s.settings.Locale,
+		}
+	}
+	return preferences, nil
+}
+
+func (s *notificationService) UpdatePreferences(ctx context.Context, userID uint, input models.NotificationPreferences) (*models.NotificationPreferences, error) {
+	if input.ReminderMinutes < 0 || input.ReminderMinutes > maxReminderMinutes {
+		return nil, invalidField("reminder_minutes", CodeInvalidValue, i18n.Params{"max": maxReminderMinutes})
+	}
+	if input.Locale == "" {
+		input.Locale = s.settings.Locale
+	}
+	if !supportedLocale(input.Locale) {
+		return nil, invalidField("locale", CodeUnsupportedLocale, i18n.Params{"locale": input.Locale})
+	}
+
+	preferences, err := s.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	preferences.Confirmation = input.Confirmation
+	preferences.Change = input.Change
+	preferences.Cancellation = input.Cancellation
+	preferences.Reminder = input.Reminder
+	preferences.ReminderMinutes = input.ReminderMinutes
+	preferences.Locale = input.Locale
+
+	if err := s.repo.SavePreferences(ctx, preferences); err != nil {
+		return nil, fmt.Errorf("failed to save notification preferences: %v", err)
+	}
+	return preferences, nil
+}
+
+// ProcessEvents emails the users affected by the pending outbox events. An
+// event whose email cannot be sent stays pending and stops the run, so it is
+// retried in order on the next one.
+func (s *notificationService) ProcessEvents(ctx context.Context) (int, error) {
+	events, err := s.repo.FindUnnotifiedEvents(ctx, webhookBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load outbox events: %v", err)
+	}
+
+	sent := 0
+	for i := range events {
+		kind, ok := notificationKinds[events[i].Type]
+		if ok {
+			var res models.Reservation
+			if err := json.Unmarshal([]byte(events[i].Payload), &res); err != nil {
+				return sent, fmt.Errorf("failed to decode event %d: %v", events[i].ID, err)
+			}
+			delivered, err := s.notify(ctx, kind, &res, 0)
+			if err != nil {
+				return sent, err
+			}
+			if delivered {
+				sent++
+			}
+		}
+		if err := s.repo.MarkEventNotified(ctx, &events[i]); err != nil {
+			return sent, fmt.Errorf("failed to mark event %d: %v", events[i].ID, err)
+		}
+	}
+	return sent, nil
+}
+
+// SendReminders emails the users whose reservations start within their
+// reminder time. Every reservation is reminded at most once.
+func (s *notificationService) SendReminders(ctx context.Context) (int, error) {
+	now := s.now()
+	reservations, err := s.repo.FindReminderCandidates(ctx, now.Format("2006-01-02"), now.AddDate(0, 0, 1).Format("2006-01-02"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to load reservations: %v", err)
+	}
+
+	preferences := map[uint]*models.NotificationPreferences{}
+	sent := 0
+	for i := range reservations {
+		res := &reservations[i]
+		prefs, ok := preferences[res.UserID]
+		if !ok {
+			prefs, err = s.GetPreferences(ctx, res.UserID)
+			if err != nil {
+				return sent, err
+			}
+			preferences[res.UserID] = prefs
+		}
+		if !prefs.Reminder || prefs.ReminderMinutes <= 0 {
+			continue
+		}
+
+		start, err := reservationStart(res)
+		if err != nil {
+			continue
+		}
+		if now.Before(start.Add(-time.Duration(prefs.ReminderMinutes)*time.Minute)) || !now.Before(start) {
+			continue
+		}
+
+		claimed, err := s.repo.MarkReminded(ctx, res)
+		if err != nil {
+			return sent, fmt.Errorf("failed to mark reservation %d: %v", res.ID, err)
+		}
+		if !claimed {
+			continue
+		}
+		minutes := int(math.Ceil(start.Sub(now).Minutes()))
+		delivered, err := s.notify(ctx, notifications.KindReminder, res, minutes)
+		if err != nil {
+			return sent, err
+		}
+		if delivered {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// notify emails the owner of a reservation, unless they opted out of the kind
+// of notification. It reports whether an email was sent.
+func (s *notificationService) notify(ctx context.Context, kind string, res *models.Reservation, minutes int) (bool, error) {
+	user, err := s.userRepo.FindByID(ctx, res.UserID)
+	if err != nil || user.Email == "" {
+		// Deleted users are not notified.
+		return false, nil
+	}
+	prefs, err := s.GetPreferences(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	if !wantsNotification(prefs, kind) {
+		return false, nil
+	}
+
+	data := notifications.TemplateData{
+		UserName:      user.Name,
+		ReservationID: res.ID,
+		Date:          res.Date,
+		StartTime:     res.StartTime,
+		EndTime:       res.EndTime,
+		Minutes:       minutes,
+	}
+	if res.ResourceID != 0 {
+		if resource, err := s.resourceRepo.FindByID(ctx, res.ResourceID); err == nil {
+			data.ResourceName = resource.Name
+		}
+	}
+
+	subject, text, html, err := notifications.Render(prefs.Locale, kind, data)
+	if err != nil {
+		return false, fmt.Errorf("failed to render %s email: %v", kind, err)
+	}
+	msg := notifications.Message{To: user.Email, Subject: subject, Text: text, HTML: html}
+
+	if kind != notifications.KindReminder {
+		event, err := reservationEvent(*res, data.ResourceName, prefs.Locale)
+		if err != nil {
+			return false, err
+		}
+		msg.Attachments = append(msg.Attachments, notifications.Attachment{
+			Filename:    "reserva.ics",
+			ContentType: "text/calendar; charset=utf-8; method=PUBLISH",
+			Data:        []byte(utils.BuildICalendar("", []utils.ICalEvent{event})),
+		})
+	}
+
+	if err := s.notifier.Send(msg); err != nil {
+		return false, fmt.Errorf("failed to send %s email for reservation %d: %v", kind, res.ID, err)
+	}
+	return true, nil
+}
+
+func wantsNotification(prefs *models.NotificationPreferences, kind string) bool {
+	switch kind {
+	case notifications.KindConfirmation:
+		return prefs.Confirmation
+	case notifications.KindChange:
+		return prefs.Change
+	case notifications.KindCancellation:
+		return prefs.Cancellation
+	case notifications.KindReminder:
+		return prefs.Reminder
+	}
+	return false
+}
+
+func supportedLocale(locale string) bool {
+	for _, supported := range notifications.Locales() {
+		if locale == supported {
+			return true
+		}
+	}
+	return false
+}
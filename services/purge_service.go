@@ -0,0 +1,40 @@
+package services
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+	"fmt"
+	"time"
+)
+
+type PurgeService interface {
+	Purge(ctx context.Context, before string, dryRun bool) (*models.PurgeReport, error)
+}
+
+type purgeService struct {
+	repo repositories.PurgeRepository
+	now  func() time.Time
+}
+
+func NewPurgeService(repo repositories.PurgeRepository) PurgeService {
+	return &purgeService{repo: repo, now: time.Now}
+}
+
+// Purge deletes the history dated before a day, given as YYYY-MM-DD. Only past
+// days can be purged, so reservations still to come are never lost.
+func (s *purgeService) Purge(ctx context.Context, before string, dryRun bool) (*models.PurgeReport, error) {
+	day, err := time.ParseInLocation("2006-01-02", before, time.Local)
+	if err != nil {
+		return nil, invalidField("before", CodeInvalidFormat, nil)
+	}
+	if day.After(s.now()) {
+		return nil, invalidField("before", CodeInvalidValue, nil)
+	}
+
+	report, err := s.repo.Purge(ctx, before, day, dryRun)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge: %v", err)
+	}
+	return report, nil
+}
@@ -0,0 +1,228 @@
+package services
+
+import (
+	"booking-api/i18n"
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	CodeQuotaHoursPerWeek       = "quota_hours_per_week"
+	CodeQuotaActiveReservations = "quota_active_reservations"
+	CodeQuotaBookingsPerDay     = "quota_bookings_per_day"
+)
+
+type QuotaService interface {
+	LimitsFor(ctx context.Context, userID uint) (models.QuotaLimits, error)
+	LimitsIn(ctx context.Context, tx repositories.Tx, userID uint) (models.QuotaLimits, error)
+	GetQuotaStatus(ctx context.Context, userID uint) (*models.QuotaStatus, error)
+	SetRoleQuota(ctx context.Context, role string, limits models.QuotaLimits) (*models.Quota, error)
+	SetUserQuota(ctx context.Context, userID uint, limits models.QuotaLimits) (*models.Quota, error)
+}
+
+type quotaService struct {
+	repo            repositories.QuotaRepository
+	userRepo        repositories.UserRepository
+	reservationRepo repositories.ReservationRepository
+	defaults        models.QuotaLimits
+	now             func() time.Time
+}
+
+func NewQuotaService(repo repositories.QuotaRepository, userRepo repositories.UserRepository, reservationRepo repositories.ReservationRepository, defaults models.QuotaLimits) QuotaService {
+	return &quotaService{repo: repo, userRepo: userRepo, reservationRepo: reservationRepo, defaults: defaults, now: time.Now}
+}
+
+// LimitsFor resolves the effective limits of a user: a per-user override wins
+// over a per-role override, which wins over the configured defaults.
+func (s *quotaService) LimitsFor(ctx context.Context, userID uint) (models.QuotaLimits, error) {
+	return s.limits(ctx, s.repo, s.userRepo, userID)
+}
+
+// LimitsIn is LimitsFor read through the repositories of a unit of work, so
+// the limits are those of the transaction checking them.
+func (s *quotaService) LimitsIn(ctx context.Context, tx repositories.Tx, userID uint) (models.QuotaLimits, error) {
+	return s.limits(ctx, tx.Quotas, tx.Users, userID)
+}
+
+func (s *quotaService) limits(ctx context.Context, repo repositories.QuotaRepository, userRepo repositories.UserRepository, userID uint) (models.QuotaLimits, error) {
+	quota, err := repo.FindByUser(ctx, userID)
+	if err != nil {
+		return models.QuotaLimits{}, fmt.Errorf("failed to load user quota: %v", err)
+	}
+	if quota != nil {
+		return quota.QuotaLimits, nil
+	}
+
+	user, err := userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return models.QuotaLimits{}, err
+	}
+	quota, err = repo.FindByRole(ctx, user.Role)
+	if err != nil {
+		return models.QuotaLimits{}, fmt.Errorf("failed to load role quota: %v", err)
+	}
+	if quota != nil {
+		return quota.QuotaLimits, nil
+	}
+
+	return s.defaults, nil
+}
+
+func (s *quotaService) GetQuotaStatus(ctx context.Context, userID uint) (*models.QuotaStatus, error) {
+	limits, err := s.LimitsFor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	usage, err := quotaUsageAt(ctx, s.reservationRepo, userID, now, now, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.QuotaStatus{
+		HoursPerWeek:       newQuotaCounter(limits.HoursPerWeek, usage.weekHours),
+		ActiveReservations: newQuotaCounter(limits.MaxActiveReservations, float64(usage.active)),
+		BookingsPerDay:     newQuotaCounter(limits.BookingsPerDay, float64(usage.dayBookings)),
+	}, nil
+}
+
+func (s *quotaService) SetRoleQuota(ctx context.Context, role string, limits models.QuotaLimits) (*models.Quota, error) {
+	if role == "" {
+		return nil, invalidField("role", CodeRequired, nil)
+	}
+	if err := validateLimits(limits); err != nil {
+		return nil, err
+	}
+	quota, err := s.repo.FindByRole(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	if quota == nil {
+		quota = &models.Quota{Role: role}
+	}
+	quota.QuotaLimits = limits
+	return quota, s.repo.Save(ctx, quota)
+}
+
+func (s *quotaService) SetUserQuota(ctx context.Context, userID uint, limits models.QuotaLimits) (*models.Quota, error) {
+	if err := validateLimits(limits); err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	quota, err := s.repo.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if quota == nil {
+		quota = &models.Quota{UserID: &userID}
+	}
+	quota.QuotaLimits = limits
+	return quota, s.repo.Save(ctx, quota)
+}
+
+type quotaUsage struct {
+	weekHours   float64
+	active      int64
+	dayBookings int
+}
+
+// quotaUsageAt measures the usage of a user for the week and day of `day`.
+// Reservations of a booking group count once, and those in exclude are
+// ignored.
+func quotaUsageAt(ctx context.Context, repo repositories.ReservationRepository, userID uint, day, now time.Time, exclude map[uint]bool) (*quotaUsage, error) {
+	weekStart := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	weekEnd := weekStart.AddDate(0, 0, 6)
+
+	reservations, err := repo.FindByUserBetween(ctx, userID, weekStart.Format("2006-01-02"), weekEnd.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reservations: %v", err)
+	}
+
+	usage := &quotaUsage{}
+	date := day.Format("2006-01-02")
+	groups := map[uint]bool{}
+	for _, r := range reservations {
+		if exclude[r.ID] {
+			continue
+		}
+		if r.GroupID != nil {
+			if groups[*r.GroupID] {
+				continue
+			}
+			groups[*r.GroupID] = true
+		}
+		start, err := time.Parse("15:04", r.StartTime)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse("15:04", r.EndTime)
+		if err != nil {
+			continue
+		}
+		usage.weekHours += end.Sub(start).Hours()
+		if r.Date == date {
+			usage.dayBookings++
+		}
+	}
+
+	usage.active, err = repo.CountActiveByUser(ctx, userID, now.Format("2006-01-02"), now.Format("15:04"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to count active reservations: %v", err)
+	}
+	return usage, nil
+}
+
+// quotaViolations checks whether booking req would exceed the limits. moving
+// holds reservations being rescheduled by req, whose old slot is not counted.
+func quotaViolations(ctx context.Context, repo repositories.ReservationRepository, limits models.QuotaLimits, req *BookingRequest, moving map[uint]bool) ([]Violation, error) {
+	if limits == (models.QuotaLimits{}) {
+		return nil, nil
+	}
+
+	usage, err := quotaUsageAt(ctx, repo, req.Reservation.UserID, req.Start, req.Now, moving)
+	if err != nil {
+		return nil, err
+	}
+
+	var violations []Violation
+	if limits.HoursPerWeek > 0 && usage.weekHours+req.End.Sub(req.Start).Hours() > float64(limits.HoursPerWeek) {
+		violations = append(violations, newViolation(CodeQuotaHoursPerWeek, "", i18n.Params{"limit": limits.HoursPerWeek}))
+	}
+	if limits.MaxActiveReservations > 0 && !req.Rescheduling && usage.active+1 > int64(limits.MaxActiveReservations) {
+		violations = append(violations, newViolation(CodeQuotaActiveReservations, "", i18n.Params{"limit": limits.MaxActiveReservations}))
+	}
+	if limits.BookingsPerDay > 0 && usage.dayBookings+1 > limits.BookingsPerDay {
+		violations = append(violations, newViolation(CodeQuotaBookingsPerDay, "date", i18n.Params{"limit": limits.BookingsPerDay}))
+	}
+	return violations, nil
+}
+
+func newQuotaCounter(limit int, used float64) models.QuotaCounter {
+	counter := models.QuotaCounter{Limit: limit, Used: used}
+	if limit > 0 {
+		remaining := float64(limit) - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		counter.Remaining = &remaining
+	}
+	return counter
+}
+
+func validateLimits(limits models.QuotaLimits) error {
+	switch {
+	case limits.HoursPerWeek < 0:
+		return invalidField("hours_per_week", CodeInvalidValue, nil)
+	case limits.MaxActiveReservations < 0:
+		return invalidField("max_active_reservations", CodeInvalidValue, nil)
+	case limits.BookingsPerDay < 0:
+		return invalidField("bookings_per_day", CodeInvalidValue, nil)
+	}
+	return nil
+}
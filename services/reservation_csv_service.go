@@ -0,0 +1,182 @@
+package services
+
+import (
+	"booking-api/i18n"
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxImportRows bounds the rows of a single import file.
+const maxImportRows = 20000
+
+const CodeUnknownUser = "unknown_user"
+
+var exportHeader = []string{"id", "user_id", "resource_id", "group_id", "date", "start_time", "end_time", "status"}
+
+type ReservationCSVService interface {
+	Import(ctx context.Context, r io.Reader, dryRun bool) (*ImportReport, error)
+	Export(ctx context.Context, w io.Writer, from, to string, userID uint) error
+}
+
+type reservationCSVService struct {
+	reservations ReservationService
+	repo         repositories.ReservationRepository
+	userRepo     repositories.UserRepository
+}
+
+func NewReservationCSVService(reservations ReservationService, repo repositories.ReservationRepository, userRepo repositories.UserRepository) ReservationCSVService {
+	return &reservationCSVService{reservations: reservations, repo: repo, userRepo: userRepo}
+}
+
+// Import reads reservations from a CSV file with a header row. The columns
+// date, start_time and end_time are required, together with user_id or
+// user_email; resource_id is optional and other columns are ignored, so an
+// export can be imported back.
+func (s *reservationCSVService) Import(ctx context.Context, r io.Reader, dryRun bool) (*ImportReport, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, newError(ErrInvalid, CodeCSVEmpty, nil)
+	}
+	if err != nil {
+		return nil, invalidCSV(err.Error())
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, required := range []string{"date", "start_time", "end_time"} {
+		if _, ok := columns[required]; !ok {
+			return nil, newError(ErrInvalid, CodeCSVMissingColumn, i18n.Params{"column": required})
+		}
+	}
+	_, hasUserID := columns["user_id"]
+	_, hasUserEmail := columns["user_email"]
+	if !hasUserID && !hasUserEmail {
+		return nil, newError(ErrInvalid, CodeCSVMissingColumn, i18n.Params{"column": "user_id or user_email"})
+	}
+
+	users := map[string]uint{}
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, invalidCSV(err.Error())
+		}
+		if len(rows) == maxImportRows {
+			return nil, newError(ErrInvalid, CodeCSVTooManyRows, i18n.Params{"max": maxImportRows})
+		}
+
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := ImportRow{
+			Line: line,
+			Reservation: models.Reservation{
+				Date:      field("date"),
+				StartTime: field("start_time"),
+				EndTime:   field("end_time"),
+			},
+		}
+
+		if value := field("user_id"); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				row.Violations = append(row.Violations, newViolation(CodeInvalidFormat, "user_id", nil))
+			}
+			row.Reservation.UserID = uint(id)
+		} else if email := strings.ToLower(field("user_email")); email != "" {
+			id, ok := users[email]
+			if !ok {
+				if user, err := s.userRepo.FindByEmail(ctx, email); err == nil {
+					id = user.ID
+				}
+				users[email] = id
+			}
+			if id == 0 {
+				row.Violations = append(row.Violations, newViolation(CodeUnknownUser, "user_email", i18n.Params{"email": email}))
+			}
+			row.Reservation.UserID = id
+		} else {
+			row.Violations = append(row.Violations, newViolation(CodeRequired, "user_id", nil))
+		}
+
+		if value := field("resource_id"); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				row.Violations = append(row.Violations, newViolation(CodeInvalidFormat, "resource_id", nil))
+			}
+			row.Reservation.ResourceID = uint(id)
+		}
+
+		rows = append(rows, row)
+	}
+
+	return s.reservations.ImportReservations(ctx, rows, dryRun)
+}
+
+// Export writes the reservations between two dates, both included, as CSV.
+// A userID other than 0 limits the export to that user.
+func (s *reservationCSVService) Export(ctx context.Context, w io.Writer, from, to string, userID uint) error {
+	if _, err := time.Parse("2006-01-02", from); err != nil {
+		return invalidField("from", CodeInvalidFormat, nil)
+	}
+	if _, err := time.Parse("2006-01-02", to); err != nil {
+		return invalidField("to", CodeInvalidFormat, nil)
+	}
+	if to < from {
+		return invalidField("to", CodeInvalidTimeRange, nil)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportHeader); err != nil {
+		return err
+	}
+
+	err := s.repo.EachBetween(ctx, from, to, userID, func(res models.Reservation) error {
+		groupID := ""
+		if res.GroupID != nil {
+			groupID = strconv.FormatUint(uint64(*res.GroupID), 10)
+		}
+		return writer.Write([]string{
+			strconv.FormatUint(uint64(res.ID), 10),
+			strconv.FormatUint(uint64(res.UserID), 10),
+			strconv.FormatUint(uint64(res.ResourceID), 10),
+			groupID,
+			res.Date,
+			res.StartTime,
+			res.EndTime,
+			res.Status,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export reservations: %v", err)
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func invalidCSV(message string) error {
+	return newError(ErrInvalid, CodeInvalidCSV, i18n.Params{"reason": message})
+}
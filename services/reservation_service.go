@@ -1,64 +1,610 @@
 package services
 
 import (
+	"booking-api/i18n"
+	"booking-api/metrics"
 	"booking-api/models"
 	"booking-api/repositories"
+	"booking-api/tracing"
+	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type ReservationService interface {
-	CreateReservation(reservation *models.Reservation) error
-	GetReservationsByDate(date string) ([]models.Reservation, error)
+	CreateReservation(ctx context.Context, reservation *models.Reservation) error
+	GetReservationsByDate(ctx context.Context, date string) ([]models.Reservation, error)
+	GetAvailability(ctx context.Context, resourceID uint, date string) ([]models.TimeSlot, error)
+	CreateGroupReservation(ctx context.Context, template *models.Reservation, resourceIDs []uint) ([]models.Reservation, error)
+	CancelReservation(ctx context.Context, id, userID uint) ([]models.Reservation, error)
+	RescheduleReservation(ctx context.Context, id, userID uint, date, startTime, endTime string) ([]models.Reservation, error)
+	ImportReservations(ctx context.Context, rows []ImportRow, dryRun bool) (*ImportReport, error)
+}
+
+// ImportRow is a reservation read from an import file. Rows that could not be
+// read carry the problems in Violations and are reported without booking.
+type ImportRow struct {
+	Line        int
+	Reservation models.Reservation
+	Violations  []Violation
+}
+
+// ImportRowError reports why a row of an import file was rejected.
+type ImportRowError struct {
+	Line       int         `json:"line"`
+	Error      string      `json:"error"`
+	Violations []Violation `json:"violations,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Valid    int              `json:"valid"`
+	Imported int              `json:"imported"`
+	Errors   []ImportRowError `json:"errors"`
 }
 
+// errImportRolledBack discards the transaction of a dry run or a failed import.
+var errImportRolledBack = errors.New("import rolled back")
+
 type reservationService struct {
-	repo repositories.ReservationRepository
+	repo         repositories.ReservationRepository
+	resourceRepo repositories.ResourceRepository
+	ruleRepo     repositories.BookingRuleRepository
+	uow          repositories.UnitOfWork
+	quotas       QuotaService
+	now          func() time.Time
+}
+
+func NewReservationService(repo repositories.ReservationRepository, resourceRepo repositories.ResourceRepository, ruleRepo repositories.BookingRuleRepository, uow repositories.UnitOfWork, quotas QuotaService) ReservationService {
+	return &reservationService{repo: repo, resourceRepo: resourceRepo, ruleRepo: ruleRepo, uow: uow, quotas: quotas, now: time.Now}
+}
+
+// CreateReservation evaluates every booking policy of the resource and the
+// quota of the user, and returns a *PolicyViolationError listing all
+// violations when the request is rejected.
+func (s *reservationService) CreateReservation(ctx context.Context, res *models.Reservation) (err error) {
+	ctx, span := tracing.Start(ctx, "ReservationService.CreateReservation", attribute.Int("booking.resource_id", int(res.ResourceID)))
+	defer tracing.End(span, &err)
+
+	err = s.book(ctx, []*models.Reservation{res}, nil)
+	countBooking(metrics.OperationCreate, 1, err)
+	return err
+}
+
+// CreateGroupReservation books the same time slot on several resources at
+// once. Either every reservation is created, grouped in a BookingGroup, or none.
+func (s *reservationService) CreateGroupReservation(ctx context.Context, template *models.Reservation, resourceIDs []uint) (_ []models.Reservation, err error) {
+	ctx, span := tracing.Start(ctx, "ReservationService.CreateGroupReservation", attribute.Int("booking.resources", len(resourceIDs)))
+	defer tracing.End(span, &err)
+
+	if len(resourceIDs) == 0 {
+		return nil, invalidField("resource_ids", CodeRequired, nil)
+	}
+
+	seen := map[uint]bool{}
+	reservations := make([]*models.Reservation, 0, len(resourceIDs))
+	for _, resourceID := range resourceIDs {
+		if seen[resourceID] {
+			return nil, invalidField("resource_ids", CodeDuplicateResource, i18n.Params{"id": resourceID})
+		}
+		seen[resourceID] = true
+
+		res := *template
+		res.ResourceID = resourceID
+		reservations = append(reservations, &res)
+	}
+
+	err = s.book(ctx, reservations, nil)
+	countBooking(metrics.OperationCreate, len(reservations), err)
+	if err != nil {
+		return nil, err
+	}
+	return derefReservations(reservations), nil
+}
+
+// ImportReservations books every row with the same checks as
+// CreateReservation, in file order and in a single transaction, so rows also
+// conflict with earlier rows of the same file. The import is all or nothing:
+// nothing is stored when a row is rejected or on a dry run.
+func (s *reservationService) ImportReservations(ctx context.Context, rows []ImportRow, dryRun bool) (_ *ImportReport, err error) {
+	ctx, span := tracing.Start(ctx, "ReservationService.ImportReservations",
+		attribute.Int("booking.rows", len(rows)),
+		attribute.Bool("booking.dry_run", dryRun),
+	)
+	defer tracing.End(span, &err)
+
+	report := &ImportReport{DryRun: dryRun, Total: len(rows), Errors: []ImportRowError{}}
+
+	err = s.uow.Do(ctx, func(tx repositories.Tx) error {
+		for i := range rows {
+			row := &rows[i]
+			err := error(&PolicyViolationError{Violations: row.Violations})
+			if len(row.Violations) == 0 {
+				err = s.bookIn(ctx, tx, []*models.Reservation{&row.Reservation}, nil)
+			}
+			if err == nil {
+				report.Valid++
+				continue
+			}
+
+			if !dryRun {
+				countRejection(metrics.OperationImport, err)
+			}
+			rowErr := ImportRowError{Line: row.Line, Error: err.Error()}
+			var policyErr *PolicyViolationError
+			if errors.As(err, &policyErr) {
+				rowErr.Violations = policyErr.Violations
+			}
+			report.Errors = append(report.Errors, rowErr)
+		}
+
+		if dryRun || len(report.Errors) > 0 {
+			return errImportRolledBack
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportRolledBack) {
+		return nil, fmt.Errorf("failed to import reservations: %v", err)
+	}
+
+	if err == nil {
+		report.Imported = report.Valid
+		metrics.ReservationsCreated(report.Imported)
+	}
+	return report, nil
+}
+
+// CancelReservation cancels a reservation of the user, together with the rest
+// of its group.
+func (s *reservationService) CancelReservation(ctx context.Context, id, userID uint) (_ []models.Reservation, err error) {
+	ctx, span := tracing.Start(ctx, "ReservationService.CancelReservation", attribute.Int("booking.reservation_id", int(id)))
+	defer tracing.End(span, &err)
+
+	members, err := s.ownedUnit(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.WithTransaction(ctx, func(tx repositories.ReservationRepository) error {
+		for _, res := range members {
+			before := *res
+			res.Status = models.ReservationCancelled
+			res.Sequence++
+			if err := tx.Update(ctx, res); err != nil {
+				return fmt.Errorf("failed to cancel reservation %d: %v", res.ID, err)
+			}
+			if err := auditReservation(ctx, tx, models.EventReservationCancelled, &before, res); err != nil {
+				return err
+			}
+		}
+		return publish(ctx, tx, models.EventReservationCancelled, members...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	metrics.ReservationsCancelled(len(members))
+	return derefReservations(members), nil
+}
+
+// RescheduleReservation moves a reservation of the user, together with the
+// rest of its group, to a new time slot. The new slot goes through the same
+// checks as a new booking, ignoring the slot being left.
+func (s *reservationService) RescheduleReservation(ctx context.Context, id, userID uint, date, startTime, endTime string) (_ []models.Reservation, err error) {
+	ctx, span := tracing.Start(ctx, "ReservationService.RescheduleReservation", attribute.Int("booking.reservation_id", int(id)))
+	defer tracing.End(span, &err)
+
+	members, err := s.ownedUnit(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	moving := map[uint]bool{}
+	for _, res := range members {
+		moving[res.ID] = true
+		res.Previous = &models.ReservationSlot{Date: res.Date, StartTime: res.StartTime, EndTime: res.EndTime}
+		res.Date = date
+		res.StartTime = startTime
+		res.EndTime = endTime
+		res.Sequence++
+		res.RemindedAt = nil
+	}
+
+	err = s.book(ctx, members, moving)
+	countBooking(metrics.OperationReschedule, len(members), err)
+	if err != nil {
+		return nil, err
+	}
+	return derefReservations(members), nil
+}
+
+// ownedUnit loads the reservation and the rest of its group, checking that it
+// belongs to the user and can still be changed.
+func (s *reservationService) ownedUnit(ctx context.Context, id, userID uint) ([]*models.Reservation, error) {
+	res, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if res.UserID != userID {
+		return nil, newError(ErrForbidden, CodeNotOwner, nil)
+	}
+	if res.Status != models.ReservationConfirmed {
+		return nil, newError(ErrConflict, CodeReservationNotConfirmed, i18n.Params{"status": res.Status})
+	}
+	start, err := reservationStart(res)
+	if err != nil {
+		return nil, err
+	}
+	if !start.After(s.now()) {
+		return nil, newError(ErrConflict, CodeReservationStarted, nil)
+	}
+
+	if res.GroupID == nil {
+		return []*models.Reservation{res}, nil
+	}
+	group, err := s.repo.FindByGroup(ctx, *res.GroupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load booking group: %v", err)
+	}
+	members := make([]*models.Reservation, 0, len(group))
+	for i := range group {
+		if group[i].Status == models.ReservationConfirmed {
+			members = append(members, &group[i])
+		}
+	}
+	return members, nil
+}
+
+// book checks and stores reservations in a single transaction holding a lock
+// on the user, so concurrent requests cannot both pass the overlap and quota
+// checks. All reservations share the user and time slot. New reservations on
+// several resources are grouped. moving holds the IDs of reservations being
+// rescheduled, which no longer count against overlap and quota.
+func (s *reservationService) book(ctx context.Context, reservations []*models.Reservation, moving map[uint]bool) error {
+	return s.uow.Do(ctx, func(tx repositories.Tx) error {
+		return s.bookIn(ctx, tx, reservations, moving)
+	})
+}
+
+// bookIn is book within the unit of work tx. The booking runs in a nested
+// transaction, so a rejected booking leaves tx usable. Buffers, booking rules
+// and quota limits are read in it after locking the user, so they are the ones
+// in force when the reservations are stored.
+func (s *reservationService) bookIn(ctx context.Context, tx repositories.Tx, reservations []*models.Reservation, moving map[uint]bool) error {
+	first := reservations[0]
+	grouped := len(reservations) > 1
+
+	if violations := validateFormat(first); len(violations) > 0 {
+		return &PolicyViolationError{Violations: violations}
+	}
+
+	return tx.Reservations.WithTransaction(ctx, func(repo repositories.ReservationRepository) error {
+		tx.Reservations = repo
+		if err := repo.LockUser(ctx, first.UserID); err != nil {
+			return err
+		}
+
+		type plan struct {
+			res      *models.Reservation
+			buffers  buffers
+			policies []BookingPolicy
+		}
+		plans := make([]plan, 0, len(reservations))
+		for _, res := range reservations {
+			buffers, err := buffersFor(ctx, tx.Resources, res.ResourceID)
+			if err != nil {
+				return err
+			}
+			policies, err := policiesFor(ctx, tx.Rules, res.ResourceID)
+			if err != nil {
+				return err
+			}
+			plans = append(plans, plan{res: res, buffers: buffers, policies: policies})
+		}
+
+		limits, err := s.quotas.LimitsIn(ctx, tx, first.UserID)
+		if err != nil {
+			return err
+		}
+
+		var violations []Violation
+		addViolation := func(v Violation, resourceID uint) {
+			if grouped {
+				v.ResourceID = resourceID
+			}
+			violations = append(violations, v)
+		}
+
+		for i, p := range plans {
+			req := s.newBookingRequest(ctx, repo, p.res)
+			req.Rescheduling = moving != nil
+
+			policies := p.policies
+			if i == 0 {
+				policies = append([]BookingPolicy{timeRangePolicy{}}, policies...)
+			}
+			for _, policy := range policies {
+				violation, err := policy.Evaluate(req)
+				if err != nil {
+					return err
+				}
+				if violation != nil {
+					addViolation(*violation, p.res.ResourceID)
+				}
+			}
+
+			if i == 0 {
+				exceeded, err := quotaViolations(ctx, repo, limits, req, moving)
+				if err != nil {
+					return err
+				}
+				violations = append(violations, exceeded...)
+			}
+
+			// Another reservation's cleanup must end by the start of this
+			// one, and its setup start after this one ends.
+			windowStart, windowEnd := widen(req.Start, req.End, p.buffers.post, p.buffers.pre)
+			overlapping, err := repo.FindOverlapping(ctx, p.res.ResourceID, p.res.Date, windowStart, windowEnd)
+			if err != nil {
+				return fmt.Errorf("failed to check overlaps: %v", err)
+			}
+			for _, other := range overlapping {
+				if !moving[other.ID] {
+					addViolation(newViolation(CodeOverlap, "", nil), p.res.ResourceID)
+					break
+				}
+			}
+		}
+
+		if len(violations) > 0 {
+			return &PolicyViolationError{Violations: violations}
+		}
+
+		if grouped && first.ID == 0 {
+			group := &models.BookingGroup{UserID: first.UserID}
+			if err := repo.CreateGroup(ctx, group); err != nil {
+				return fmt.Errorf("failed to create booking group: %v", err)
+			}
+			for _, res := range reservations {
+				res.GroupID = &group.ID
+			}
+		}
+
+		eventType := models.EventReservationCreated
+		if moving != nil {
+			eventType = models.EventReservationRescheduled
+		}
+		for _, res := range reservations {
+			// A rescheduled reservation is audited against its stored
+			// version, as res already holds the new slot.
+			var before *models.Reservation
+			if res.ID == 0 {
+				err = repo.Create(ctx, res)
+			} else if before, err = repo.FindByID(ctx, res.ID); err == nil {
+				err = repo.Update(ctx, res)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to save reservation: %v", err)
+			}
+			if err := auditReservation(ctx, repo, eventType, before, res); err != nil {
+				return err
+			}
+		}
+		return publish(ctx, repo, eventType, reservations...)
+	})
+}
+
+// countBooking records the outcome of booking n reservations. Reschedules
+// only count when rejected, as they create nothing.
+func countBooking(operation string, n int, err error) {
+	if err == nil {
+		if operation != metrics.OperationReschedule {
+			metrics.ReservationsCreated(n)
+		}
+		return
+	}
+	countRejection(operation, err)
+}
+
+// countRejection records a rejected booking by the codes of its violations,
+// once per code. Errors without a code, such as a failed query, are not
+// rejections.
+func countRejection(operation string, err error) {
+	var policyErr *PolicyViolationError
+	var serviceErr *Error
+	switch {
+	case errors.As(err, &policyErr):
+		seen := map[string]bool{}
+		for _, v := range policyErr.Violations {
+			if !seen[v.Code] {
+				seen[v.Code] = true
+				metrics.ReservationRejected(operation, v.Code)
+			}
+		}
+	case errors.As(err, &serviceErr):
+		metrics.ReservationRejected(operation, serviceErr.Code)
+	}
 }
 
-func NewReservationService(repo repositories.ReservationRepository) ReservationService {
-	return &reservationService{repo: repo}
+func (s *reservationService) GetReservationsByDate(ctx context.Context, date string) (_ []models.Reservation, err error) {
+	ctx, span := tracing.Start(ctx, "ReservationService.GetReservationsByDate")
+	defer tracing.End(span, &err)
+
+	return s.repo.FindByDate(ctx, date)
 }
 
-func (s *reservationService) CreateReservation(res *models.Reservation) error {
+// GetAvailability returns the free slots of a resource within opening hours.
+// Existing reservations are widened by the resource buffers, so the slots are
+// the times a new reservation can actually occupy.
+func (s *reservationService) GetAvailability(ctx context.Context, resourceID uint, date string) (_ []models.TimeSlot, err error) {
+	ctx, span := tracing.Start(ctx, "ReservationService.GetAvailability", attribute.Int("booking.resource_id", int(resourceID)))
+	defer tracing.End(span, &err)
 
-	startTime, err := time.Parse("15:04", res.StartTime)
+	buffers, err := buffersFor(ctx, s.resourceRepo, resourceID)
 	if err != nil {
-		return errors.New("invalid start_time format (expected HH:MM)")
+		return nil, err
 	}
-	endTime, err := time.Parse("15:04", res.EndTime)
+	policies, err := policiesFor(ctx, s.ruleRepo, resourceID)
 	if err != nil {
-		return errors.New("invalid end_time format (expected HH:MM)")
+		return nil, err
 	}
 
-	opening, _ := time.Parse("15:04", "09:00")
-	closing, _ := time.Parse("15:04", "18:00")
+	reservations, err := s.repo.FindByResourceAndDate(ctx, resourceID, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load reservations: %v", err)
+	}
 
-	if startTime.Before(opening) || endTime.After(closing) {
-		return errors.New("reservation must be between 09:00 and 18:00")
+	type interval struct{ start, end time.Time }
+	busy := make([]interval, 0, len(reservations))
+	for _, r := range reservations {
+		start, err := time.Parse("15:04", r.StartTime)
+		if err != nil {
+			continue
+		}
+		end, err := time.Parse("15:04", r.EndTime)
+		if err != nil {
+			continue
+		}
+		busy = append(busy, interval{start.Add(-buffers.pre), end.Add(buffers.post)})
 	}
+	sort.Slice(busy, func(i, j int) bool { return busy[i].start.Before(busy[j].start) })
+
+	opening, closing := openingHours(policies)
 
-	if !endTime.After(startTime) {
-		return errors.New("end_time must be after start_time")
+	slots := []models.TimeSlot{}
+	cursor := opening
+	for _, b := range busy {
+		if b.start.After(cursor) {
+			end := b.start
+			if end.After(closing) {
+				end = closing
+			}
+			if end.After(cursor) {
+				slots = append(slots, models.TimeSlot{StartTime: cursor.Format("15:04"), EndTime: end.Format("15:04")})
+			}
+		}
+		if b.end.After(cursor) {
+			cursor = b.end
+		}
 	}
+	if closing.After(cursor) {
+		slots = append(slots, models.TimeSlot{StartTime: cursor.Format("15:04"), EndTime: closing.Format("15:04")})
+	}
+
+	return slots, nil
+}
 
-	duration := endTime.Sub(startTime)
-	if duration < time.Hour {
-		return errors.New("minimum reservation duration is 1 hour")
+// validateFormat reports malformed fields. No policy can be evaluated without
+// a valid date and times, so these are checked before anything else.
+func validateFormat(res *models.Reservation) []Violation {
+	var violations []Violation
+	if _, err := time.Parse("2006-01-02", res.Date); err != nil {
+		violations = append(violations, newViolation(CodeInvalidFormat, "date", nil))
+	}
+	if _, err := time.Parse("15:04", res.StartTime); err != nil {
+		violations = append(violations, newViolation(CodeInvalidFormat, "start_time", nil))
 	}
+	if _, err := time.Parse("15:04", res.EndTime); err != nil {
+		violations = append(violations, newViolation(CodeInvalidFormat, "end_time", nil))
+	}
+	return violations
+}
 
-	overlapping, err := s.repo.FindOverlapping(res.Date, res.StartTime, res.EndTime)
+// newBookingRequest builds the request evaluated by the policies. The
+// reservation must have passed validateFormat.
+func (s *reservationService) newBookingRequest(ctx context.Context, repo repositories.ReservationRepository, res *models.Reservation) *BookingRequest {
+	day, _ := time.ParseInLocation("2006-01-02", res.Date, time.Local)
+	startTime, _ := time.Parse("15:04", res.StartTime)
+	endTime, _ := time.Parse("15:04", res.EndTime)
+	now := s.now()
+
+	return &BookingRequest{
+		Reservation: res,
+		Start:       atClock(day, startTime),
+		End:         atClock(day, endTime),
+		Now:         now,
+		ActiveBookings: func() (int64, error) {
+			return repo.CountActiveByUser(ctx, res.UserID, now.Format("2006-01-02"), now.Format("15:04"))
+		},
+	}
+}
+
+func policiesFor(ctx context.Context, ruleRepo repositories.BookingRuleRepository, resourceID uint) ([]BookingPolicy, error) {
+	var rules []models.BookingRule
+	if resourceID != 0 {
+		var err error
+		rules, err = ruleRepo.FindByResource(ctx, resourceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load booking rules: %v", err)
+		}
+	}
+	return policiesFromRules(rules)
+}
+
+// buffers are the setup time a resource needs before each reservation (pre)
+// and the cleanup time after it (post). No other reservation may fall inside
+// them.
+type buffers struct {
+	pre, post time.Duration
+}
+
+func buffersFor(ctx context.Context, resourceRepo repositories.ResourceRepository, resourceID uint) (buffers, error) {
+	if resourceID == 0 {
+		return buffers{}, nil
+	}
+	resource, err := resourceRepo.FindByID(ctx, resourceID)
 	if err != nil {
-		return fmt.Errorf("failed to check overlaps: %v", err)
+		return buffers{}, err
 	}
-	if len(overlapping) > 0 {
-		return errors.New("reservation time overlaps with an existing reservation")
+	return buffers{
+		pre:  time.Duration(resource.PreBufferMinutes) * time.Minute,
+		post: time.Duration(resource.PostBufferMinutes) * time.Minute,
+	}, nil
+}
+
+// openingHours returns the configured opening hours, or the whole day when the
+// resource has no opening_hours rule.
+func openingHours(policies []BookingPolicy) (time.Time, time.Time) {
+	for _, policy := range policies {
+		if hours, ok := policy.(*openingHoursPolicy); ok {
+			open, _ := time.Parse("15:04", hours.open)
+			close, _ := time.Parse("15:04", hours.close)
+			return open, close
+		}
 	}
+	open, _ := time.Parse("15:04", "00:00")
+	close, _ := time.Parse("15:04", "23:59")
+	return open, close
+}
 
-	return s.repo.Create(res)
+func atClock(day, clock time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour(), clock.Minute(), 0, 0, day.Location())
 }
 
-func (s *reservationService) GetReservationsByDate(date string) ([]models.Reservation, error) {
-	return s.repo.FindByDate(date)
+// widen extends start by before and end by after, within the same day.
+func widen(start, end time.Time, before, after time.Duration) (string, string) {
+	dayStart := atClock(start, time.Time{})
+	dayEnd := dayStart.Add(24*time.Hour - time.Minute)
+
+	from := start.Add(-before)
+	if from.Before(dayStart) {
+		from = dayStart
+	}
+	to := end.Add(after)
+	if to.After(dayEnd) {
+		to = dayEnd
+	}
+	return from.Format("15:04"), to.Format("15:04")
+}
+
+func derefReservations(reservations []*models.Reservation) []models.Reservation {
+	result := make([]models.Reservation, len(reservations))
+	for i, res := range reservations {
+		result[i] = *res
+	}
+	return result
 }
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"booking-api/i18n"
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+)
+
+type ResourceService interface {
+	CreateResource(ctx context.Context, resource *models.Resource) error
+	GetResources(ctx context.Context) ([]models.Resource, error)
+	AddRule(ctx context.Context, rule *models.BookingRule) error
+	GetRules(ctx context.Context, resourceID uint) ([]models.BookingRule, error)
+	UpdateRule(ctx context.Context, id uint, kind, value string) (*models.BookingRule, error)
+	DeleteRule(ctx context.Context, id uint) error
+}
+
+type resourceService struct {
+	repo     repositories.ResourceRepository
+	ruleRepo repositories.BookingRuleRepository
+}
+
+func NewResourceService(repo repositories.ResourceRepository, ruleRepo repositories.BookingRuleRepository) ResourceService {
+	return &resourceService{repo: repo, ruleRepo: ruleRepo}
+}
+
+func (s *resourceService) CreateResource(ctx context.Context, resource *models.Resource) error {
+	if resource.Name == "" {
+		return invalidField("name", CodeRequired, nil)
+	}
+	if resource.PreBufferMinutes < 0 {
+		return invalidField("pre_buffer_minutes", CodeInvalidValue, nil)
+	}
+	if resource.PostBufferMinutes < 0 {
+		return invalidField("post_buffer_minutes", CodeInvalidValue, nil)
+	}
+	return s.repo.Create(ctx, resource)
+}
+
+func (s *resourceService) GetResources(ctx context.Context) ([]models.Resource, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// AddRule validates a booking rule against the policy registry before storing
+// it, so a misconfigured rule never reaches CreateReservation.
+func (s *resourceService) AddRule(ctx context.Context, rule *models.BookingRule) error {
+	if _, err := s.repo.FindByID(ctx, rule.ResourceID); err != nil {
+		return err
+	}
+	if err := validateRule(rule); err != nil {
+		return err
+	}
+	return s.ruleRepo.Create(ctx, rule)
+}
+
+func (s *resourceService) GetRules(ctx context.Context, resourceID uint) ([]models.BookingRule, error) {
+	if _, err := s.repo.FindByID(ctx, resourceID); err != nil {
+		return nil, err
+	}
+	return s.ruleRepo.FindByResource(ctx, resourceID)
+}
+
+// UpdateRule changes the kind and value of a rule, validated as AddRule does.
+// The rule stays on its resource.
+func (s *resourceService) UpdateRule(ctx context.Context, id uint, kind, value string) (*models.BookingRule, error) {
+	rule, err := s.ruleRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	rule.Kind = kind
+	rule.Value = value
+	if err := validateRule(rule); err != nil {
+		return nil, err
+	}
+	if err := s.ruleRepo.Update(ctx, rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteRule removes a rule. A default rule of the same kind applies again.
+func (s *resourceService) DeleteRule(ctx context.Context, id uint) error {
+	rule, err := s.ruleRepo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.ruleRepo.Delete(ctx, rule)
+}
+
+func validateRule(rule *models.BookingRule) error {
+	if _, err := NewPolicy(rule.Kind, rule.Value); err != nil {
+		field := "value"
+		if _, ok := policyFactories[rule.Kind]; !ok {
+			field = "kind"
+		}
+		return invalidField(field, CodeInvalidRule, i18n.Params{"reason": err.Error()})
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"booking-api/models"
+	"booking-api/realtime"
+	"booking-api/repositories"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// maxMissedEvents bounds the events replayed to a reconnecting subscriber.
+// Clients that missed more should reload the day instead.
+const maxMissedEvents = 500
+
+// StreamFilter selects the events a subscriber receives. Empty fields match
+// everything.
+type StreamFilter struct {
+	Date       string
+	ResourceID *uint
+}
+
+// Matches reports whether a change of res concerns the filter. Rescheduled
+// reservations match both the slot they left and the new one.
+func (f StreamFilter) Matches(res models.Reservation) bool {
+	if f.ResourceID != nil && res.ResourceID != *f.ResourceID {
+		return false
+	}
+	if f.Date == "" || res.Date == f.Date {
+		return true
+	}
+	return res.Previous != nil && res.Previous.Date == f.Date
+}
+
+type StreamService interface {
+	Subscribe(filter StreamFilter) (<-chan realtime.Event, func())
+	Missed(ctx context.Context, afterID uint, filter StreamFilter) ([]realtime.Event, error)
+	Relay(ctx context.Context) (int, error)
+	Close()
+}
+
+type streamService struct {
+	repo   repositories.OutboxRepository
+	broker realtime.Broker
+
+	mu      sync.Mutex
+	started bool
+	lastID  uint
+}
+
+func NewStreamService(repo repositories.OutboxRepository, broker realtime.Broker) StreamService {
+	return &streamService{repo: repo, broker: broker}
+}
+
+func (s *streamService) Subscribe(filter StreamFilter) (<-chan realtime.Event, func()) {
+	return s.broker.Subscribe(func(event realtime.Event) bool {
+		return filter.Matches(event.Reservation)
+	})
+}
+
+// Close ends the open streams. Clients reconnect with Last-Event-ID, to
+// another instance or once this one is back.
+func (s *streamService) Close() {
+	s.broker.Close()
+}
+
+// Missed returns the events after afterID matching the filter, so clients
+// reconnecting with Last-Event-ID do not lose changes.
+func (s *streamService) Missed(ctx context.Context, afterID uint, filter StreamFilter) ([]realtime.Event, error) {
+	outbox, err := s.repo.FindAfter(ctx, afterID, maxMissedEvents)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load events: %v", err)
+	}
+
+	events := []realtime.Event{}
+	for _, record := range outbox {
+		event, err := streamEvent(record)
+		if err != nil {
+			return nil, err
+		}
+		if filter.Matches(event.Reservation) {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// Relay publishes the outbox events recorded since the previous run. Every
+// instance tails the shared outbox, so subscribers get every change whichever
+// instance made it. The first run starts from the latest event.
+func (s *streamService) Relay(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.started {
+		latest, err := s.repo.LatestID(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to load the latest event: %v", err)
+		}
+		s.lastID = latest
+		s.started = true
+		return 0, nil
+	}
+
+	outbox, err := s.repo.FindAfter(ctx, s.lastID, webhookBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load events: %v", err)
+	}
+	for _, record := range outbox {
+		s.lastID = record.ID
+		event, err := streamEvent(record)
+		if err != nil {
+			return 0, err
+		}
+		s.broker.Publish(event)
+	}
+	return len(outbox), nil
+}
+
+func streamEvent(record models.OutboxEvent) (realtime.Event, error) {
+	event := realtime.Event{ID: record.ID, Type: record.Type}
+	if err := json.Unmarshal([]byte(record.Payload), &event.Reservation); err != nil {
+		return event, fmt.Errorf("failed to decode event %d: %v", record.ID, err)
+	}
+	return event, nil
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"booking-api/i18n"
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxWebhookAttempts is how many times a delivery is tried before it is
+	// marked as failed.
+	maxWebhookAttempts = 8
+	// webhookRetryBase is the wait after the first failed attempt, doubled
+	// after each further one.
+	webhookRetryBase = 30 * time.Second
+	// webhookBatchSize bounds the events and deliveries handled per run.
+	webhookBatchSize = 100
+	// deliveryLogSize is how many deliveries the delivery log returns.
+	deliveryLogSize = 100
+)
+
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error
+	GetSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uint) error
+	GetDeliveries(ctx context.Context, subscriptionID uint) ([]models.WebhookDelivery, error)
+	Redeliver(ctx context.Context, deliveryID uint) (*models.WebhookDelivery, error)
+	ProcessOutbox(ctx context.Context) (int, error)
+	DeliverDue(ctx context.Context) (int, error)
+}
+
+// WebhookSettings limit where deliveries are sent. Subscriptions may only
+// reach public addresses unless AllowPrivateHosts is set.
+type WebhookSettings struct {
+	AllowPrivateHosts bool
+}
+
+type webhookService struct {
+	repo   repositories.WebhookRepository
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewWebhookService(repo repositories.WebhookRepository, settings WebhookSettings) WebhookService {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !settings.AllowPrivateHosts {
+		dialer.Control = publicOnly
+	}
+	return &webhookService{
+		repo: repo,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			// No proxy: the addresses checked must be the ones connected to.
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+		},
+		now: time.Now,
+	}
+}
+
+// CreateSubscription validates the URL and event filter and generates the
+// signing secret when none is given.
+func (s *webhookService) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	target, err := url.Parse(subscription.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return invalidField("url", CodeInvalidURL, nil)
+	}
+
+	var events []string
+	for _, event := range strings.Split(subscription.Events, ",") {
+		event = strings.TrimSpace(event)
+		if event == "" {
+			continue
+		}
+		if !knownEvent(event) {
+			return invalidField("events", CodeUnknownEvent, i18n.Params{"event": event})
+		}
+		events = append(events, event)
+	}
+	subscription.Events = strings.Join(events, ",")
+
+	if subscription.Secret == "" {
+		secret, err := utils.RandomToken()
+		if err != nil {
+			return fmt.Errorf("failed to generate webhook secret: %v", err)
+		}
+		subscription.Secret = secret
+	}
+
+	if err := s.repo.CreateSubscription(ctx, subscription); err != nil {
+		return fmt.Errorf("failed to save webhook: %v", err)
+	}
+	return nil
+}
+
+func (s *webhookService) GetSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return s.repo.FindSubscriptions(ctx)
+}
+
+func (s *webhookService) DeleteSubscription(ctx context.Context, id uint) error {
+	subscription, err := s.repo.FindSubscriptionByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.repo.DeleteSubscription(ctx, subscription)
+}
+
+func (s *webhookService) GetDeliveries(ctx context.Context, subscriptionID uint) ([]models.WebhookDelivery, error) {
+	if _, err := s.repo.FindSubscriptionByID(ctx, subscriptionID); err != nil {
+		return nil, err
+	}
+	return s.repo.FindDeliveriesBySubscription(ctx, subscriptionID, deliveryLogSize)
+}
+
+// Redeliver sends a delivery again right away, whatever its status. If it
+// fails it goes back to the retry schedule while attempts remain.
+func (s *webhookService) Redeliver(ctx context.Context, deliveryID uint) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.FindDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Status = models.DeliveryPending
+	if delivery.Attempts >= maxWebhookAttempts {
+		delivery.Attempts = maxWebhookAttempts - 1
+	}
+	if err := s.attempt(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// ProcessOutbox fans out the pending outbox events to the subscriptions that
+// listen to them. It returns how many events were dispatched.
+func (s *webhookService) ProcessOutbox(ctx context.Context) (int, error) {
+	events, err := s.repo.FindPendingEvents(ctx, webhookBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load outbox events: %v", err)
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	subscriptions, err := s.repo.FindSubscriptions(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load webhooks: %v", err)
+	}
+
+	now := s.now()
+	for i, event := range events {
+		payload, err := json.Marshal(map[string]interface{}{
+			"id":         event.ID,
+			"type":       event.Type,
+			"created_at": event.CreatedAt,
+			"data":       json.RawMessage(event.Payload),
+		})
+		if err != nil {
+			return i, fmt.Errorf("failed to encode event %d: %v", event.ID, err)
+		}
+
+		var deliveries []models.WebhookDelivery
+		for _, subscription := range subscriptions {
+			if !subscribed(subscription, event.Type) {
+				continue
+			}
+			deliveries = append(deliveries, models.WebhookDelivery{
+				SubscriptionID: subscription.ID,
+				EventID:        event.ID,
+				EventType:      event.Type,
+				Payload:        string(payload),
+				Status:         models.DeliveryPending,
+				NextAttemptAt:  &now,
+			})
+		}
+
+		if err := s.repo.DispatchEvent(ctx, &events[i], deliveries); err != nil {
+			return i, fmt.Errorf("failed to dispatch event %d: %v", event.ID, err)
+		}
+	}
+	return len(events), nil
+}
+
+// DeliverDue attempts every delivery whose next attempt is due. It returns how
+// many were attempted.
+func (s *webhookService) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := s.repo.FindDueDeliveries(ctx, s.now(), webhookBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load deliveries: %v", err)
+	}
+
+	for i := range deliveries {
+		if err := s.attempt(ctx, &deliveries[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(deliveries), nil
+}
+
+// attempt sends a delivery once and records the outcome. A failed send is not
+// an error: it is recorded on the delivery and retried with exponential
+// back-off. Only failing to save the outcome is returned.
+func (s *webhookService) attempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	now := s.now()
+	delivery.Attempts++
+	delivery.LastAttemptAt = &now
+	delivery.ResponseStatus = 0
+
+	sendErr := s.send(ctx, delivery, now)
+	switch {
+	case sendErr == nil:
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= maxWebhookAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = sendErr.Error()
+		delivery.NextAttemptAt = nil
+	default:
+		delivery.LastError = sendErr.Error()
+		next := now.Add(webhookRetryBase << (delivery.Attempts - 1))
+		delivery.NextAttemptAt = &next
+	}
+
+	if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to save delivery %d: %v", delivery.ID, err)
+	}
+	return nil
+}
+
+func (s *webhookService) send(ctx context.Context, delivery *models.WebhookDelivery, now time.Time) error {
+	subscription, err := s.repo.FindSubscriptionByID(ctx, delivery.SubscriptionID)
+	if err != nil {
+		return err
+	}
+
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "booking-api-webhooks")
+	req.Header.Set("X-Webhook-Event", delivery.EventType)
+	req.Header.Set("X-Webhook-Delivery", strconv.FormatUint(uint64(delivery.ID), 10))
+	req.Header.Set("X-Webhook-Timestamp", strconv.FormatInt(timestamp, 10))
+	req.Header.Set("X-Webhook-Signature", utils.SignWebhook(subscription.Secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	delivery.ResponseStatus = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func knownEvent(event string) bool {
+	for _, known := range models.EventTypes {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+func subscribed(subscription models.WebhookSubscription, event string) bool {
+	if subscription.Events == "" {
+		return true
+	}
+	for _, wanted := range strings.Split(subscription.Events, ",") {
+		if wanted == event {
+			return true
+		}
+	}
+	return false
+}
+
+// newOutboxEvent describes a change of a reservation for the outbox.
+func newOutboxEvent(eventType string, res *models.Reservation) (*models.OutboxEvent, error) {
+	payload, err := json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event: %v", eventType, err)
+	}
+	return &models.OutboxEvent{Type: eventType, Payload: string(payload)}, nil
+}
+
+// publish writes an event for each reservation to the outbox of repo, which
+// should be bound to the transaction of the change.
+func publish(ctx context.Context, repo repositories.ReservationRepository, eventType string, reservations ...*models.Reservation) error {
+	for _, res := range reservations {
+		event, err := newOutboxEvent(eventType, res)
+		if err != nil {
+			return err
+		}
+		if err := repo.CreateOutboxEvent(ctx, event); err != nil {
+			return fmt.Errorf("failed to record %s event: %v", eventType, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package tests
+
+import (
+	"booking-api/audit"
+	"booking-api/database"
+	"booking-api/middlewares"
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/services"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type auditFixture struct {
+	db    *gorm.DB
+	users repositories.UserRepository
+	auth  services.AuthService
+	audit services.AuditService
+}
+
+// newAuditFixture migrates a database whose audit log is hash chained.
+func newAuditFixture(t *testing.T) *auditFixture {
+	db := openMigrationDB(t, filepath.Join(t.TempDir(), "booking.db"))
+	migrator, err := database.NewMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	require.NoError(t, database.ChainAudit(db))
+
+	users := repositories.NewUserRepository(db)
+	return &auditFixture{
+		db:    db,
+		users: users,
+		auth:  services.NewAuthService(users, testAuthSettings),
+		audit: services.NewAuditService(repositories.NewAuditRepository(db)),
+	}
+}
+
+func TestAudit_RecordsAccountEventsWithActor(t *testing.T) {
+	f := newAuditFixture(t)
+	ctx := audit.WithActor(context.Background(), audit.Actor{IP: "203.0.113.7", UserAgent: "curl/8.5", RequestID: "req-1"})
+
+	user := &models.User{Name: "Ana", Email: "ana@example.com", Password: "secret"}
+	require.NoError(t, f.auth.Register(ctx, user))
+	_, err := f.auth.Login(ctx, "ana@example.com", "wrong")
+	require.Error(t, err)
+	_, err = f.auth.Login(ctx, "ana@example.com", "secret")
+	require.NoError(t, err)
+	_, err = f.auth.Login(ctx, "nadie@example.com", "secret")
+	require.Error(t, err)
+
+	entries, err := f.audit.List(context.Background(), repositories.AuditFilter{TargetType: models.AuditTargetUser, TargetID: user.ID})
+	require.NoError(t, err)
+	require.Len(t, entries, 3, "unknown emails have no account to audit")
+	assert.Equal(t, models.AuditLoginSucceeded, entries[0].Action)
+	assert.Equal(t, &user.ID, entries[0].ActorID, "logging in identifies the actor")
+	assert.Equal(t, models.AuditLoginFailed, entries[1].Action)
+	assert.Nil(t, entries[1].ActorID)
+
+	registered := entries[2]
+	assert.Equal(t, models.AuditUserRegistered, registered.Action)
+	assert.Equal(t, "203.0.113.7", registered.IP)
+	assert.Equal(t, "curl/8.5", registered.UserAgent)
+	assert.Equal(t, "req-1", registered.RequestID)
+	assert.Contains(t, registered.Changes, `"email":{"before":null,"after":"ana@example.com"}`)
+	assert.Contains(t, registered.Changes, `"password":{"before":null,"after":"[REDACTED]"}`)
+	assert.NotContains(t, registered.Changes, user.Password, "password hashes stay out of the log")
+
+	failed, err := f.audit.List(context.Background(), repositories.AuditFilter{Action: models.AuditLoginFailed})
+	require.NoError(t, err)
+	assert.Len(t, failed, 1)
+}
+
+func TestAudit_EntryIsRolledBackWithTheChange(t *testing.T) {
+	f := newAuditFixture(t)
+	errRollback := errors.New("rollback")
+
+	err := f.users.WithTransaction(context.Background(), func(tx repositories.UserRepository) error {
+		require.NoError(t, tx.AppendAudit(context.Background(), &models.AuditEntry{Action: models.AuditUserPasswordReset}))
+		return errRollback
+	})
+	require.ErrorIs(t, err, errRollback)
+
+	entries, err := f.audit.List(context.Background(), repositories.AuditFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestAudit_HashChainDetectsTampering(t *testing.T) {
+	f := newAuditFixture(t)
+	for _, email := range []string{"ana@example.com", "bea@example.com", "carla@example.com"} {
+		require.NoError(t, f.auth.Register(context.Background(), &models.User{Name: "User", Email: email, Password: "secret"}))
+	}
+
+	result, err := f.audit.Verify(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, 3, result.Checked)
+
+	entries, err := f.audit.List(context.Background(), repositories.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, entries[0].Hash, result.LastHash)
+	assert.Equal(t, entries[1].Hash, entries[0].PrevHash)
+
+	middle := entries[1]
+	require.NoError(t, f.db.Model(&models.AuditEntry{}).Where("id = ?", middle.ID).
+		Update("changes", strings.Replace(middle.Changes, "bea@", "eve@", 1)).Error)
+	result, err = f.audit.Verify(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, &middle.ID, result.BrokenAt)
+	assert.Equal(t, 1, result.Checked)
+
+	require.NoError(t, f.db.Delete(&models.AuditEntry{}, middle.ID).Error)
+	result, err = f.audit.Verify(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Valid, "removing an entry breaks the next one")
+	assert.Equal(t, &entries[0].ID, result.BrokenAt)
+}
+
+func TestAudit_ConcurrentLoginsKeepTheChain(t *testing.T) {
+	f := newAuditFixture(t)
+	require.NoError(t, f.auth.Register(context.Background(), &models.User{Name: "Ana", Email: "ana@example.com", Password: "secret"}))
+
+	const logins = 8
+	var wg sync.WaitGroup
+	errs := make([]error, logins)
+	for i := range logins {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			password := "secret"
+			if i%2 == 0 {
+				password = "wrong"
+			}
+			_, err := f.auth.Login(context.Background(), "ana@example.com", password)
+			if err != nil && !errors.Is(err, services.ErrUnauthorized) {
+				errs[i] = err
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	result, err := f.audit.Verify(context.Background())
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Equal(t, logins+1, result.Checked)
+}
+
+func TestAudit_ListValidatesFilter(t *testing.T) {
+	f := newAuditFixture(t)
+
+	var serviceErr *services.Error
+	_, err := f.audit.List(context.Background(), repositories.AuditFilter{Limit: services.MaxAuditLimit + 1})
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, "limit", serviceErr.Field)
+}
+
+func TestAuditActor_TakesRequestOriginAndUser(t *testing.T) {
+	app := setupFiber()
+	app.Use(middlewares.RequestID())
+	app.Use(middlewares.AuditActor())
+	var actor audit.Actor
+	app.Get("/", func(c *fiber.Ctx) error {
+		actor = audit.ActorFrom(audit.WithUser(c.UserContext(), 7))
+		return nil
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderUserAgent, "booking-app/2.1")
+	req.Header.Set(fiber.HeaderXRequestID, "req-42")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, "booking-app/2.1", actor.UserAgent)
+	assert.Equal(t, "req-42", actor.RequestID)
+	assert.NotEmpty(t, actor.IP)
+	require.NotNil(t, actor.UserID)
+	assert.Equal(t, uint(7), *actor.UserID)
+}
@@ -4,6 +4,7 @@ import (
 	"booking-api/controllers"
 	"booking-api/models"
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
 	"net/http/httptest"
@@ -18,18 +19,31 @@ type MockAuthService struct {
 	mock.Mock
 }
 
-func (m *MockAuthService) Register(user *models.User) error {
-	args := m.Called(user)
+func (m *MockAuthService) Register(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
 	return args.Error(0)
 }
 
-func (m *MockAuthService) Login(email, password string) (string, error) {
-	args := m.Called(email, password)
+func (m *MockAuthService) Login(ctx context.Context, email, password string) (string, error) {
+	args := m.Called(ctx, email, password)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockAuthService) CreateAdmin(ctx context.Context, user *models.User) error {
+	return m.Called(ctx, user).Error(0)
+}
+
+func (m *MockAuthService) ResetPassword(ctx context.Context, email, password string) error {
+	return m.Called(ctx, email, password).Error(0)
+}
+
+func (m *MockAuthService) IssueToken(user *models.User) (string, error) {
+	args := m.Called(user)
 	return args.String(0), args.Error(1)
 }
 
 func setupFiber() *fiber.App {
-	return fiber.New()
+	return fiber.New(fiber.Config{ErrorHandler: controllers.ErrorHandler})
 }
 
 func TestAuthController_Login_Success(t *testing.T) {
@@ -45,7 +59,7 @@ func TestAuthController_Login_Success(t *testing.T) {
 	}
 	body, _ := json.Marshal(loginInput)
 
-	mockService.On("Login", "test@example.com", "secret").Return("mocked-token", nil)
+	mockService.On("Login", mock.Anything, "test@example.com", "secret").Return("mocked-token", nil)
 
 	req := httptest.NewRequest("POST", "/login", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
@@ -81,11 +95,12 @@ func TestAuthController_Register_Success(t *testing.T) {
 		Password: "password123",
 	}
 
-	mockService.On("Register", mock.MatchedBy(func(u *models.User) bool {
+	mockService.On("Register", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
 		return u.Name == expectedUser.Name &&
 			u.Email == expectedUser.Email &&
 			u.Password == expectedUser.Password
 	})).Return(nil)
+	mockService.On("IssueToken", mock.Anything).Return("registered-token", nil)
 
 	req := httptest.NewRequest("POST", "/register", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
@@ -97,6 +112,6 @@ func TestAuthController_Register_Success(t *testing.T) {
 	respBody, _ := io.ReadAll(resp.Body)
 
 	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
-	assert.Contains(t, string(respBody), "token")
+	assert.Contains(t, string(respBody), "registered-token")
 	mockService.AssertExpectations(t)
 }
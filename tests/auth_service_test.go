@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/services"
+	"booking-api/utils"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var testAuthSettings = services.AuthSettings{JWTSecret: "test-secret", TokenTTL: time.Hour}
+
+func TestAuthService_CreateAdmin_StoresAdminWithHashedPassword(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	service := services.NewAuthService(userRepo, testAuthSettings)
+
+	userRepo.On("FindByEmail", mock.Anything, "root@example.com").Return(nil, &repositories.NotFoundError{Entity: "user"})
+	userRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+	userRepo.On("AppendAudit", mock.Anything, mock.Anything).Return(nil)
+
+	user := &models.User{Name: "Root", Email: "root@example.com", Password: "secret"}
+	require.NoError(t, service.CreateAdmin(context.Background(), user))
+
+	assert.Equal(t, models.RoleAdmin, user.Role)
+	assert.True(t, utils.CheckPasswordHash("secret", user.Password))
+	userRepo.AssertCalled(t, "AppendAudit", mock.Anything, mock.MatchedBy(func(e *models.AuditEntry) bool {
+		return e.Action == models.AuditUserAdminCreated && !strings.Contains(e.Changes, user.Password)
+	}))
+}
+
+func TestAuthService_ResetPassword(t *testing.T) {
+	userRepo := new(MockUserRepository)
+	service := services.NewAuthService(userRepo, testAuthSettings)
+
+	user := &models.User{Email: "ana@example.com", Password: "old-hash"}
+	userRepo.On("FindByEmail", mock.Anything, "ana@example.com").Return(user, nil)
+	userRepo.On("FindByEmail", mock.Anything, "nadie@example.com").Return(nil, &repositories.NotFoundError{Entity: "user"})
+	userRepo.On("Update", mock.Anything, user).Return(nil)
+	userRepo.On("AppendAudit", mock.Anything, mock.Anything).Return(nil)
+
+	require.NoError(t, service.ResetPassword(context.Background(), "ana@example.com", "new-secret"))
+	assert.True(t, utils.CheckPasswordHash("new-secret", user.Password))
+
+	err := service.ResetPassword(context.Background(), "nadie@example.com", "new-secret")
+	assert.True(t, errors.Is(err, repositories.ErrNotFound))
+
+	var serviceErr *services.Error
+	require.True(t, errors.As(service.ResetPassword(context.Background(), "ana@example.com", ""), &serviceErr))
+	assert.Equal(t, services.CodeRequired, serviceErr.Code)
+	userRepo.AssertNumberOfCalls(t, "Update", 1)
+}
+
+func TestAuthService_IssueToken_UsesSettings(t *testing.T) {
+	service := services.NewAuthService(new(MockUserRepository), testAuthSettings)
+
+	user := &models.User{Role: models.RoleUser}
+	user.ID = 4
+	signed, err := service.IssueToken(user)
+	require.NoError(t, err)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(signed, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(testAuthSettings.JWTSecret), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, float64(4), claims["user_id"])
+	assert.WithinDuration(t, time.Now().Add(testAuthSettings.TokenTTL), time.Unix(int64(claims["exp"].(float64)), 0), time.Minute)
+
+	_, err = jwt.Parse(signed, func(*jwt.Token) (interface{}, error) { return []byte("other-secret"), nil })
+	assert.Error(t, err)
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"booking-api/models"
+	"booking-api/repositories/memory"
+	"booking-api/services"
+	"booking-api/utils"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildICalendar_Event(t *testing.T) {
+	start := time.Date(2025, 5, 23, 10, 0, 0, 0, time.UTC)
+	calendar := utils.BuildICalendar("Sala A", []utils.ICalEvent{{
+		UID:      "reservation-7@booking-api",
+		Summary:  "Reserva: Sala A, planta 2; ala norte",
+		Start:    start,
+		End:      start.Add(time.Hour),
+		Stamp:    start.Add(-time.Hour),
+		Sequence: 2,
+		Status:   "CANCELLED",
+	}})
+
+	assert.True(t, strings.HasPrefix(calendar, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(calendar, "END:VCALENDAR\r\n"))
+	assert.Contains(t, calendar, "UID:reservation-7@booking-api\r\n")
+	assert.Contains(t, calendar, "DTSTART:20250523T100000Z\r\n")
+	assert.Contains(t, calendar, "DTEND:20250523T110000Z\r\n")
+	assert.Contains(t, calendar, "SEQUENCE:2\r\n")
+	assert.Contains(t, calendar, "STATUS:CANCELLED\r\n")
+	assert.Contains(t, calendar, `SUMMARY:Reserva: Sala A\, planta 2\; ala norte`)
+}
+
+func TestBuildICalendar_FoldsLongLines(t *testing.T) {
+	calendar := utils.BuildICalendar("", []utils.ICalEvent{{
+		UID:         "reservation-1@booking-api",
+		Summary:     "Reserva",
+		Description: strings.Repeat("ñ", 100),
+		Status:      "CONFIRMED",
+	}})
+
+	for _, line := range strings.Split(strings.TrimSuffix(calendar, "\r\n"), "\r\n") {
+		assert.LessOrEqual(t, len(line), 75)
+	}
+	unfolded := strings.ReplaceAll(calendar, "\r\n ", "")
+	assert.Contains(t, unfolded, "DESCRIPTION:"+strings.Repeat("ñ", 100)+"\r\n")
+}
+
+func TestCalendarService_FeedInRequesterLocale(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	repos := memoryRepositorySet(store)
+	ana := createUser(t, repos, "ana@example.com")
+	require.NoError(t, repos.resources.Create(ctx, &models.Resource{Name: "Sala A"}))
+	createReservation(t, repos, ana, 1, time.Now().AddDate(0, 0, 1).Format("2006-01-02"), "10:00", "11:00", "")
+	service := services.NewCalendarService(repos.reservations, repos.resources, repos.users)
+	token, err := service.UserFeedToken(ctx, ana.ID, false)
+	require.NoError(t, err)
+
+	calendar, err := service.FeedCalendar(ctx, token, "en")
+	require.NoError(t, err)
+	assert.Contains(t, calendar, "X-WR-CALNAME:Bookings of User\r\n")
+	assert.Contains(t, calendar, "SUMMARY:Booking: Sala A\r\n")
+
+	calendar, err = service.FeedCalendar(ctx, token, "es")
+	require.NoError(t, err)
+	assert.Contains(t, calendar, "X-WR-CALNAME:Reservas de User\r\n")
+	assert.Contains(t, calendar, "SUMMARY:Reserva: Sala A\r\n")
+}
@@ -0,0 +1,164 @@
+package tests
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/services"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUnitOfWork hands its repositories to the work, without a transaction.
+type fakeUnitOfWork repositories.Tx
+
+func (u fakeUnitOfWork) Do(ctx context.Context, fn func(tx repositories.Tx) error) error {
+	return fn(repositories.Tx(u))
+}
+
+var checkInSettings = services.CheckInSettings{Before: 15 * time.Minute, Grace: 15 * time.Minute}
+
+func reservationStartingIn(offset time.Duration) *models.Reservation {
+	start := time.Now().Add(offset)
+	res := &models.Reservation{
+		UserID:     7,
+		ResourceID: 1,
+		Date:       start.Format("2006-01-02"),
+		StartTime:  start.Format("15:04"),
+		EndTime:    start.Add(time.Hour).Format("15:04"),
+		Status:     models.ReservationConfirmed,
+	}
+	res.ID = 42
+	return res
+}
+
+func roomWithCheckInCode(code string) *models.Resource {
+	room := &models.Resource{Name: "Room A", CheckInCode: code}
+	room.ID = 1
+	return room
+}
+
+func TestCheckInService_CheckIn_WithinWindow(t *testing.T) {
+	repo := new(MockReservationRepository)
+	resourceRepo := new(MockResourceRepository)
+	service := services.NewCheckInService(repo, resourceRepo, fakeUnitOfWork{Reservations: repo}, checkInSettings)
+
+	repo.On("FindByID", mock.Anything, uint(42)).Return(reservationStartingIn(5*time.Minute), nil)
+	resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithCheckInCode("ROOM-A"), nil)
+	repo.On("MarkCheckedIn", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+	repo.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(e *models.OutboxEvent) bool {
+		return e.Type == models.EventReservationCheckedIn
+	})).Return(nil)
+	repo.On("AppendAudit", mock.Anything, mock.MatchedBy(func(e *models.AuditEntry) bool {
+		return e.Action == models.EventReservationCheckedIn && e.TargetID == 42 && strings.Contains(e.Changes, `"status":{"before":"confirmed","after":"checked_in"}`)
+	})).Return(nil)
+
+	res, err := service.CheckIn(context.Background(), 42, 7, "ROOM-A")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReservationCheckedIn, res.Status)
+	repo.AssertExpectations(t)
+}
+
+func TestCheckInService_CheckIn_WrongCode(t *testing.T) {
+	repo := new(MockReservationRepository)
+	resourceRepo := new(MockResourceRepository)
+	service := services.NewCheckInService(repo, resourceRepo, fakeUnitOfWork{Reservations: repo}, checkInSettings)
+
+	repo.On("FindByID", mock.Anything, uint(42)).Return(reservationStartingIn(5*time.Minute), nil)
+	resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithCheckInCode("ROOM-A"), nil)
+
+	_, err := service.CheckIn(context.Background(), 42, 7, "ROOM-B")
+
+	assert.EqualError(t, err, "invalid check-in code")
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestCheckInService_CheckIn_TooEarly(t *testing.T) {
+	repo := new(MockReservationRepository)
+	resourceRepo := new(MockResourceRepository)
+	service := services.NewCheckInService(repo, resourceRepo, fakeUnitOfWork{Reservations: repo}, checkInSettings)
+
+	repo.On("FindByID", mock.Anything, uint(42)).Return(reservationStartingIn(2*time.Hour), nil)
+	resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithCheckInCode(""), nil)
+
+	_, err := service.CheckIn(context.Background(), 42, 7, "")
+
+	assert.ErrorContains(t, err, "check-in opens at")
+	repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestCheckInService_CheckIn_RequiresConfirmedReservation(t *testing.T) {
+	repo := new(MockReservationRepository)
+	resourceRepo := new(MockResourceRepository)
+	service := services.NewCheckInService(repo, resourceRepo, fakeUnitOfWork{Reservations: repo}, checkInSettings)
+
+	cancelled := reservationStartingIn(5 * time.Minute)
+	cancelled.Status = models.ReservationCancelled
+	repo.On("FindByID", mock.Anything, uint(42)).Return(cancelled, nil)
+
+	_, err := service.CheckIn(context.Background(), 42, 7, "")
+
+	var serviceErr *services.Error
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, services.ErrConflict, serviceErr.Err)
+	assert.Equal(t, services.CodeReservationNotConfirmed, serviceErr.Code)
+	repo.AssertNotCalled(t, "MarkCheckedIn", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCheckInService_CheckIn_LosesRaceWithNoShowRelease(t *testing.T) {
+	repo := new(MockReservationRepository)
+	resourceRepo := new(MockResourceRepository)
+	service := services.NewCheckInService(repo, resourceRepo, fakeUnitOfWork{Reservations: repo}, checkInSettings)
+
+	released := reservationStartingIn(-10 * time.Minute)
+	released.Status = models.ReservationNoShow
+	repo.On("FindByID", mock.Anything, uint(42)).Return(reservationStartingIn(-10*time.Minute), nil).Once()
+	repo.On("FindByID", mock.Anything, uint(42)).Return(released, nil)
+	resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithCheckInCode(""), nil)
+	repo.On("MarkCheckedIn", mock.Anything, mock.Anything, mock.Anything).Return(false, nil)
+
+	_, err := service.CheckIn(context.Background(), 42, 7, "")
+
+	var serviceErr *services.Error
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, services.ErrConflict, serviceErr.Err)
+	assert.Equal(t, services.CodeReservationNoShow, serviceErr.Code)
+	repo.AssertNotCalled(t, "CreateOutboxEvent", mock.Anything, mock.Anything)
+}
+
+func TestCheckInService_ReleaseNoShows(t *testing.T) {
+	repo := new(MockReservationRepository)
+	resourceRepo := new(MockResourceRepository)
+	userRepo := new(MockUserRepository)
+	service := services.NewCheckInService(repo, resourceRepo, fakeUnitOfWork{Reservations: repo, Users: userRepo}, checkInSettings)
+
+	late := *reservationStartingIn(-30 * time.Minute)
+	raced := *reservationStartingIn(-20 * time.Minute)
+	raced.ID = 43
+
+	repo.On("FindUncheckedStartedBefore", mock.Anything, mock.Anything, mock.Anything).
+		Return([]models.Reservation{late, raced}, nil)
+	repo.On("MarkNoShow", mock.Anything, mock.MatchedBy(func(r *models.Reservation) bool { return r.ID == 42 })).Return(true, nil)
+	repo.On("MarkNoShow", mock.Anything, mock.MatchedBy(func(r *models.Reservation) bool { return r.ID == 43 })).Return(false, nil)
+	repo.On("CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(e *models.OutboxEvent) bool {
+		return e.Type == models.EventReservationNoShow && strings.Contains(e.Payload, `"ID":42`)
+	})).Return(nil).Once()
+	repo.On("AppendAudit", mock.Anything, mock.MatchedBy(func(e *models.AuditEntry) bool {
+		return e.Action == models.EventReservationNoShow && e.TargetID == 42 && e.ActorID == nil
+	})).Return(nil).Once()
+	userRepo.On("IncrementNoShows", mock.Anything, uint(7)).Return(nil).Once()
+
+	released, err := service.ReleaseNoShows(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, released)
+	repo.AssertExpectations(t)
+	userRepo.AssertExpectations(t)
+}
@@ -0,0 +1,124 @@
+package tests
+
+import (
+	"booking-api/config"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const strongSecret = "b8f3c1e07a9d4f62a5e1c0d7f4b2a9e3"
+
+// writeConfigFile writes a YAML config file and returns its path.
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestConfig_DefaultsGenerateDevelopmentSecret(t *testing.T) {
+	t.Setenv("JWT_SECRET", "")
+
+	cfg, args, err := config.Load([]string{"migrate", "status"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"migrate", "status"}, args)
+	assert.Equal(t, config.EnvDevelopment, cfg.Env)
+	assert.Equal(t, 3000, cfg.Server.Port)
+	assert.Len(t, cfg.Auth.JWTSecret, 64)
+	assert.NotEmpty(t, cfg.Warnings())
+}
+
+func TestConfig_FlagsOverrideEnvOverrideFile(t *testing.T) {
+	file := writeConfigFile(t, `
+server:
+  port: 4000
+auth:
+  token_ttl_hours: 2
+logging:
+  level: debug
+notifications:
+  notifier: file
+  mail_dir: /tmp/mail
+`)
+	t.Setenv("JWT_SECRET", strongSecret)
+	t.Setenv("PORT", "5000")
+	t.Setenv("TOKEN_TTL_HOURS", "8")
+
+	cfg, _, err := config.Load([]string{"-config", file})
+	require.NoError(t, err)
+	assert.Equal(t, 5000, cfg.Server.Port, "the environment overrides the file")
+	assert.Equal(t, "debug", cfg.Logging.Level)
+	assert.Equal(t, "file", cfg.Notifications.Notifier)
+	assert.Equal(t, 8, cfg.Auth.TokenTTLHours)
+	assert.Empty(t, cfg.Warnings())
+
+	cfg, args, err := config.Load([]string{"-config", file, "-port", "6000", "serve"})
+	require.NoError(t, err)
+	assert.Equal(t, 6000, cfg.Server.Port, "flags override the environment")
+	assert.Equal(t, []string{"serve"}, args)
+}
+
+func TestConfig_ReportsEveryInvalidSetting(t *testing.T) {
+	t.Setenv("JWT_SECRET", strongSecret)
+	t.Setenv("QUOTA_MAX_ACTIVE", "many")
+	_, _, err := config.Load(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "QUOTA_MAX_ACTIVE")
+
+	cfg := config.Defaults()
+	cfg.Auth.JWTSecret = strongSecret
+	cfg.Server.Port = 70000
+	cfg.Notifications.Notifier = "fax"
+	cfg.Notifications.DefaultLocale = "fr"
+	cfg.Jobs.WebhookIntervalSeconds = 0
+
+	var validationErr *config.ValidationError
+	require.True(t, errors.As(cfg.Validate(), &validationErr))
+	assert.Len(t, validationErr.Problems, 4)
+	assert.Contains(t, validationErr.Error(), "server.port (PORT)")
+	assert.Contains(t, validationErr.Error(), "notifications.notifier (NOTIFIER)")
+}
+
+func TestConfig_ProductionRefusesWeakSecrets(t *testing.T) {
+	for _, secret := range []string{"", "defaultsecret", "short-but-unique", "SUPERSECRETKEY"} {
+		t.Run(secret, func(t *testing.T) {
+			t.Setenv("JWT_SECRET", secret)
+			_, _, err := config.Load([]string{"-env", "production"})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "JWT_SECRET")
+		})
+	}
+
+	t.Setenv("JWT_SECRET", strongSecret)
+	cfg, _, err := config.Load([]string{"-env", "production"})
+	require.NoError(t, err)
+	assert.Equal(t, config.EnvProduction, cfg.Env)
+}
+
+func TestConfig_RejectsUnknownFileKeys(t *testing.T) {
+	t.Setenv("JWT_SECRET", strongSecret)
+	file := writeConfigFile(t, "server:\n  prot: 4000\n")
+
+	_, _, err := config.Load([]string{"-config", file})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "prot")
+}
+
+func TestConfig_MemoryStorageIsForDemosOnly(t *testing.T) {
+	t.Setenv("JWT_SECRET", strongSecret)
+	t.Setenv("DB_URL", "")
+
+	cfg, _, err := config.Load([]string{"-storage", "memory"})
+	require.NoError(t, err, "no database URL is needed")
+	assert.Equal(t, config.StorageMemory, cfg.Database.Storage)
+	assert.NotEmpty(t, cfg.Warnings())
+
+	_, _, err = config.Load([]string{"-storage", "memory", "-env", "production"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "STORAGE")
+}
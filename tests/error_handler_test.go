@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"booking-api/controllers"
+	"booking-api/middlewares"
+	"booking-api/repositories"
+	"booking-api/services"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHandler_MapsDomainErrorsToProblems(t *testing.T) {
+	overlap := &services.PolicyViolationError{Violations: []services.Violation{
+		{Code: services.CodeOverlap, Message: "reservation time overlaps with an existing reservation"},
+	}}
+
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		field  string
+	}{
+		{"overlap is a conflict", overlap, fiber.StatusConflict, services.CodeOverlap, ""},
+		{"missing entity", &repositories.NotFoundError{Entity: "external calendar"}, fiber.StatusNotFound, "external_calendar_not_found", ""},
+		{"invalid field", &services.Error{Err: services.ErrInvalid, Code: services.CodeRequired, Field: "name", Message: "name is required"}, fiber.StatusUnprocessableEntity, services.CodeRequired, "name"},
+		{"state conflict", &services.Error{Err: services.ErrConflict, Code: services.CodeCheckInClosed, Message: "check-in window has closed"}, fiber.StatusConflict, services.CodeCheckInClosed, ""},
+		{"framework error", fiber.NewError(fiber.StatusUnauthorized, "Missing or invalid token"), fiber.StatusUnauthorized, "unauthorized", ""},
+		{"unexpected error", errors.New("database is locked"), fiber.StatusInternalServerError, controllers.CodeInternalError, ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			app := fiber.New(fiber.Config{ErrorHandler: controllers.ErrorHandler})
+			app.Use(middlewares.RequestID())
+			app.Get("/boom", func(c *fiber.Ctx) error { return tc.err })
+
+			resp, err := app.Test(httptest.NewRequest("GET", "/boom", nil))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			var problem controllers.Problem
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+
+			assert.Equal(t, tc.status, resp.StatusCode)
+			assert.Equal(t, controllers.MIMEProblemJSON, resp.Header.Get(fiber.HeaderContentType))
+			assert.Equal(t, tc.status, problem.Status)
+			assert.Equal(t, tc.code, problem.Code)
+			assert.Equal(t, "/boom", problem.Instance)
+			assert.Equal(t, resp.Header.Get(fiber.HeaderXRequestID), problem.RequestID)
+			assert.NotEmpty(t, problem.RequestID)
+			if tc.field != "" {
+				require.Len(t, problem.Violations, 1)
+				assert.Equal(t, tc.field, problem.Violations[0].Field)
+			}
+		})
+	}
+}
+
+func TestServiceErrors_MatchSentinels(t *testing.T) {
+	overlap := &services.PolicyViolationError{Violations: []services.Violation{{Code: services.CodeOverlap}}}
+	assert.True(t, errors.Is(overlap, services.ErrOverlap))
+	assert.False(t, errors.Is(overlap, services.ErrOutsideHours))
+
+	notFound := error(&repositories.NotFoundError{Entity: "reservation"})
+	assert.True(t, errors.Is(notFound, services.ErrNotFound))
+	assert.EqualError(t, notFound, "reservation not found")
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/services"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tomorrowCalendar() string {
+	day := time.Now().AddDate(0, 0, 1).Format("20060102")
+	return "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:maintenance@example.com\r\n" +
+		"DTSTART:" + day + "T100000\r\n" +
+		"DTEND:" + day + "T110000\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+}
+
+// newExternalCalendarService returns the service on a migrated database with
+// a resource 1.
+func newExternalCalendarService(t *testing.T, settings services.ExternalCalendarSettings) (services.ExternalCalendarService, repositories.ExternalCalendarRepository) {
+	db := migratedDB(t)
+	resourceRepo := repositories.NewResourceRepository(db)
+	require.NoError(t, resourceRepo.Create(context.Background(), &models.Resource{Name: "Room A"}))
+	repo := repositories.NewExternalCalendarRepository(db)
+	return services.NewExternalCalendarService(repo, resourceRepo, settings), repo
+}
+
+func serviceErrorCode(t *testing.T, err error) string {
+	var serviceErr *services.Error
+	require.True(t, errors.As(err, &serviceErr), "got %v", err)
+	return serviceErr.Code
+}
+
+func TestExternalCalendarService_FilesOnlyFromCalendarDirectory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "room.ics"), []byte(tomorrowCalendar()), 0o600))
+	secret := filepath.Join(t.TempDir(), "secret.txt")
+	require.NoError(t, os.WriteFile(secret, []byte("password=hunter2\n"), 0o600))
+	require.NoError(t, os.Symlink(secret, filepath.Join(dir, "link.ics")))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.ics"), []byte("token hunter2\n"), 0o600))
+
+	service, _ := newExternalCalendarService(t, services.ExternalCalendarSettings{})
+	err := service.AddCalendar(context.Background(), &models.ExternalCalendar{ResourceID: 1, FilePath: "room.ics"})
+	assert.Equal(t, services.CodeCalendarFilesDisabled, serviceErrorCode(t, err), "no directory, no files")
+
+	service, repo := newExternalCalendarService(t, services.ExternalCalendarSettings{FileDir: dir})
+	for _, path := range []string{secret, "../secret.txt"} {
+		calendar := &models.ExternalCalendar{ResourceID: 1, FilePath: path}
+		err := service.AddCalendar(context.Background(), calendar)
+		assert.Equal(t, services.CodeInvalidValue, serviceErrorCode(t, err), path)
+		assert.Zero(t, calendar.ID, "refused sources are not saved")
+	}
+
+	calendar := &models.ExternalCalendar{ResourceID: 1, FilePath: "room.ics"}
+	require.NoError(t, service.AddCalendar(context.Background(), calendar))
+	assert.Equal(t, 1, calendar.BlockCount)
+
+	for _, path := range []string{"link.ics", "notes.ics"} {
+		calendar := &models.ExternalCalendar{ResourceID: 1, FilePath: path}
+		require.Error(t, service.AddCalendar(context.Background(), calendar), path)
+		stored, err := repo.FindByID(context.Background(), calendar.ID)
+		require.NoError(t, err)
+		assert.NotEmpty(t, stored.LastError, path)
+		assert.NotContains(t, stored.LastError, "hunter2", "file contents are not echoed")
+	}
+}
+
+func TestExternalCalendarService_URLsOnlyReachPublicHosts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(tomorrowCalendar()))
+	}))
+	defer server.Close()
+
+	service, _ := newExternalCalendarService(t, services.ExternalCalendarSettings{})
+	err := service.AddCalendar(context.Background(), &models.ExternalCalendar{ResourceID: 1, URL: "file:///etc/passwd"})
+	assert.Equal(t, services.CodeInvalidURL, serviceErrorCode(t, err))
+
+	calendar := &models.ExternalCalendar{ResourceID: 1, URL: server.URL}
+	err = service.AddCalendar(context.Background(), calendar)
+	assert.Equal(t, services.CodeCalendarUnavailable, serviceErrorCode(t, err), "loopback addresses are refused")
+	assert.Contains(t, calendar.LastError, "private and loopback addresses are not allowed")
+
+	service, _ = newExternalCalendarService(t, services.ExternalCalendarSettings{AllowPrivateHosts: true})
+	calendar = &models.ExternalCalendar{ResourceID: 1, URL: server.URL}
+	require.NoError(t, service.AddCalendar(context.Background(), calendar))
+	assert.Equal(t, 1, calendar.BlockCount)
+}
+
+func TestExternalCalendarService_UnsupportedEventsDoNotBlockTheSync(t *testing.T) {
+	day := time.Now().AddDate(0, 0, 1).Format("20060102")
+	data := strings.Replace(tomorrowCalendar(), "END:VCALENDAR", "BEGIN:VEVENT\r\n"+
+		"UID:hourly@example.com\r\n"+
+		"DTSTART:"+day+"T090000\r\n"+
+		"RRULE:FREQ=DAILY;BYHOUR=9,17\r\n"+
+		"END:VEVENT\r\n"+
+		"END:VCALENDAR", 1)
+
+	service, _ := newExternalCalendarService(t, services.ExternalCalendarSettings{})
+	calendar, err := service.UploadCalendar(context.Background(), 1, "Mixed", data)
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, calendar.BlockCount)
+	assert.Equal(t, `events skipped: event "hourly@example.com": unsupported BYHOUR`, calendar.LastError)
+}
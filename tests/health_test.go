@@ -0,0 +1,70 @@
+package tests
+
+import (
+	"booking-api/controllers"
+	"booking-api/services"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func probe(t *testing.T, app *fiber.App, path string) (int, services.HealthReport) {
+	resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var report services.HealthReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	return resp.StatusCode, report
+}
+
+func TestHealth_ReadinessFollowsChecksAndDraining(t *testing.T) {
+	var dbErr error
+	health := services.NewHealthService(
+		services.HealthCheck{Name: "database", Check: func() error { return dbErr }},
+		services.HealthCheck{Name: "migrations", Check: func() error { return nil }},
+	)
+	controller := controllers.NewHealthController(health)
+	app := setupFiber()
+	app.Get("/healthz", controller.Liveness)
+	app.Get("/readyz", controller.Readiness)
+
+	status, report := probe(t, app, "/readyz")
+	assert.Equal(t, fiber.StatusOK, status)
+	assert.Equal(t, map[string]string{"database": services.HealthOK, "migrations": services.HealthOK}, report.Checks)
+
+	dbErr = errors.New("connection refused")
+	status, report = probe(t, app, "/readyz")
+	assert.Equal(t, fiber.StatusServiceUnavailable, status)
+	assert.Equal(t, services.HealthUnavailable, report.Status)
+	assert.Equal(t, services.HealthFailing, report.Checks["database"], "errors are not exposed")
+
+	dbErr = nil
+	health.Drain()
+	status, report = probe(t, app, "/readyz")
+	assert.Equal(t, fiber.StatusServiceUnavailable, status)
+	assert.Equal(t, services.HealthDraining, report.Status)
+
+	status, _ = probe(t, app, "/healthz")
+	assert.Equal(t, fiber.StatusOK, status, "a draining instance is still alive")
+}
+
+func TestRoutes_ProbesAreNotVersioned(t *testing.T) {
+	app := newRoutedApp()
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/readyz", nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Deprecation"))
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/v1/healthz", nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
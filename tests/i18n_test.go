@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"booking-api/controllers"
+	"booking-api/i18n"
+	"booking-api/middlewares"
+	"booking-api/services"
+	"encoding/json"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var placeholder = regexp.MustCompile(`\{[a-z_]+\}`)
+
+func TestI18n_CatalogsHaveTheSameMessages(t *testing.T) {
+	english := i18n.Keys(i18n.Fallback)
+	require.NotEmpty(t, english)
+
+	for _, locale := range i18n.Locales() {
+		assert.Equal(t, english, i18n.Keys(locale), "keys of %s", locale)
+		for _, key := range english {
+			want, _ := i18n.Message(i18n.Fallback, nil, key)
+			got, _ := i18n.Message(locale, nil, key)
+			assert.Equal(t, placeholders(want), placeholders(got), "placeholders of %s in %s", key, locale)
+		}
+	}
+}
+
+func placeholders(message string) []string {
+	found := placeholder.FindAllString(message, -1)
+	sort.Strings(found)
+	return found
+}
+
+func TestI18n_Negotiate(t *testing.T) {
+	cases := map[string]string{
+		"":                        "en",
+		"es":                      "es",
+		"es-AR,es;q=0.9,en;q=0.8": "es",
+		"fr-FR,fr;q=0.9,es;q=0.5": "es",
+		"fr, en;q=0.3, es;q=0.7":  "es",
+		"de":                      "en",
+		"es;q=0, en":              "en",
+		"*":                       "en",
+		"EN-gb":                   "en",
+		"es;q=garbage, en;q=0.1":  "en",
+	}
+	for header, want := range cases {
+		assert.Equal(t, want, i18n.Negotiate(header), "Accept-Language: %q", header)
+	}
+}
+
+func TestI18n_FormatsDurationsAndDates(t *testing.T) {
+	date := time.Date(2025, time.May, 23, 0, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "Friday, May 23, 2025", i18n.FormatDate("en", date))
+	assert.Equal(t, "viernes, 23 de mayo de 2025", i18n.FormatDate("es", date))
+	assert.Equal(t, "1 hora", i18n.FormatDuration("es", time.Hour))
+	assert.Equal(t, "90 minutes", i18n.FormatDuration("en", 90*time.Minute))
+
+	message, ok := i18n.Describe("es", services.CodeWeekdayNotAllowed, "date", i18n.Params{"weekdays": []time.Weekday{time.Monday, time.Wednesday}})
+	assert.True(t, ok)
+	assert.Equal(t, "solo se permiten reservas los días lunes, miércoles", message)
+}
+
+func TestErrorHandler_LocalisesProblems(t *testing.T) {
+	policy, err := services.NewPolicy(services.RuleMinDuration, "60")
+	require.NoError(t, err)
+	start := time.Date(2025, time.May, 23, 10, 0, 0, 0, time.UTC)
+	violation, err := policy.Evaluate(&services.BookingRequest{Start: start, End: start.Add(30 * time.Minute), Now: start})
+	require.NoError(t, err)
+	require.NotNil(t, violation)
+	assert.Equal(t, "minimum reservation duration is 1 hour", violation.Message)
+
+	app := fiber.New(fiber.Config{ErrorHandler: controllers.ErrorHandler})
+	app.Use(middlewares.Locale())
+	app.Get("/policy", func(c *fiber.Ctx) error {
+		return &services.PolicyViolationError{Violations: []services.Violation{*violation}}
+	})
+
+	cases := []struct {
+		acceptLanguage string
+		locale         string
+		title          string
+		message        string
+	}{
+		{"es-ES,es;q=0.9", "es", "Entidad no procesable", "la duración mínima de una reserva es de 1 hora"},
+		{"fr", "en", "Unprocessable Entity", "minimum reservation duration is 1 hour"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", "/policy", nil)
+		req.Header.Set(fiber.HeaderAcceptLanguage, tc.acceptLanguage)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		var problem controllers.Problem
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+		resp.Body.Close()
+
+		assert.Equal(t, tc.locale, resp.Header.Get(fiber.HeaderContentLanguage))
+		assert.Equal(t, services.CodeDurationTooShort, problem.Violations[0].Code, "codes are not translated")
+		assert.Equal(t, tc.title, problem.Title)
+		assert.Equal(t, tc.message, problem.Detail)
+		assert.Equal(t, tc.message, problem.Violations[0].Message)
+	}
+}
@@ -0,0 +1,186 @@
+package tests
+
+import (
+	"booking-api/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseICalBusyTimes_WeeklyWithExdate(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"UID:maintenance@example.com\r\n" +
+		"SUMMARY:Mantenimiento\r\n" +
+		"DTSTART;TZID=UTC:20250505T100000\r\n" +
+		"DTEND;TZID=UTC:20250505T113000\r\n" +
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4\r\n" +
+		"EXDATE;TZID=UTC:20250507T100000\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	occurrences, skipped, err := utils.ParseICalBusyTimes(data, from, to, time.UTC)
+
+	assert.NoError(t, err)
+	assert.Empty(t, skipped)
+	if assert.Len(t, occurrences, 3) {
+		assert.Equal(t, time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC), occurrences[0].Start.UTC())
+		assert.Equal(t, time.Date(2025, 5, 12, 10, 0, 0, 0, time.UTC), occurrences[1].Start.UTC())
+		assert.Equal(t, time.Date(2025, 5, 14, 11, 30, 0, 0, time.UTC), occurrences[2].End.UTC())
+		assert.Equal(t, "Mantenimiento", occurrences[0].Summary)
+	}
+}
+
+func TestParseICalBusyTimes_AllDayAndSkippedEvents(t *testing.T) {
+	data := "BEGIN:VCALENDAR\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:holiday\n" +
+		"DTSTART;VALUE=DATE:20250525\n" +
+		"END:VEVENT\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:free\n" +
+		"DTSTART:20250526T090000Z\n" +
+		"DURATION:PT1H\n" +
+		"TRANSP:TRANSPARENT\n" +
+		"END:VEVENT\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:cancelled\n" +
+		"DTSTART:20250526T090000Z\n" +
+		"DURATION:PT1H\n" +
+		"STATUS:CANCELLED\n" +
+		"END:VEVENT\n" +
+		"END:VCALENDAR\n"
+
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	occurrences, skipped, err := utils.ParseICalBusyTimes(data, from, to, time.UTC)
+
+	assert.NoError(t, err)
+	assert.Empty(t, skipped)
+	if assert.Len(t, occurrences, 1) {
+		assert.Equal(t, "holiday", occurrences[0].UID)
+		assert.Equal(t, 24*time.Hour, occurrences[0].End.Sub(occurrences[0].Start))
+	}
+}
+
+func TestParseICalBusyTimes_RejectsNonCalendar(t *testing.T) {
+	_, _, err := utils.ParseICalBusyTimes("hello: world", time.Now(), time.Now().Add(time.Hour), time.UTC)
+
+	assert.Error(t, err)
+}
+
+func recurringCalendar(rrule string) string {
+	return "BEGIN:VCALENDAR\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:recurring\n" +
+		"DTSTART:20250505T100000Z\n" +
+		"DURATION:PT1H\n" +
+		"RRULE:" + rrule + "\n" +
+		"END:VEVENT\n" +
+		"END:VCALENDAR\n"
+}
+
+func TestParseICalBusyTimes_ByRuleParts(t *testing.T) {
+	tests := []struct {
+		rrule string
+		days  []string
+	}{
+		{"FREQ=MONTHLY;BYDAY=1MO;COUNT=3", []string{"2025-05-05", "2025-06-02", "2025-07-07"}},
+		{"FREQ=MONTHLY;BYDAY=-1FR;COUNT=2", []string{"2025-05-30", "2025-06-27"}},
+		{"FREQ=MONTHLY;BYMONTHDAY=15,-1;COUNT=3", []string{"2025-05-15", "2025-05-31", "2025-06-15"}},
+		{"FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1;COUNT=3", []string{"2025-05-30", "2025-06-30", "2025-07-31"}},
+		{"FREQ=DAILY;BYDAY=SA,SU;COUNT=3", []string{"2025-05-10", "2025-05-11", "2025-05-17"}},
+		{"FREQ=WEEKLY;INTERVAL=2;BYDAY=FR,MO;COUNT=3", []string{"2025-05-05", "2025-05-09", "2025-05-19"}},
+		{"FREQ=YEARLY;BYMONTH=6,9;BYDAY=1MO;COUNT=2", []string{"2025-06-02", "2025-09-01"}},
+	}
+
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		occurrences, skipped, err := utils.ParseICalBusyTimes(recurringCalendar(tt.rrule), from, to, time.UTC)
+
+		require.NoError(t, err)
+		assert.Empty(t, skipped, tt.rrule)
+		var days []string
+		for _, occurrence := range occurrences {
+			days = append(days, occurrence.Start.Format("2006-01-02"))
+		}
+		assert.Equal(t, tt.days, days, tt.rrule)
+	}
+}
+
+func TestParseICalBusyTimes_SkipsOnlyUnsupportedEvents(t *testing.T) {
+	data := "BEGIN:VCALENDAR\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:hourly\n" +
+		"DTSTART:20250505T100000Z\n" +
+		"RRULE:FREQ=DAILY;BYHOUR=9,17\n" +
+		"END:VEVENT\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:weekly-ordinal\n" +
+		"DTSTART:20250505T100000Z\n" +
+		"RRULE:FREQ=WEEKLY;BYDAY=1MO\n" +
+		"END:VEVENT\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:meeting\n" +
+		"DTSTART:20250506T100000Z\n" +
+		"DURATION:PT1H\n" +
+		"END:VEVENT\n" +
+		"END:VCALENDAR\n"
+
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	occurrences, skipped, err := utils.ParseICalBusyTimes(data, from, to, time.UTC)
+
+	require.NoError(t, err)
+	if assert.Len(t, occurrences, 1) {
+		assert.Equal(t, "meeting", occurrences[0].UID)
+	}
+	if assert.Len(t, skipped, 2) {
+		assert.EqualError(t, skipped[0], `event "hourly": unsupported BYHOUR`)
+		assert.EqualError(t, skipped[1], `event "weekly-ordinal": unsupported BYDAY "1MO" with FREQ=WEEKLY`)
+	}
+}
+
+func TestParseICalBusyTimes_AppliesRecurrenceOverrides(t *testing.T) {
+	data := "BEGIN:VCALENDAR\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:standup\n" +
+		"DTSTART:20250505T100000Z\n" +
+		"DURATION:PT30M\n" +
+		"RRULE:FREQ=WEEKLY;COUNT=4\n" +
+		"END:VEVENT\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:standup\n" +
+		"RECURRENCE-ID:20250512T100000Z\n" +
+		"DTSTART:20250513T150000Z\n" +
+		"DURATION:PT30M\n" +
+		"END:VEVENT\n" +
+		"BEGIN:VEVENT\n" +
+		"UID:standup\n" +
+		"RECURRENCE-ID:20250519T100000Z\n" +
+		"DTSTART:20250519T100000Z\n" +
+		"STATUS:CANCELLED\n" +
+		"END:VEVENT\n" +
+		"END:VCALENDAR\n"
+
+	from := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	occurrences, skipped, err := utils.ParseICalBusyTimes(data, from, to, time.UTC)
+
+	require.NoError(t, err)
+	assert.Empty(t, skipped)
+	var starts []time.Time
+	for _, occurrence := range occurrences {
+		starts = append(starts, occurrence.Start.UTC())
+	}
+	assert.Equal(t, []time.Time{
+		time.Date(2025, 5, 5, 10, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 13, 15, 0, 0, 0, time.UTC),
+		time.Date(2025, 5, 26, 10, 0, 0, 0, time.UTC),
+	}, starts, "the moved instance blocks its new time only and the cancelled one nothing")
+}
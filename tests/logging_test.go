@@ -0,0 +1,131 @@
+package tests
+
+import (
+	"booking-api/logging"
+	"booking-api/middlewares"
+	"booking-api/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLogs makes the default logger write JSON records to the returned
+// buffer for the rest of the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, logging.FormatJSON, "debug")
+	require.NoError(t, err)
+	previous := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(previous) })
+	return &buf
+}
+
+// logRecords decodes the JSON records written to buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestLogging_RedactsSecrets(t *testing.T) {
+	buf := captureLogs(t)
+	user := models.User{Email: "ana@example.com", Password: "$2a$10$hash", Role: models.RoleUser}
+	slog.Info("intento de acceso",
+		"password", "hunter2",
+		"feed_token", "feed-secret",
+		"error", errors.New("rejected header Authorization: Bearer abc.def.ghi"),
+		"user", user,
+	)
+
+	out := buf.String()
+	for _, secret := range []string{"hunter2", "feed-secret", "abc.def.ghi", "$2a$10$hash"} {
+		assert.NotContains(t, out, secret)
+	}
+	record := logRecords(t, buf)[0]
+	assert.Equal(t, logging.Redacted, record["password"])
+	assert.Equal(t, "ana@example.com", record["user"].(map[string]any)["email"])
+}
+
+func TestLogging_AddsContextAttributes(t *testing.T) {
+	buf := captureLogs(t)
+	ctx := logging.With(context.Background(), slog.String("request_id", "abc"))
+	slog.InfoContext(ctx, "hola")
+	slog.Info("sin contexto")
+
+	records := logRecords(t, buf)
+	require.Len(t, records, 2)
+	assert.Equal(t, "abc", records[0]["request_id"])
+	assert.NotContains(t, records[1], "request_id")
+}
+
+func TestLogging_RejectsUnknownSettings(t *testing.T) {
+	_, err := logging.New(&bytes.Buffer{}, "xml", "info")
+	assert.Error(t, err)
+	_, err = logging.New(&bytes.Buffer{}, logging.FormatJSON, "verbose")
+	assert.Error(t, err)
+}
+
+func TestAccessLog_RecordsRequestWithUserAndRequestID(t *testing.T) {
+	buf := captureLogs(t)
+	app := setupFiber()
+	app.Use(middlewares.RequestID())
+	app.Use(middlewares.AccessLog())
+	app.Use(middlewares.Metrics())
+	app.Get("/items/:id", func(c *fiber.Ctx) error {
+		c.Locals("user", createTestToken(7))
+		slog.InfoContext(c.UserContext(), "dentro del handler")
+		return fiber.ErrConflict
+	})
+
+	req := httptest.NewRequest("GET", "/items/1?access_token=secret", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "req-123")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "req-123", resp.Header.Get(fiber.HeaderXRequestID))
+
+	records := logRecords(t, buf)
+	require.Len(t, records, 2)
+	assert.Equal(t, "req-123", records[0]["request_id"], "handler records carry the request ID")
+	access := records[1]
+	assert.Equal(t, "req-123", access["request_id"])
+	assert.Equal(t, "GET", access["method"])
+	assert.Equal(t, "/items/:id", access["route"])
+	assert.Equal(t, float64(fiber.StatusConflict), access["status"])
+	assert.Equal(t, float64(7), access["user_id"])
+	assert.Contains(t, access, "latency_ms")
+	assert.NotContains(t, buf.String(), "secret", "URLs are not logged")
+}
+
+func TestRequestID_ReplacesInvalidIDs(t *testing.T) {
+	app := setupFiber()
+	app.Use(middlewares.RequestID())
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "bad id\" injected=1")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	id := resp.Header.Get(fiber.HeaderXRequestID)
+	assert.NotEmpty(t, id)
+	assert.NotContains(t, id, "injected")
+}
@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"booking-api/metrics"
+	"booking-api/middlewares"
+	"booking-api/models"
+	"booking-api/services"
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// metricValue returns the value of the counter, or the sample count of the
+// histogram, with exactly the given labels; 0 when it was never recorded.
+func metricValue(t *testing.T, name string, labels map[string]string) float64 {
+	families, err := metrics.Registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+	next:
+		for _, m := range family.GetMetric() {
+			if len(m.GetLabel()) != len(labels) {
+				continue
+			}
+			for _, label := range m.GetLabel() {
+				if labels[label.GetName()] != label.GetValue() {
+					continue next
+				}
+			}
+			if m.GetHistogram() != nil {
+				return float64(m.GetHistogram().GetSampleCount())
+			}
+			return m.GetCounter().GetValue()
+		}
+	}
+	return 0
+}
+
+func TestMetrics_RecordsRequestsByRouteTemplate(t *testing.T) {
+	app := setupFiber()
+	app.Use(middlewares.Metrics())
+	app.Get("/items/:id", func(c *fiber.Ctx) error { return fiber.ErrConflict })
+
+	conflict := map[string]string{"method": "GET", "route": "/items/:id", "status": "409"}
+	unmatched := map[string]string{"method": "GET", "route": "unmatched", "status": "404"}
+	conflicts := metricValue(t, "booking_http_requests_total", conflict)
+	misses := metricValue(t, "booking_http_requests_total", unmatched)
+
+	for _, path := range []string{"/items/1", "/items/2", "/nothing/here"} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	assert.Equal(t, conflicts+2, metricValue(t, "booking_http_requests_total", conflict), "errors are counted with the status they are answered with")
+	assert.Equal(t, misses+1, metricValue(t, "booking_http_requests_total", unmatched))
+	assert.Equal(t, conflicts+2, metricValue(t, "booking_http_request_duration_seconds", conflict))
+
+	resp, err := newRoutedApp().Test(httptest.NewRequest("GET", "/metrics", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "booking_http_requests_total")
+}
+
+func TestMetrics_CountsBookingOutcomes(t *testing.T) {
+	f := newReservationServiceFixture()
+	f.resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithBuffers(), nil)
+	f.ruleRepo.On("FindByResource", mock.Anything, uint(1)).Return([]models.BookingRule{
+		{Kind: services.RuleMinDuration, Value: "60"},
+	}, nil)
+	f.repo.On("FindOverlapping", mock.Anything, uint(1), "2025-05-23", "09:45", "11:15").Return([]models.Reservation{}, nil)
+	f.repo.On("FindOverlapping", mock.Anything, uint(1), "2025-05-23", "09:45", "10:45").Return([]models.Reservation{{ResourceID: 1}}, nil)
+	f.repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	overlap := map[string]string{"operation": metrics.OperationCreate, "reason": services.CodeOverlap}
+	tooShort := map[string]string{"operation": metrics.OperationCreate, "reason": services.CodeDurationTooShort}
+	created := metricValue(t, "booking_reservations_created_total", nil)
+	overlaps := metricValue(t, "booking_reservations_rejected_total", overlap)
+	short := metricValue(t, "booking_reservations_rejected_total", tooShort)
+
+	require.NoError(t, f.service.CreateReservation(context.Background(), &models.Reservation{ResourceID: 1, Date: "2025-05-23", StartTime: "10:00", EndTime: "11:00"}))
+	require.Error(t, f.service.CreateReservation(context.Background(), &models.Reservation{ResourceID: 1, Date: "2025-05-23", StartTime: "10:00", EndTime: "10:30"}))
+
+	assert.Equal(t, created+1, metricValue(t, "booking_reservations_created_total", nil))
+	assert.Equal(t, overlaps+1, metricValue(t, "booking_reservations_rejected_total", overlap))
+	assert.Equal(t, short+1, metricValue(t, "booking_reservations_rejected_total", tooShort))
+}
@@ -0,0 +1,176 @@
+package tests
+
+import (
+	"booking-api/database"
+	"booking-api/models"
+	"booking-api/repositories"
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+)
+
+var migratedModels = []interface{}{
+	&models.User{}, &models.Resource{}, &models.BookingRule{}, &models.BookingGroup{}, &models.Reservation{},
+	&models.Quota{}, &models.ExternalCalendar{}, &models.ExternalBlock{}, &models.WebhookSubscription{},
+	&models.OutboxEvent{}, &models.WebhookDelivery{}, &models.NotificationPreferences{}, &models.AuditEntry{},
+}
+
+func openMigrationDB(t *testing.T, file string) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(file+"?_busy_timeout=5000"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	return db
+}
+
+func TestMigrations_CreateEveryModelColumn(t *testing.T) {
+	db := openMigrationDB(t, filepath.Join(t.TempDir(), "booking.db"))
+	migrator, err := database.NewMigrator(db)
+	require.NoError(t, err)
+
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	require.NotEmpty(t, applied)
+
+	for _, model := range migratedModels {
+		s, err := schema.Parse(model, &sync.Map{}, db.NamingStrategy)
+		require.NoError(t, err)
+		require.True(t, db.Migrator().HasTable(s.Table), "table %s", s.Table)
+		for _, field := range s.Fields {
+			if field.DBName != "" {
+				assert.True(t, db.Migrator().HasColumn(model, field.DBName), "column %s.%s", s.Table, field.DBName)
+			}
+		}
+	}
+}
+
+func TestMigrations_UpDownAndStatus(t *testing.T) {
+	db := openMigrationDB(t, filepath.Join(t.TempDir(), "booking.db"))
+	migrator, err := database.NewMigrator(db)
+	require.NoError(t, err)
+
+	pending, err := migrator.Pending()
+	require.NoError(t, err)
+	all := len(pending)
+
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	assert.Len(t, applied, all)
+
+	applied, err = migrator.Up()
+	require.NoError(t, err)
+	assert.Empty(t, applied, "a second up has nothing to do")
+
+	status, err := migrator.Status()
+	require.NoError(t, err)
+	for _, s := range status {
+		assert.NotNil(t, s.AppliedAt, "%04d_%s", s.Version, s.Name)
+	}
+
+	reverted, err := migrator.Down(all + 1)
+	require.NoError(t, err)
+	assert.Len(t, reverted, all, "down stops when nothing is applied")
+	assert.False(t, db.Migrator().HasTable("reservations"))
+
+	pending, err = migrator.Pending()
+	require.NoError(t, err)
+	assert.Len(t, pending, all)
+}
+
+// baselineSchema is the schema AutoMigrate created before the migrations
+// existed, as found in the repository's booking.db.
+const baselineSchema = "CREATE TABLE `users` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`name` text,`email` text,`password` text);\n" +
+	"CREATE UNIQUE INDEX `idx_users_email` ON `users`(`email`);\n" +
+	"CREATE INDEX `idx_users_deleted_at` ON `users`(`deleted_at`);\n" +
+	"CREATE TABLE `reservations` (`id` integer PRIMARY KEY AUTOINCREMENT,`created_at` datetime,`updated_at` datetime,`deleted_at` datetime,`user_id` integer,`date` text,`start_time` text,`end_time` text,CONSTRAINT `fk_users_reservations` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`));\n" +
+	"CREATE INDEX `idx_reservations_deleted_at` ON `reservations`(`deleted_at`);\n" +
+	"INSERT INTO users (name, email, password) VALUES ('Ana', 'ana@example.com', 'hash');\n" +
+	"INSERT INTO reservations (user_id, date, start_time, end_time) VALUES (1, '2030-01-02', '10:00', '11:00');\n"
+
+func TestMigrations_UpgradeBaselineDatabase(t *testing.T) {
+	db := openMigrationDB(t, filepath.Join(t.TempDir(), "booking.db"))
+	for _, statement := range strings.Split(strings.TrimSpace(baselineSchema), ";\n") {
+		require.NoError(t, db.Exec(statement).Error)
+	}
+
+	migrator, err := database.NewMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+
+	for _, model := range migratedModels {
+		s, err := schema.Parse(model, &sync.Map{}, db.NamingStrategy)
+		require.NoError(t, err)
+		for _, field := range s.Fields {
+			if field.DBName != "" {
+				assert.True(t, db.Migrator().HasColumn(model, field.DBName), "column %s.%s", s.Table, field.DBName)
+			}
+		}
+	}
+
+	var user models.User
+	require.NoError(t, db.First(&user, 1).Error)
+	assert.Equal(t, "ana@example.com", user.Email, "existing rows are kept")
+	assert.Equal(t, models.RoleUser, user.Role, "new columns take their default")
+
+	var reservation models.Reservation
+	require.NoError(t, db.First(&reservation, 1).Error)
+	assert.Equal(t, "10:00", reservation.StartTime)
+	assert.Equal(t, models.ReservationConfirmed, reservation.Status)
+	assert.Nil(t, reservation.GroupID)
+
+	busy, err := repositories.NewReservationRepository(db).FindOverlapping(context.Background(), reservation.ResourceID, "2030-01-02", "10:30", "11:30")
+	require.NoError(t, err)
+	assert.Equal(t, []uint{reservation.ID}, reservationIDs(busy), "legacy reservations still hold their slot")
+}
+
+func TestMigrations_ConcurrentUpAppliesOnce(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "booking.db")
+
+	var wg sync.WaitGroup
+	counts := make([]int, 3)
+	errs := make([]error, 3)
+	for i := range counts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			migrator, err := database.NewMigrator(openMigrationDB(t, file))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			applied, err := migrator.Up()
+			counts[i], errs[i] = len(applied), err
+		}(i)
+	}
+	wg.Wait()
+
+	migrations, err := database.LoadMigrations("sqlite")
+	require.NoError(t, err)
+	total := 0
+	for i := range counts {
+		require.NoError(t, errs[i])
+		total += counts[i]
+	}
+	assert.Equal(t, len(migrations), total, "every migration is applied by exactly one migrator")
+}
+
+func TestMigrations_DialectsHaveTheSameVersions(t *testing.T) {
+	sqliteMigrations, err := database.LoadMigrations("sqlite")
+	require.NoError(t, err)
+	postgresMigrations, err := database.LoadMigrations("postgres")
+	require.NoError(t, err)
+
+	require.Equal(t, len(sqliteMigrations), len(postgresMigrations))
+	for i := range sqliteMigrations {
+		assert.Equal(t, sqliteMigrations[i].Version, postgresMigrations[i].Version)
+		assert.Equal(t, sqliteMigrations[i].Name, postgresMigrations[i].Name)
+	}
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"booking-api/models"
+	"booking-api/notifications"
+	"booking-api/services"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockNotificationRepository struct {
+	mock.Mock
+}
+
+func (m *MockNotificationRepository) FindPreferences(ctx context.Context, userID uint) (*models.NotificationPreferences, error) {
+	args := m.Called(ctx, userID)
+	preferences, _ := args.Get(0).(*models.NotificationPreferences)
+	return preferences, args.Error(1)
+}
+
+func (m *MockNotificationRepository) SavePreferences(ctx context.Context, preferences *models.NotificationPreferences) error {
+	return m.Called(ctx, preferences).Error(0)
+}
+
+func (m *MockNotificationRepository) FindUnnotifiedEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]models.OutboxEvent), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkEventNotified(ctx context.Context, event *models.OutboxEvent) error {
+	return m.Called(ctx, event).Error(0)
+}
+
+func (m *MockNotificationRepository) FindReminderCandidates(ctx context.Context, fromDate, toDate string) ([]models.Reservation, error) {
+	args := m.Called(ctx, fromDate, toDate)
+	return args.Get(0).([]models.Reservation), args.Error(1)
+}
+
+func (m *MockNotificationRepository) MarkReminded(ctx context.Context, reservation *models.Reservation) (bool, error) {
+	args := m.Called(ctx, reservation)
+	return args.Bool(0), args.Error(1)
+}
+
+// recordingNotifier keeps the messages instead of sending them.
+type recordingNotifier struct {
+	sent []notifications.Message
+}
+
+func (n *recordingNotifier) Send(msg notifications.Message) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+type notificationFixture struct {
+	repo         *MockNotificationRepository
+	userRepo     *MockUserRepository
+	resourceRepo *MockResourceRepository
+	notifier     *recordingNotifier
+	service      services.NotificationService
+}
+
+func newNotificationFixture() *notificationFixture {
+	f := &notificationFixture{
+		repo:         new(MockNotificationRepository),
+		userRepo:     new(MockUserRepository),
+		resourceRepo: new(MockResourceRepository),
+		notifier:     &recordingNotifier{},
+	}
+	f.service = services.NewNotificationService(f.repo, f.userRepo, f.resourceRepo, f.notifier, services.NotificationSettings{
+		ReminderMinutes: 60,
+		Locale:          "es",
+	})
+
+	user := &models.User{Name: "Ana", Email: "ana@example.com"}
+	user.ID = 7
+	f.userRepo.On("FindByID", mock.Anything, uint(7)).Return(user, nil)
+	f.resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithBuffers(), nil)
+	return f
+}
+
+func TestNotificationService_ProcessEvents_SendsLocalisedEmailWithInvite(t *testing.T) {
+	f := newNotificationFixture()
+
+	f.repo.On("FindUnnotifiedEvents", mock.Anything, mock.Anything).Return([]models.OutboxEvent{
+		{ID: 1, Type: models.EventReservationCreated, Payload: `{"ID":42,"user_id":7,"resource_id":1,"date":"2025-05-23","start_time":"10:00","end_time":"11:00","status":"confirmed"}`},
+		{ID: 2, Type: models.EventReservationCheckedIn, Payload: `{"ID":42,"user_id":7}`},
+	}, nil)
+	f.repo.On("FindPreferences", mock.Anything, uint(7)).Return(&models.NotificationPreferences{UserID: 7, Confirmation: true, Locale: "en"}, nil)
+	f.repo.On("MarkEventNotified", mock.Anything, mock.Anything).Return(nil)
+
+	sent, err := f.service.ProcessEvents(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	f.repo.AssertNumberOfCalls(t, "MarkEventNotified", 2)
+	if assert.Len(t, f.notifier.sent, 1) {
+		msg := f.notifier.sent[0]
+		assert.Equal(t, "ana@example.com", msg.To)
+		assert.Equal(t, "Booking confirmed: 2025-05-23 10:00", msg.Subject)
+		assert.Contains(t, msg.Text, "Your booking of Room A is confirmed for Friday, May 23, 2025")
+		assert.Contains(t, msg.HTML, "<strong>Room A</strong>")
+		if assert.Len(t, msg.Attachments, 1) {
+			assert.Contains(t, string(msg.Attachments[0].Data), "UID:reservation-42@booking-api")
+		}
+	}
+}
+
+func TestNotificationService_ProcessEvents_RespectsOptOut(t *testing.T) {
+	f := newNotificationFixture()
+
+	f.repo.On("FindUnnotifiedEvents", mock.Anything, mock.Anything).Return([]models.OutboxEvent{
+		{ID: 1, Type: models.EventReservationCancelled, Payload: `{"ID":42,"user_id":7,"date":"2025-05-23","start_time":"10:00","end_time":"11:00"}`},
+	}, nil)
+	f.repo.On("FindPreferences", mock.Anything, uint(7)).Return(&models.NotificationPreferences{UserID: 7, Confirmation: true, Locale: "es"}, nil)
+	f.repo.On("MarkEventNotified", mock.Anything, mock.Anything).Return(nil)
+
+	sent, err := f.service.ProcessEvents(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Empty(t, f.notifier.sent)
+	f.repo.AssertCalled(t, "MarkEventNotified", mock.Anything, mock.Anything)
+}
+
+func TestNotificationService_SendReminders_OnlyWithinReminderTime(t *testing.T) {
+	f := newNotificationFixture()
+
+	soon := *reservationStartingIn(30 * time.Minute)
+	later := *reservationStartingIn(3 * time.Hour)
+	later.ID = 43
+	f.repo.On("FindReminderCandidates", mock.Anything, mock.Anything, mock.Anything).Return([]models.Reservation{soon, later}, nil)
+	f.repo.On("FindPreferences", mock.Anything, uint(7)).Return(nil, nil)
+	f.repo.On("MarkReminded", mock.Anything, mock.Anything).Return(true, nil)
+
+	sent, err := f.service.SendReminders(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	f.repo.AssertNumberOfCalls(t, "MarkReminded", 1)
+	if assert.Len(t, f.notifier.sent, 1) {
+		assert.True(t, strings.HasPrefix(f.notifier.sent[0].Subject, "Recordatorio"))
+		assert.Contains(t, f.notifier.sent[0].Text, "empieza en 30 minutos")
+		assert.Empty(t, f.notifier.sent[0].Attachments)
+	}
+}
+
+func TestNotifications_Render_FallsBackToEnglish(t *testing.T) {
+	subject, text, html, err := notifications.Render("fr", notifications.KindCancellation, notifications.TemplateData{
+		UserName: "<Ana>", Date: "2025-05-23", StartTime: "10:00", EndTime: "11:00",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Booking cancelled: 2025-05-23 10:00", subject)
+	assert.Contains(t, text, "Hi <Ana>,")
+	assert.Contains(t, text, "on Friday, May 23, 2025 from 10:00")
+	assert.Contains(t, html, "Hi &lt;Ana&gt;,")
+}
+
+func TestNotifications_Render_FormatsDatesInLocale(t *testing.T) {
+	_, text, _, err := notifications.Render("es", notifications.KindConfirmation, notifications.TemplateData{
+		UserName: "Ana", Date: "2025-05-23", StartTime: "10:00", EndTime: "11:00",
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, text, "confirmada para el viernes, 23 de mayo de 2025 de 10:00 a 11:00")
+}
@@ -0,0 +1,121 @@
+package tests
+
+import (
+	"booking-api/controllers"
+	"booking-api/docs"
+	"booking-api/routes"
+	"booking-api/services"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var routeParam = regexp.MustCompile(`:([a-zA-Z_]+)`)
+
+func newRoutedApp() *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: controllers.ErrorHandler})
+	routes.Setup(app, routes.Controllers{
+		Auth:             &controllers.AuthController{},
+		Reservation:      &controllers.ReservationController{},
+		Resource:         &controllers.ResourceController{},
+		Quota:            &controllers.QuotaController{},
+		CheckIn:          &controllers.CheckInController{},
+		Calendar:         &controllers.CalendarController{},
+		ExternalCalendar: &controllers.ExternalCalendarController{},
+		ReservationCSV:   &controllers.ReservationCSVController{},
+		Webhook:          &controllers.WebhookController{},
+		Notification:     &controllers.NotificationController{},
+		Stream:           &controllers.StreamController{},
+		Docs:             controllers.NewDocsController(),
+		Health:           controllers.NewHealthController(services.NewHealthService()),
+		Audit:            &controllers.AuditController{},
+	}, routes.Options{JWTSecret: "secret", Sunset: time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)})
+	return app
+}
+
+// specOperations returns "METHOD /path" for every operation of the document.
+func specOperations(spec map[string]interface{}) map[string]bool {
+	operations := map[string]bool{}
+	for path, item := range spec["paths"].(map[string]interface{}) {
+		for method := range item.(map[string]interface{}) {
+			operations[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+	return operations
+}
+
+func TestOpenAPI_MatchesRegisteredRoutes(t *testing.T) {
+	app := newRoutedApp()
+
+	registered := map[string]bool{}
+	for _, route := range app.GetRoutes(true) {
+		// GetRoutes(true) leaves out app.Use middleware; Fiber adds a HEAD
+		// route for every GET.
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		// The probes and metrics are for the deployment, outside the API.
+		if route.Path == "/healthz" || route.Path == "/readyz" || route.Path == "/metrics" {
+			continue
+		}
+		// The document lists paths relative to its /v1 server.
+		require.True(t, strings.HasPrefix(route.Path, routes.Current+"/"), "route %s is not versioned", route.Path)
+		path := strings.TrimSuffix(strings.TrimPrefix(route.Path, routes.Current), "/")
+		registered[route.Method+" "+routeParam.ReplaceAllString(path, "{$1}")] = true
+	}
+	documented := specOperations(docs.Spec())
+
+	for route := range registered {
+		assert.True(t, documented[route], "route %s is not documented in the OpenAPI spec", route)
+	}
+	for operation := range documented {
+		assert.True(t, registered[operation], "operation %s is documented but not registered", operation)
+	}
+}
+
+func TestOpenAPI_ServesDocumentAndUI(t *testing.T) {
+	app := newRoutedApp()
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/openapi.json", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get(fiber.HeaderContentType))
+
+	var spec map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&spec))
+	assert.Equal(t, "3.1.0", spec["openapi"])
+
+	schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	reservation := schemas["Reservation"].(map[string]interface{})["properties"].(map[string]interface{})
+	for _, field := range []string{"ID", "CreatedAt", "user_id", "resource_id", "group_id", "date", "start_time", "end_time", "status", "checked_in_at", "sequence", "previous"} {
+		assert.Contains(t, reservation, field)
+	}
+	assert.NotContains(t, reservation, "RemindedAt", "fields hidden from JSON must not be documented")
+	assert.Equal(t, []interface{}{"string", "null"}, reservation["checked_in_at"].(map[string]interface{})["type"])
+
+	assert.Contains(t, schemas["LoginRequest"].(map[string]interface{})["properties"], "password")
+	assert.Contains(t, schemas["TokenResponse"].(map[string]interface{})["properties"], "token")
+	problem := schemas["Problem"].(map[string]interface{})["properties"].(map[string]interface{})
+	encoded, _ := json.Marshal(controllers.Problem{Detail: "d", Instance: "i", RequestID: "r", Violations: []services.Violation{{}}})
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(encoded, &fields))
+	for field := range fields {
+		assert.Contains(t, problem, field)
+	}
+	assert.Len(t, problem, len(fields), "docs.Problem must mirror controllers.Problem")
+	assert.Contains(t, spec["components"].(map[string]interface{})["securitySchemes"], "bearerAuth")
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/v1/docs", nil))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `url: "openapi.json"`, "relative, so it works from /v1/docs and /docs")
+}
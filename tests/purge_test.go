@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"booking-api/database"
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/services"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func newPurgeFixture(t *testing.T) (*gorm.DB, services.PurgeService) {
+	db := openMigrationDB(t, filepath.Join(t.TempDir(), "booking.db"))
+	migrator, err := database.NewMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	return db, services.NewPurgeService(repositories.NewPurgeRepository(db))
+}
+
+func TestPurgeService_DeletesOnlyFinishedHistory(t *testing.T) {
+	db, service := newPurgeFixture(t)
+
+	old := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.Local)
+	done := old.Add(time.Minute)
+	group := models.BookingGroup{UserID: 1}
+	group.CreatedAt = old
+	require.NoError(t, db.Create(&group).Error)
+	require.NoError(t, db.Create(&[]models.Reservation{
+		{UserID: 1, ResourceID: 1, GroupID: &group.ID, Date: "2024-03-01", StartTime: "10:00", EndTime: "11:00"},
+		{UserID: 1, ResourceID: 1, Date: "2024-03-02", StartTime: "10:00", EndTime: "11:00", Status: models.ReservationCancelled},
+		{UserID: 1, ResourceID: 1, Date: "2024-06-01", StartTime: "10:00", EndTime: "11:00"},
+	}).Error)
+	require.NoError(t, db.Create(&[]models.ExternalBlock{
+		{CalendarID: 1, ResourceID: 1, Date: "2024-03-01", StartTime: "09:00", EndTime: "10:00"},
+		{CalendarID: 1, ResourceID: 1, Date: "2024-06-01", StartTime: "09:00", EndTime: "10:00"},
+	}).Error)
+	require.NoError(t, db.Create(&[]models.OutboxEvent{
+		{CreatedAt: old, Type: models.EventReservationCreated, ProcessedAt: &done, NotifiedAt: &done},
+		{CreatedAt: old, Type: models.EventReservationCreated, ProcessedAt: &done},
+	}).Error)
+	deliveries := []models.WebhookDelivery{
+		{SubscriptionID: 1, EventID: 1, Status: models.DeliverySucceeded},
+		{SubscriptionID: 1, EventID: 2, Status: models.DeliveryPending},
+	}
+	for i := range deliveries {
+		deliveries[i].CreatedAt = old
+	}
+	require.NoError(t, db.Create(&deliveries).Error)
+
+	want := &models.PurgeReport{Reservations: 2, BookingGroups: 1, ExternalBlocks: 1, OutboxEvents: 1, WebhookDeliveries: 1}
+
+	report, err := service.Purge(context.Background(), "2024-04-01", true)
+	require.NoError(t, err)
+	assert.Equal(t, want, report)
+	var count int64
+	db.Model(&models.Reservation{}).Count(&count)
+	assert.Equal(t, int64(3), count, "a dry run deletes nothing")
+
+	report, err = service.Purge(context.Background(), "2024-04-01", false)
+	require.NoError(t, err)
+	assert.Equal(t, want, report)
+
+	var reservations []models.Reservation
+	require.NoError(t, db.Unscoped().Find(&reservations).Error)
+	require.Len(t, reservations, 1)
+	assert.Equal(t, "2024-06-01", reservations[0].Date)
+	db.Model(&models.OutboxEvent{}).Where("notified_at IS NULL").Count(&count)
+	assert.Equal(t, int64(1), count, "events not yet notified are kept")
+	db.Unscoped().Model(&models.WebhookDelivery{}).Where("status = ?", models.DeliveryPending).Count(&count)
+	assert.Equal(t, int64(1), count, "pending deliveries are kept")
+}
+
+func TestPurgeService_RejectsFutureAndMalformedDates(t *testing.T) {
+	_, service := newPurgeFixture(t)
+
+	_, err := service.Purge(context.Background(), time.Now().AddDate(0, 0, 2).Format("2006-01-02"), true)
+	var serviceErr *services.Error
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, services.CodeInvalidValue, serviceErr.Code)
+	assert.Equal(t, "before", serviceErr.Field)
+
+	_, err = service.Purge(context.Background(), "01/03/2024", true)
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, services.CodeInvalidFormat, serviceErr.Code)
+}
@@ -0,0 +1,905 @@
+package tests
+
+import (
+	"booking-api/database"
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/repositories/memory"
+	"booking-api/services"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// repositorySet is what the conformance suite runs against: empty
+// repositories sharing one database or store.
+type repositorySet struct {
+	reservations  repositories.ReservationRepository
+	users         repositories.UserRepository
+	outbox        repositories.OutboxRepository
+	resources     repositories.ResourceRepository
+	rules         repositories.BookingRuleRepository
+	quotas        repositories.QuotaRepository
+	calendars     repositories.ExternalCalendarRepository
+	webhooks      repositories.WebhookRepository
+	notifications repositories.NotificationRepository
+	purge         repositories.PurgeRepository
+	audit         repositories.AuditRepository
+	uow           repositories.UnitOfWork
+	// chainAudit turns on the hash chain of the audit log.
+	chainAudit func() error
+}
+
+func gormRepositorySet(db *gorm.DB) repositorySet {
+	return repositorySet{
+		reservations:  repositories.NewReservationRepository(db),
+		users:         repositories.NewUserRepository(db),
+		outbox:        repositories.NewOutboxRepository(db),
+		resources:     repositories.NewResourceRepository(db),
+		rules:         repositories.NewBookingRuleRepository(db),
+		quotas:        repositories.NewQuotaRepository(db),
+		calendars:     repositories.NewExternalCalendarRepository(db),
+		webhooks:      repositories.NewWebhookRepository(db),
+		notifications: repositories.NewNotificationRepository(db),
+		purge:         repositories.NewPurgeRepository(db),
+		audit:         repositories.NewAuditRepository(db),
+		uow:           repositories.NewUnitOfWork(db),
+		chainAudit:    func() error { return database.ChainAudit(db) },
+	}
+}
+
+func memoryRepositorySet(store *memory.Store) repositorySet {
+	return repositorySet{
+		reservations:  memory.NewReservationRepository(store),
+		users:         memory.NewUserRepository(store),
+		outbox:        memory.NewOutboxRepository(store),
+		resources:     memory.NewResourceRepository(store),
+		rules:         memory.NewBookingRuleRepository(store),
+		quotas:        memory.NewQuotaRepository(store),
+		calendars:     memory.NewExternalCalendarRepository(store),
+		webhooks:      memory.NewWebhookRepository(store),
+		notifications: memory.NewNotificationRepository(store),
+		purge:         memory.NewPurgeRepository(store),
+		audit:         memory.NewAuditRepository(store),
+		uow:           memory.NewUnitOfWork(store),
+		chainAudit:    func() error { store.ChainAudit(); return nil },
+	}
+}
+
+// forEachBackend runs test on the in-memory repositories, on SQLite and, when
+// TEST_POSTGRES_URL points to a database, on PostgreSQL.
+func forEachBackend(t *testing.T, test func(t *testing.T, repos repositorySet)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, memoryRepositorySet(memory.NewStore()))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		test(t, gormRepositorySet(migratedDB(t)))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		url := os.Getenv("TEST_POSTGRES_URL")
+		if url == "" {
+			t.Skip("TEST_POSTGRES_URL is not set")
+		}
+		test(t, gormRepositorySet(postgresSchema(t, url)))
+	})
+}
+
+// postgresSchema migrates a schema of its own in the database at url and
+// drops it when the test ends.
+func postgresSchema(t *testing.T, url string) *gorm.DB {
+	config := &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)}
+	admin, err := gorm.Open(postgres.Open(url), config)
+	require.NoError(t, err)
+	schema := fmt.Sprintf("conformance_%d", time.Now().UnixNano())
+	require.NoError(t, admin.Exec("CREATE SCHEMA "+schema).Error)
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	separator := "?"
+	if strings.Contains(url, "?") {
+		separator = "&"
+	}
+	db, err := gorm.Open(postgres.Open(url+separator+"search_path="+schema), config)
+	require.NoError(t, err)
+	migrator, err := database.NewMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	return db
+}
+
+func createUser(t *testing.T, repos repositorySet, email string) *models.User {
+	user := &models.User{Name: "User", Email: email, Password: "hash"}
+	require.NoError(t, repos.users.Create(context.Background(), user))
+	return user
+}
+
+func createReservation(t *testing.T, repos repositorySet, user *models.User, resourceID uint, date, start, end, status string) *models.Reservation {
+	res := &models.Reservation{UserID: user.ID, ResourceID: resourceID, Date: date, StartTime: start, EndTime: end, Status: status}
+	require.NoError(t, repos.reservations.Create(context.Background(), res))
+	return res
+}
+
+func reservationIDs(reservations []models.Reservation) []uint {
+	ids := make([]uint, len(reservations))
+	for i, res := range reservations {
+		ids[i] = res.ID
+	}
+	return ids
+}
+
+func startTimes(reservations []models.Reservation) []string {
+	starts := make([]string, len(reservations))
+	for i, res := range reservations {
+		starts[i] = res.StartTime
+	}
+	return starts
+}
+
+func TestRepositoryConformance_Users(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx := context.Background()
+		ana := createUser(t, repos, "ana@example.com")
+		bea := createUser(t, repos, "bea@example.com")
+		assert.NotZero(t, ana.ID)
+		assert.NotEqual(t, ana.ID, bea.ID)
+
+		found, err := repos.users.FindByEmail(ctx, "ana@example.com")
+		require.NoError(t, err)
+		assert.Equal(t, ana.ID, found.ID)
+		assert.Equal(t, models.RoleUser, found.Role, "the role defaults to user")
+
+		_, err = repos.users.FindByEmail(ctx, "nadie@example.com")
+		assert.True(t, errors.Is(err, repositories.ErrNotFound))
+		_, err = repos.users.FindByID(ctx, 9999)
+		assert.True(t, errors.Is(err, repositories.ErrNotFound))
+		assert.Error(t, repos.users.Create(ctx, &models.User{Name: "Ana", Email: "ana@example.com"}), "emails are unique")
+
+		token := "feed-token"
+		found.FeedToken = &token
+		found.Name = "Ana María"
+		require.NoError(t, repos.users.Update(ctx, found))
+		token = "changed after saving"
+		byToken, err := repos.users.FindByFeedToken(ctx, "feed-token")
+		require.NoError(t, err)
+		assert.Equal(t, "Ana María", byToken.Name)
+		bea.FeedToken = byToken.FeedToken
+		assert.Error(t, repos.users.Update(ctx, bea), "feed tokens are unique")
+
+		require.NoError(t, repos.users.IncrementNoShows(ctx, ana.ID))
+		require.NoError(t, repos.users.IncrementNoShows(ctx, ana.ID))
+		found, err = repos.users.FindByID(ctx, ana.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, found.NoShowCount)
+	})
+}
+
+func TestRepositoryConformance_ReservationQueries(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx := context.Background()
+		ana := createUser(t, repos, "ana@example.com")
+		bea := createUser(t, repos, "bea@example.com")
+		r1 := createReservation(t, repos, ana, 1, "2025-05-20", "10:00", "11:00", "")
+		r2 := createReservation(t, repos, ana, 1, "2025-05-20", "11:00", "12:00", models.ReservationConfirmed)
+		r3 := createReservation(t, repos, bea, 1, "2025-05-20", "09:00", "10:30", models.ReservationCancelled)
+		r4 := createReservation(t, repos, bea, 2, "2025-05-21", "08:00", "09:00", models.ReservationConfirmed)
+
+		stored, err := repos.reservations.FindByID(ctx, r1.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReservationConfirmed, stored.Status, "the status defaults to confirmed")
+		_, err = repos.reservations.FindByID(ctx, 9999)
+		assert.True(t, errors.Is(err, repositories.ErrNotFound))
+
+		overlapping, err := repos.reservations.FindOverlapping(ctx, 1, "2025-05-20", "10:30", "11:30")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []uint{r1.ID, r2.ID}, reservationIDs(overlapping), "released reservations do not overlap")
+		overlapping, err = repos.reservations.FindOverlapping(ctx, 1, "2025-05-20", "12:00", "13:00")
+		require.NoError(t, err)
+		assert.Empty(t, overlapping, "adjacent slots do not overlap")
+
+		cases := []struct {
+			name  string
+			find  func() ([]models.Reservation, error)
+			want  []uint
+			order bool
+		}{
+			{"FindByDate", func() ([]models.Reservation, error) {
+				return repos.reservations.FindByDate(ctx, "2025-05-20")
+			}, []uint{r1.ID, r2.ID, r3.ID}, false},
+			{"FindByResourceAndDate", func() ([]models.Reservation, error) {
+				return repos.reservations.FindByResourceAndDate(ctx, 1, "2025-05-20")
+			}, []uint{r1.ID, r2.ID}, true},
+			{"FindByUserBetween", func() ([]models.Reservation, error) {
+				return repos.reservations.FindByUserBetween(ctx, bea.ID, "2025-05-20", "2025-05-21")
+			}, []uint{r4.ID}, true},
+			{"FindByUserFrom", func() ([]models.Reservation, error) {
+				return repos.reservations.FindByUserFrom(ctx, bea.ID, "2025-05-20")
+			}, []uint{r3.ID, r4.ID}, true},
+			{"FindByResourceFrom", func() ([]models.Reservation, error) {
+				return repos.reservations.FindByResourceFrom(ctx, 1, "2025-05-20")
+			}, []uint{r3.ID, r1.ID, r2.ID}, true},
+			{"FindUncheckedStartedBefore", func() ([]models.Reservation, error) {
+				return repos.reservations.FindUncheckedStartedBefore(ctx, "2025-05-20", "10:00")
+			}, []uint{r1.ID}, false},
+		}
+		for _, c := range cases {
+			found, err := c.find()
+			require.NoError(t, err, c.name)
+			if c.order {
+				assert.Equal(t, c.want, reservationIDs(found), c.name)
+			} else {
+				assert.ElementsMatch(t, c.want, reservationIDs(found), c.name)
+			}
+		}
+
+		var each []uint
+		require.NoError(t, repos.reservations.EachBetween(ctx, "2025-05-20", "2025-05-21", 0, func(res models.Reservation) error {
+			each = append(each, res.ID)
+			return nil
+		}))
+		assert.Equal(t, []uint{r3.ID, r1.ID, r2.ID, r4.ID}, each)
+		each = nil
+		require.NoError(t, repos.reservations.EachBetween(ctx, "2025-05-20", "2025-05-21", ana.ID, func(res models.Reservation) error {
+			each = append(each, res.ID)
+			return nil
+		}))
+		assert.Equal(t, []uint{r1.ID, r2.ID}, each)
+	})
+}
+
+func TestRepositoryConformance_ActiveReservationsAndGroups(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx := context.Background()
+		ana := createUser(t, repos, "ana@example.com")
+		group := &models.BookingGroup{UserID: ana.ID}
+		require.NoError(t, repos.reservations.CreateGroup(ctx, group))
+		for _, resourceID := range []uint{3, 2} {
+			res := &models.Reservation{UserID: ana.ID, ResourceID: resourceID, GroupID: &group.ID, Date: "2025-05-20", StartTime: "12:00", EndTime: "13:00"}
+			require.NoError(t, repos.reservations.Create(ctx, res))
+		}
+		createReservation(t, repos, ana, 1, "2025-05-20", "09:00", "10:00", "")
+		createReservation(t, repos, ana, 1, "2025-05-21", "09:00", "10:00", "")
+		createReservation(t, repos, ana, 1, "2025-05-22", "09:00", "10:00", models.ReservationNoShow)
+
+		active, err := repos.reservations.CountActiveByUser(ctx, ana.ID, "2025-05-20", "10:30")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), active, "the group counts once, ended and released reservations not at all")
+
+		members, err := repos.reservations.FindByGroup(ctx, group.ID)
+		require.NoError(t, err)
+		require.Len(t, members, 2)
+		assert.Equal(t, []uint{2, 3}, []uint{members[0].ResourceID, members[1].ResourceID})
+
+		assert.NoError(t, repos.reservations.LockUser(ctx, ana.ID))
+		assert.True(t, errors.Is(repos.reservations.LockUser(ctx, 9999), repositories.ErrNotFound))
+	})
+}
+
+func TestRepositoryConformance_UpdateAndMarkNoShow(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx := context.Background()
+		ana := createUser(t, repos, "ana@example.com")
+		late := createReservation(t, repos, ana, 1, "2025-05-20", "10:00", "11:00", "")
+		checkedIn := createReservation(t, repos, ana, 1, "2025-05-20", "12:00", "13:00", "")
+
+		now := time.Now()
+		checkedIn.Status = models.ReservationCheckedIn
+		checkedIn.CheckedInAt = &now
+		checkedIn.Previous = &models.ReservationSlot{Date: "2025-05-19"}
+		require.NoError(t, repos.reservations.Update(ctx, checkedIn))
+		stored, err := repos.reservations.FindByID(ctx, checkedIn.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReservationCheckedIn, stored.Status)
+		require.NotNil(t, stored.CheckedInAt)
+		assert.Nil(t, stored.Previous, "the previous slot is not stored")
+
+		marked, err := repos.reservations.MarkNoShow(ctx, late)
+		require.NoError(t, err)
+		assert.True(t, marked)
+		assert.Equal(t, models.ReservationNoShow, late.Status)
+		stored, err = repos.reservations.FindByID(ctx, late.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, stored.Sequence, "calendars see the no-show as a new version")
+		assert.Equal(t, 1, late.Sequence)
+
+		again := *late
+		again.Status = models.ReservationConfirmed
+		marked, err = repos.reservations.MarkNoShow(ctx, &again)
+		require.NoError(t, err)
+		assert.False(t, marked, "a released reservation is not marked twice")
+		marked, err = repos.reservations.MarkNoShow(ctx, checkedIn)
+		require.NoError(t, err)
+		assert.False(t, marked, "checked in reservations are kept")
+
+		onTime := createReservation(t, repos, ana, 1, "2025-05-20", "14:00", "15:00", "")
+		marked, err = repos.reservations.MarkCheckedIn(ctx, onTime, now)
+		require.NoError(t, err)
+		assert.True(t, marked)
+		assert.Equal(t, models.ReservationCheckedIn, onTime.Status)
+		stored, err = repos.reservations.FindByID(ctx, onTime.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.ReservationCheckedIn, stored.Status)
+		require.NotNil(t, stored.CheckedInAt)
+		assert.Equal(t, 1, stored.Sequence)
+		for _, res := range []*models.Reservation{onTime, late} {
+			marked, err = repos.reservations.MarkCheckedIn(ctx, res, now)
+			require.NoError(t, err)
+			assert.False(t, marked, "only confirmed reservations are checked in")
+		}
+		cancelled := createReservation(t, repos, ana, 1, "2025-05-20", "16:00", "17:00", models.ReservationCancelled)
+		marked, err = repos.reservations.MarkCheckedIn(ctx, cancelled, now)
+		require.NoError(t, err)
+		assert.False(t, marked)
+		assert.Equal(t, models.ReservationCancelled, cancelled.Status)
+	})
+}
+
+func TestRepositoryConformance_Transactions(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx := context.Background()
+		ana := createUser(t, repos, "ana@example.com")
+		errRollback := errors.New("rollback")
+
+		var kept, discarded models.Reservation
+		err := repos.reservations.WithTransaction(ctx, func(tx repositories.ReservationRepository) error {
+			kept = models.Reservation{UserID: ana.ID, ResourceID: 1, Date: "2025-05-20", StartTime: "10:00", EndTime: "11:00"}
+			if err := tx.Create(ctx, &kept); err != nil {
+				return err
+			}
+			if err := tx.CreateOutboxEvent(ctx, &models.OutboxEvent{Type: models.EventReservationCreated}); err != nil {
+				return err
+			}
+			nested := tx.WithTransaction(ctx, func(tx repositories.ReservationRepository) error {
+				discarded = models.Reservation{UserID: ana.ID, ResourceID: 1, Date: "2025-05-20", StartTime: "12:00", EndTime: "13:00"}
+				if err := tx.Create(ctx, &discarded); err != nil {
+					return err
+				}
+				return errRollback
+			})
+			require.ErrorIs(t, nested, errRollback)
+			return nil
+		})
+		require.NoError(t, err)
+
+		_, err = repos.reservations.FindByID(ctx, kept.ID)
+		assert.NoError(t, err)
+		_, err = repos.reservations.FindByID(ctx, discarded.ID)
+		assert.True(t, errors.Is(err, repositories.ErrNotFound), "a failed nested transaction only undoes its own changes")
+
+		err = repos.reservations.WithTransaction(ctx, func(tx repositories.ReservationRepository) error {
+			if err := tx.Create(ctx, &models.Reservation{UserID: ana.ID, ResourceID: 2, Date: "2025-05-20", StartTime: "10:00", EndTime: "11:00"}); err != nil {
+				return err
+			}
+			if err := tx.CreateOutboxEvent(ctx, &models.OutboxEvent{Type: models.EventReservationCreated}); err != nil {
+				return err
+			}
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+
+		reservations, err := repos.reservations.FindByDate(ctx, "2025-05-20")
+		require.NoError(t, err)
+		assert.Equal(t, []uint{kept.ID}, reservationIDs(reservations))
+		events, err := repos.outbox.FindAfter(ctx, 0, 10)
+		require.NoError(t, err)
+		assert.Len(t, events, 1, "events are rolled back with the change")
+		latest, err := repos.outbox.LatestID(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, events[0].ID, latest)
+	})
+}
+
+func TestRepositoryConformance_CancelledContext(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := repos.users.FindByEmail(ctx, "ana@example.com")
+		assert.ErrorIs(t, err, context.Canceled)
+		err = repos.reservations.WithTransaction(ctx, func(repositories.ReservationRepository) error { return nil })
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestRepositoryConformance_Resources(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx := context.Background()
+		token := "room-feed"
+		room := &models.Resource{Name: "Room A", PreBufferMinutes: 15, FeedToken: &token}
+		desk := &models.Resource{Name: "Desk"}
+		require.NoError(t, repos.resources.Create(ctx, room))
+		require.NoError(t, repos.resources.Create(ctx, desk))
+
+		found, err := repos.resources.FindByID(ctx, room.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "Room A", found.Name)
+		assert.Equal(t, 15, found.PreBufferMinutes)
+		_, err = repos.resources.FindByID(ctx, 9999)
+		assert.True(t, errors.Is(err, repositories.ErrNotFound))
+
+		all, err := repos.resources.FindAll(ctx)
+		require.NoError(t, err)
+		require.Len(t, all, 2)
+		assert.Equal(t, []uint{room.ID, desk.ID}, []uint{all[0].ID, all[1].ID})
+
+		byToken, err := repos.resources.FindByFeedToken(ctx, "room-feed")
+		require.NoError(t, err)
+		assert.Equal(t, room.ID, byToken.ID)
+		_, err = repos.resources.FindByFeedToken(ctx, "other")
+		assert.True(t, errors.Is(err, repositories.ErrNotFound))
+
+		desk.FeedToken = &token
+		assert.Error(t, repos.resources.Update(ctx, desk), "feed tokens are unique")
+		desk.FeedToken = nil
+		desk.CheckInCode = "1234"
+		require.NoError(t, repos.resources.Update(ctx, desk))
+		found, err = repos.resources.FindByID(ctx, desk.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "1234", found.CheckInCode)
+	})
+}
+
+func TestRepositoryConformance_BookingRulesAndQuotas(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx := context.Background()
+		for _, rule := range []models.BookingRule{
+			{ResourceID: 1, Kind: "max_duration", Value: "120"},
+			{ResourceID: 2, Kind: "max_duration", Value: "60"},
+			{ResourceID: 1, Kind: "min_notice", Value: "30"},
+		} {
+			require.NoError(t, repos.rules.Create(ctx, &rule))
+		}
+		rules, err := repos.rules.FindByResource(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, rules, 2)
+		assert.Equal(t, []string{"max_duration", "min_notice"}, []string{rules[0].Kind, rules[1].Kind})
+		rules, err = repos.rules.FindByResource(ctx, 3)
+		require.NoError(t, err)
+		assert.Empty(t, rules)
+
+		rule, err := repos.rules.FindByID(ctx, 1)
+		require.NoError(t, err)
+		rule.Value = "90"
+		require.NoError(t, repos.rules.Update(ctx, rule))
+		rule, err = repos.rules.FindByID(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, "90", rule.Value)
+		require.NoError(t, repos.rules.Delete(ctx, rule))
+		_, err = repos.rules.FindByID(ctx, 1)
+		var notFound *repositories.NotFoundError
+		assert.ErrorAs(t, err, &notFound)
+		rules, err = repos.rules.FindByResource(ctx, 1)
+		require.NoError(t, err)
+		assert.Len(t, rules, 1, "deleted rules are gone")
+
+		quota, err := repos.quotas.FindByRole(ctx, models.RoleUser)
+		require.NoError(t, err)
+		assert.Nil(t, quota, "no override")
+		ana := createUser(t, repos, "ana@example.com")
+		personal := &models.Quota{Role: models.RoleUser, UserID: &ana.ID, QuotaLimits: models.QuotaLimits{BookingsPerDay: 5}}
+		require.NoError(t, repos.quotas.Save(ctx, personal))
+		quota, err = repos.quotas.FindByRole(ctx, models.RoleUser)
+		require.NoError(t, err)
+		assert.Nil(t, quota, "overrides of a user do not apply to the role")
+
+		role := &models.Quota{Role: models.RoleUser, QuotaLimits: models.QuotaLimits{BookingsPerDay: 2}}
+		require.NoError(t, repos.quotas.Save(ctx, role))
+		role.HoursPerWeek = 10
+		require.NoError(t, repos.quotas.Save(ctx, role))
+		quota, err = repos.quotas.FindByRole(ctx, models.RoleUser)
+		require.NoError(t, err)
+		require.NotNil(t, quota)
+		assert.Equal(t, role.ID, quota.ID)
+		assert.Equal(t, models.QuotaLimits{HoursPerWeek: 10, BookingsPerDay: 2}, quota.QuotaLimits)
+
+		quota, err = repos.quotas.FindByUser(ctx, ana.ID)
+		require.NoError(t, err)
+		require.NotNil(t, quota)
+		assert.Equal(t, personal.ID, quota.ID)
+		quota, err = repos.quotas.FindByUser(ctx, 9999)
+		require.NoError(t, err)
+		assert.Nil(t, quota)
+	})
+}
+
+func TestRepositoryConformance_ExternalCalendars(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx := context.Background()
+		ana := createUser(t, repos, "ana@example.com")
+		booked := createReservation(t, repos, ana, 1, "2025-05-20", "12:00", "13:00", "")
+		feed := &models.ExternalCalendar{ResourceID: 1, Name: "Feed", URL: "https://example.com/feed.ics"}
+		upload := &models.ExternalCalendar{ResourceID: 1, Name: "Upload"}
+		require.NoError(t, repos.calendars.Create(ctx, feed))
+		require.NoError(t, repos.calendars.Create(ctx, upload))
+
+		calendars, err := repos.calendars.FindByResource(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, calendars, 2)
+		assert.Equal(t, feed.ID, calendars[0].ID)
+		syncable, err := repos.calendars.FindSyncable(ctx)
+		require.NoError(t, err)
+		require.Len(t, syncable, 1, "uploads without a file cannot be fetched again")
+		assert.Equal(t, feed.ID, syncable[0].ID)
+
+		block := func(start, end string) models.ExternalBlock {
+			return models.ExternalBlock{CalendarID: feed.ID, ResourceID: 1, Date: "2025-05-20", StartTime: start, EndTime: end}
+		}
+		feed.BlockCount = 2
+		require.NoError(t, repos.calendars.ReplaceBlocks(ctx, feed, []models.ExternalBlock{block("15:00", "16:00"), block("09:00", "10:00")}))
+		stored, err := repos.calendars.FindByID(ctx, feed.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 2, stored.BlockCount, "the calendar is saved with its blocks")
+
+		busy, err := repos.reservations.FindOverlapping(ctx, 1, "2025-05-20", "09:30", "12:30")
+		require.NoError(t, err)
+		require.Len(t, busy, 2)
+		assert.Equal(t, booked.ID, busy[0].ID)
+		assert.Equal(t, models.ReservationExternal, busy[1].Status)
+		assert.Zero(t, busy[1].ID, "blocks have no reservation ID")
+		assert.Equal(t, "09:00", busy[1].StartTime)
+
+		day, err := repos.reservations.FindByResourceAndDate(ctx, 1, "2025-05-20")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"12:00", "09:00", "15:00"}, startTimes(day), "reservations first, then blocks by start time")
+
+		require.NoError(t, repos.calendars.ReplaceBlocks(ctx, feed, []models.ExternalBlock{block("17:00", "18:00")}))
+		day, err = repos.reservations.FindByResourceAndDate(ctx, 1, "2025-05-20")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"12:00", "17:00"}, startTimes(day), "the old blocks are replaced")
+
+		require.NoError(t, repos.calendars.Delete(ctx, feed))
+		_, err = repos.calendars.FindByID(ctx, feed.ID)
+		assert.True(t, errors.Is(err, repositories.ErrNotFound))
+		day, err = repos.reservations.FindByResourceAndDate(ctx, 1, "2025-05-20")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"12:00"}, startTimes(day), "blocks are deleted with their calendar")
+	})
+}
+
+func TestRepositoryConformance_Webhooks(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx := context.Background()
+		hook := &models.WebhookSubscription{URL: "https://example.com/hook", Events: "*", Secret: "secret"}
+		other := &models.WebhookSubscription{URL: "https://example.com/other", Events: "*"}
+		require.NoError(t, repos.webhooks.CreateSubscription(ctx, hook))
+		require.NoError(t, repos.webhooks.CreateSubscription(ctx, other))
+		require.NoError(t, repos.webhooks.DeleteSubscription(ctx, other))
+		subscriptions, err := repos.webhooks.FindSubscriptions(ctx)
+		require.NoError(t, err)
+		require.Len(t, subscriptions, 1)
+		assert.Equal(t, hook.ID, subscriptions[0].ID)
+		_, err = repos.webhooks.FindSubscriptionByID(ctx, other.ID)
+		assert.True(t, errors.Is(err, repositories.ErrNotFound))
+
+		for range 2 {
+			require.NoError(t, repos.reservations.CreateOutboxEvent(ctx, &models.OutboxEvent{Type: models.EventReservationCreated, Payload: "{}"}))
+		}
+		pending, err := repos.webhooks.FindPendingEvents(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		event, again := pending[0], pending[0]
+		require.NoError(t, repos.webhooks.DispatchEvent(ctx, &event, []models.WebhookDelivery{
+			{SubscriptionID: hook.ID, EventID: event.ID, EventType: event.Type, Payload: event.Payload},
+		}))
+		assert.NotNil(t, event.ProcessedAt)
+		require.NoError(t, repos.webhooks.DispatchEvent(ctx, &again, []models.WebhookDelivery{{SubscriptionID: hook.ID, EventID: again.ID}}))
+		assert.Nil(t, again.ProcessedAt, "an event is dispatched once")
+		pending, err = repos.webhooks.FindPendingEvents(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		assert.NotEqual(t, event.ID, pending[0].ID)
+
+		deliveries, err := repos.webhooks.FindDeliveriesBySubscription(ctx, hook.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		delivery := deliveries[0]
+		assert.Equal(t, models.DeliveryPending, delivery.Status, "the status defaults to pending")
+		assert.Zero(t, delivery.Attempts)
+
+		due, err := repos.webhooks.FindDueDeliveries(ctx, time.Now(), 10)
+		require.NoError(t, err)
+		assert.Empty(t, due, "deliveries without a next attempt are not due")
+		next := time.Now().Add(-time.Minute)
+		delivery.NextAttemptAt = &next
+		delivery.Attempts = 1
+		require.NoError(t, repos.webhooks.UpdateDelivery(ctx, &delivery))
+		due, err = repos.webhooks.FindDueDeliveries(ctx, time.Now(), 10)
+		require.NoError(t, err)
+		require.Len(t, due, 1)
+		assert.Equal(t, 1, due[0].Attempts)
+
+		delivery.Status = models.DeliverySucceeded
+		require.NoError(t, repos.webhooks.UpdateDelivery(ctx, &delivery))
+		due, err = repos.webhooks.FindDueDeliveries(ctx, time.Now(), 10)
+		require.NoError(t, err)
+		assert.Empty(t, due, "only pending deliveries are due")
+		stored, err := repos.webhooks.FindDeliveryByID(ctx, delivery.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.DeliverySucceeded, stored.Status)
+		_, err = repos.webhooks.FindDeliveryByID(ctx, 9999)
+		assert.True(t, errors.Is(err, repositories.ErrNotFound))
+	})
+}
+
+func TestRepositoryConformance_Notifications(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx := context.Background()
+		ana := createUser(t, repos, "ana@example.com")
+		bea := createUser(t, repos, "bea@example.com")
+
+		preferences, err := repos.notifications.FindPreferences(ctx, ana.ID)
+		require.NoError(t, err)
+		assert.Nil(t, preferences)
+		saved := &models.NotificationPreferences{UserID: ana.ID, Confirmation: true, Locale: "es"}
+		require.NoError(t, repos.notifications.SavePreferences(ctx, saved))
+		saved.Reminder = true
+		require.NoError(t, repos.notifications.SavePreferences(ctx, saved))
+		preferences, err = repos.notifications.FindPreferences(ctx, ana.ID)
+		require.NoError(t, err)
+		require.NotNil(t, preferences)
+		assert.Equal(t, saved.ID, preferences.ID)
+		assert.True(t, preferences.Reminder)
+		assert.Error(t, repos.notifications.SavePreferences(ctx, &models.NotificationPreferences{UserID: ana.ID}), "a user has one set of preferences")
+
+		for range 2 {
+			require.NoError(t, repos.reservations.CreateOutboxEvent(ctx, &models.OutboxEvent{Type: models.EventReservationCreated, Payload: "{}"}))
+		}
+		events, err := repos.notifications.FindUnnotifiedEvents(ctx, 1)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		first := events[0]
+		require.NoError(t, repos.notifications.MarkEventNotified(ctx, &first))
+		assert.NotNil(t, first.NotifiedAt)
+		events, err = repos.notifications.FindUnnotifiedEvents(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		assert.NotEqual(t, first.ID, events[0].ID)
+
+		later := createReservation(t, repos, ana, 1, "2025-05-20", "10:00", "11:00", "")
+		sooner := createReservation(t, repos, bea, 2, "2025-05-20", "09:00", "10:00", "")
+		createReservation(t, repos, ana, 1, "2025-05-21", "09:00", "10:00", "")
+		createReservation(t, repos, bea, 1, "2025-05-20", "12:00", "13:00", models.ReservationCancelled)
+		candidates, err := repos.notifications.FindReminderCandidates(ctx, "2025-05-19", "2025-05-20")
+		require.NoError(t, err)
+		assert.Equal(t, []uint{sooner.ID, later.ID}, reservationIDs(candidates))
+
+		claimed := *sooner
+		marked, err := repos.notifications.MarkReminded(ctx, &claimed)
+		require.NoError(t, err)
+		assert.True(t, marked)
+		assert.NotNil(t, claimed.RemindedAt)
+		marked, err = repos.notifications.MarkReminded(ctx, sooner)
+		require.NoError(t, err)
+		assert.False(t, marked, "a reminder is claimed once")
+		candidates, err = repos.notifications.FindReminderCandidates(ctx, "2025-05-19", "2025-05-20")
+		require.NoError(t, err)
+		assert.Equal(t, []uint{later.ID}, reservationIDs(candidates))
+	})
+}
+
+func TestRepositoryConformance_Purge(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx := context.Background()
+		ana := createUser(t, repos, "ana@example.com")
+		group := &models.BookingGroup{UserID: ana.ID}
+		require.NoError(t, repos.reservations.CreateGroup(ctx, group))
+		old := &models.Reservation{UserID: ana.ID, ResourceID: 1, GroupID: &group.ID, Date: "2025-01-10", StartTime: "10:00", EndTime: "11:00"}
+		require.NoError(t, repos.reservations.Create(ctx, old))
+		kept := createReservation(t, repos, ana, 1, "2025-03-01", "10:00", "11:00", "")
+
+		calendar := &models.ExternalCalendar{ResourceID: 1, Name: "Feed"}
+		require.NoError(t, repos.calendars.Create(ctx, calendar))
+		require.NoError(t, repos.calendars.ReplaceBlocks(ctx, calendar, []models.ExternalBlock{
+			{CalendarID: calendar.ID, ResourceID: 1, Date: "2025-01-10", StartTime: "09:00", EndTime: "10:00"},
+			{CalendarID: calendar.ID, ResourceID: 1, Date: "2025-03-01", StartTime: "09:00", EndTime: "10:00"},
+		}))
+
+		// The first event is done with; the second still waits for the
+		// notifications.
+		hook := &models.WebhookSubscription{URL: "https://example.com/hook", Events: "*"}
+		require.NoError(t, repos.webhooks.CreateSubscription(ctx, hook))
+		for range 2 {
+			require.NoError(t, repos.reservations.CreateOutboxEvent(ctx, &models.OutboxEvent{Type: models.EventReservationCreated, Payload: "{}"}))
+		}
+		events, err := repos.webhooks.FindPendingEvents(ctx, 10)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		for i := range events {
+			require.NoError(t, repos.webhooks.DispatchEvent(ctx, &events[i], []models.WebhookDelivery{{SubscriptionID: hook.ID, EventID: events[i].ID}}))
+		}
+		require.NoError(t, repos.notifications.MarkEventNotified(ctx, &events[0]))
+		deliveries, err := repos.webhooks.FindDeliveriesBySubscription(ctx, hook.ID, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		deliveries[0].Status = models.DeliveryFailed
+		require.NoError(t, repos.webhooks.UpdateDelivery(ctx, &deliveries[0]))
+
+		before := time.Now().Add(time.Hour)
+		want := &models.PurgeReport{Reservations: 1, BookingGroups: 1, ExternalBlocks: 1, OutboxEvents: 1, WebhookDeliveries: 1}
+		report, err := repos.purge.Purge(ctx, "2025-02-01", before, true)
+		require.NoError(t, err)
+		assert.Equal(t, want, report)
+		_, err = repos.reservations.FindByID(ctx, old.ID)
+		assert.NoError(t, err, "a dry run deletes nothing")
+
+		report, err = repos.purge.Purge(ctx, "2025-02-01", before, false)
+		require.NoError(t, err)
+		assert.Equal(t, want, report)
+		_, err = repos.reservations.FindByID(ctx, old.ID)
+		assert.True(t, errors.Is(err, repositories.ErrNotFound))
+		_, err = repos.reservations.FindByID(ctx, kept.ID)
+		assert.NoError(t, err)
+		day, err := repos.reservations.FindByResourceAndDate(ctx, 1, "2025-03-01")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"10:00", "09:00"}, startTimes(day), "later blocks stay")
+		remaining, err := repos.outbox.FindAfter(ctx, 0, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []uint{events[1].ID}, []uint{remaining[0].ID})
+		_, err = repos.webhooks.FindDeliveryByID(ctx, deliveries[0].ID)
+		assert.True(t, errors.Is(err, repositories.ErrNotFound))
+	})
+}
+
+func TestRepositoryConformance_Audit(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, repos repositorySet) {
+		ctx := context.Background()
+		require.NoError(t, repos.chainAudit())
+		ana := createUser(t, repos, "ana@example.com")
+		login := &models.AuditEntry{ActorID: &ana.ID, Action: models.AuditLoginSucceeded, TargetType: models.AuditTargetUser, TargetID: ana.ID}
+		created := &models.AuditEntry{ActorID: &ana.ID, Action: models.EventReservationCreated, TargetType: models.AuditTargetReservation, TargetID: 7}
+		cancelled := &models.AuditEntry{Action: models.EventReservationCancelled, TargetType: models.AuditTargetReservation, TargetID: 7}
+		require.NoError(t, repos.users.AppendAudit(ctx, login))
+		require.NoError(t, repos.reservations.AppendAudit(ctx, created))
+		require.NoError(t, repos.reservations.WithTransaction(ctx, func(tx repositories.ReservationRepository) error {
+			return tx.AppendAudit(ctx, cancelled)
+		}))
+		assert.Equal(t, login.Hash, created.PrevHash, "entries are chained")
+
+		find := func(filter repositories.AuditFilter) []uint {
+			entries, err := repos.audit.Find(ctx, filter)
+			require.NoError(t, err)
+			ids := make([]uint, len(entries))
+			for i, entry := range entries {
+				ids[i] = entry.ID
+			}
+			return ids
+		}
+		assert.Equal(t, []uint{cancelled.ID, created.ID, login.ID}, find(repositories.AuditFilter{Limit: 10}), "newest first")
+		assert.Equal(t, []uint{created.ID, login.ID}, find(repositories.AuditFilter{ActorID: &ana.ID, Limit: 10}))
+		assert.Equal(t, []uint{cancelled.ID}, find(repositories.AuditFilter{Action: models.EventReservationCancelled, Limit: 10}))
+		assert.Equal(t, []uint{cancelled.ID, created.ID}, find(repositories.AuditFilter{TargetType: models.AuditTargetReservation, TargetID: 7, Limit: 10}))
+		assert.Equal(t, []uint{created.ID}, find(repositories.AuditFilter{BeforeID: cancelled.ID, Limit: 1}))
+		assert.Empty(t, find(repositories.AuditFilter{From: time.Now().Add(time.Hour), Limit: 10}))
+		assert.Len(t, find(repositories.AuditFilter{To: time.Now().Add(time.Hour), Limit: 10}), 3)
+
+		verification, err := services.NewAuditService(repos.audit).Verify(ctx)
+		require.NoError(t, err)
+		assert.True(t, verification.Valid)
+		assert.Equal(t, 3, verification.Checked)
+		assert.Equal(t, cancelled.Hash, verification.LastHash)
+	})
+}
+
+func TestMemoryStore_SerializesConcurrentTransactions(t *testing.T) {
+	store := memory.NewStore()
+	reservations := memory.NewReservationRepository(store)
+	user := &models.User{Name: "Ana", Email: "ana@example.com"}
+	require.NoError(t, memory.NewUserRepository(store).Create(context.Background(), user))
+
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := reservations.WithTransaction(context.Background(), func(tx repositories.ReservationRepository) error {
+				busy, err := tx.FindOverlapping(context.Background(), 1, "2025-05-20", "10:00", "11:00")
+				if err != nil || len(busy) > 0 {
+					return err
+				}
+				return tx.Create(context.Background(), &models.Reservation{UserID: user.ID, ResourceID: 1, Date: "2025-05-20", StartTime: "10:00", EndTime: "11:00"})
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	booked, err := reservations.FindByDate(context.Background(), "2025-05-20")
+	require.NoError(t, err)
+	assert.Len(t, booked, 1)
+}
+
+func TestReservationService_OnMemoryRepositories_BooksSlotOnce(t *testing.T) {
+	store := memory.NewStore()
+	user := &models.User{Name: "Ana", Email: "ana@example.com"}
+	require.NoError(t, memory.NewUserRepository(store).Create(context.Background(), user))
+	require.NoError(t, memory.NewResourceRepository(store).Create(context.Background(), roomWithBuffers()))
+	service := memoryReservationService(store, models.QuotaLimits{})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = service.CreateReservation(context.Background(), &models.Reservation{
+				UserID: user.ID, ResourceID: 1, Date: "2025-05-23", StartTime: "10:00", EndTime: "11:00",
+			})
+		}()
+	}
+	wg.Wait()
+
+	booked := 0
+	for _, err := range errs {
+		if err == nil {
+			booked++
+		} else {
+			assert.ErrorIs(t, err, services.ErrOverlap)
+		}
+	}
+	assert.Equal(t, 1, booked)
+	events, err := memory.NewOutboxRepository(store).FindAfter(context.Background(), 0, 10)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+}
+
+// memoryReservationService returns a ReservationService whose repositories,
+// quotas included, all live in store.
+func memoryReservationService(store *memory.Store, limits models.QuotaLimits) services.ReservationService {
+	reservations, users := memory.NewReservationRepository(store), memory.NewUserRepository(store)
+	quotas := services.NewQuotaService(memory.NewQuotaRepository(store), users, reservations, limits)
+	return services.NewReservationService(reservations, memory.NewResourceRepository(store), memory.NewBookingRuleRepository(store), memory.NewUnitOfWork(store), quotas)
+}
+
+func TestReservationService_OnMemoryRepositories_ImportsReservations(t *testing.T) {
+	ctx := context.Background()
+	store := memory.NewStore()
+	user := &models.User{Name: "Ana", Email: "ana@example.com"}
+	require.NoError(t, memory.NewUserRepository(store).Create(ctx, user))
+	require.NoError(t, memory.NewResourceRepository(store).Create(ctx, roomWithBuffers()))
+	require.NoError(t, memory.NewQuotaRepository(store).Save(ctx, &models.Quota{Role: models.RoleUser, QuotaLimits: models.QuotaLimits{BookingsPerDay: 2}}))
+	service := memoryReservationService(store, models.QuotaLimits{})
+	row := func(line int, start, end string) services.ImportRow {
+		return services.ImportRow{Line: line, Reservation: models.Reservation{UserID: user.ID, ResourceID: 1, Date: "2030-05-23", StartTime: start, EndTime: end}}
+	}
+
+	// The quota limits are read inside the transaction holding the store,
+	// which used to deadlock.
+	done := make(chan struct{})
+	var report *services.ImportReport
+	var err error
+	go func() {
+		defer close(done)
+		report, err = service.ImportReservations(ctx, []services.ImportRow{
+			row(2, "09:00", "10:00"), row(3, "11:00", "12:00"), row(4, "14:00", "15:00"),
+		}, false)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the import did not finish")
+	}
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, report.Imported)
+	if assert.Len(t, report.Errors, 1) {
+		assert.Equal(t, 4, report.Errors[0].Line)
+		assert.Equal(t, services.CodeQuotaBookingsPerDay, report.Errors[0].Violations[0].Code)
+	}
+
+	report, err = service.ImportReservations(ctx, []services.ImportRow{row(2, "09:00", "10:00"), row(3, "11:00", "12:00")}, false)
+	require.NoError(t, err)
+	assert.Equal(t, 2, report.Imported)
+	booked, err := memory.NewReservationRepository(store).FindByDate(ctx, "2030-05-23")
+	require.NoError(t, err)
+	assert.Len(t, booked, 2)
+}
@@ -3,7 +3,9 @@ package tests
 import (
 	"booking-api/controllers"
 	"booking-api/models"
+	"booking-api/services"
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http/httptest"
@@ -20,17 +22,46 @@ type MockReservationService struct {
 	mock.Mock
 }
 
-func (m *MockReservationService) CreateReservation(reservation *models.Reservation) error {
-	return m.Called(reservation).Error(0)
+func (m *MockReservationService) CreateReservation(ctx context.Context, reservation *models.Reservation) error {
+	return m.Called(ctx, reservation).Error(0)
 }
 
-func (m *MockReservationService) GetReservationsByDate(date string) ([]models.Reservation, error) {
-	args := m.Called(date)
+func (m *MockReservationService) GetReservationsByDate(ctx context.Context, date string) ([]models.Reservation, error) {
+	args := m.Called(ctx, date)
 	return args.Get(0).([]models.Reservation), args.Error(1)
 }
 
+func (m *MockReservationService) GetAvailability(ctx context.Context, resourceID uint, date string) ([]models.TimeSlot, error) {
+	args := m.Called(ctx, resourceID, date)
+	return args.Get(0).([]models.TimeSlot), args.Error(1)
+}
+
+func (m *MockReservationService) CreateGroupReservation(ctx context.Context, template *models.Reservation, resourceIDs []uint) ([]models.Reservation, error) {
+	args := m.Called(ctx, template, resourceIDs)
+	reservations, _ := args.Get(0).([]models.Reservation)
+	return reservations, args.Error(1)
+}
+
+func (m *MockReservationService) CancelReservation(ctx context.Context, id, userID uint) ([]models.Reservation, error) {
+	args := m.Called(ctx, id, userID)
+	reservations, _ := args.Get(0).([]models.Reservation)
+	return reservations, args.Error(1)
+}
+
+func (m *MockReservationService) RescheduleReservation(ctx context.Context, id, userID uint, date, startTime, endTime string) ([]models.Reservation, error) {
+	args := m.Called(ctx, id, userID, date, startTime, endTime)
+	reservations, _ := args.Get(0).([]models.Reservation)
+	return reservations, args.Error(1)
+}
+
+func (m *MockReservationService) ImportReservations(ctx context.Context, rows []services.ImportRow, dryRun bool) (*services.ImportReport, error) {
+	args := m.Called(ctx, rows, dryRun)
+	report, _ := args.Get(0).(*services.ImportReport)
+	return report, args.Error(1)
+}
+
 func setupTestFiber() *fiber.App {
-	return fiber.New()
+	return fiber.New(fiber.Config{ErrorHandler: controllers.ErrorHandler})
 }
 
 func createTestToken(userID uint) *jwt.Token {
@@ -63,11 +94,8 @@ func TestReservationController_CreateReservation_Success(t *testing.T) {
 	}
 	body, _ := json.Marshal(input)
 
-	mockService.On("GetReservationsByDate", inputDate).
-		Return([]models.Reservation{}, nil)
-
 	mockService.
-		On("CreateReservation", mock.MatchedBy(func(r *models.Reservation) bool {
+		On("CreateReservation", mock.Anything, mock.MatchedBy(func(r *models.Reservation) bool {
 			return r.UserID == 123 &&
 				r.StartTime == input["start_time"] &&
 				r.EndTime == input["end_time"]
@@ -125,9 +153,7 @@ func TestReservationController_CreateReservation_ServiceError(t *testing.T) {
 	}
 	body, _ := json.Marshal(input)
 
-	mockService.On("GetReservationsByDate", inputDate).
-		Return([]models.Reservation{}, nil)
-	mockService.On("CreateReservation", mock.Anything).
+	mockService.On("CreateReservation", mock.Anything, mock.Anything).
 		Return(errors.New("service error"))
 
 	req := httptest.NewRequest("POST", "/reservations", bytes.NewReader(body))
@@ -137,6 +163,52 @@ func TestReservationController_CreateReservation_ServiceError(t *testing.T) {
 	assert.NoError(t, err)
 	defer resp.Body.Close()
 
-	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	var problem controllers.Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, controllers.CodeInternalError, problem.Code)
+	assert.NotContains(t, problem.Detail, "service error", "unexpected errors must not leak")
+	mockService.AssertExpectations(t)
+}
+
+func TestReservationController_CreateReservation_PolicyViolations(t *testing.T) {
+	app := setupTestFiber()
+	mockService := new(MockReservationService)
+	controller := controllers.NewReservationController(mockService)
+
+	app.Post("/reservations", func(c *fiber.Ctx) error {
+		c.Locals("user", createTestToken(789))
+		return controller.CreateReservation(c)
+	})
+
+	input := map[string]string{
+		"date":       time.Now().Format("2006-01-02"),
+		"start_time": "08:00",
+		"end_time":   "08:30",
+	}
+	body, _ := json.Marshal(input)
+
+	mockService.On("CreateReservation", mock.Anything, mock.Anything).
+		Return(&services.PolicyViolationError{Violations: []services.Violation{
+			{Code: services.CodeOutsideOpeningHours, Message: "reservation must be between 09:00 and 18:00"},
+			{Code: services.CodeDurationTooShort, Field: "end_time", Message: "minimum reservation duration is 1 hour"},
+		}})
+
+	req := httptest.NewRequest("POST", "/reservations", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var payload controllers.Problem
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&payload))
+
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, controllers.MIMEProblemJSON, resp.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, controllers.CodePolicyViolation, payload.Code)
+	assert.Len(t, payload.Violations, 2)
+	assert.Equal(t, services.CodeOutsideOpeningHours, payload.Violations[0].Code)
 	mockService.AssertExpectations(t)
 }
@@ -0,0 +1,141 @@
+package tests
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/services"
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockUserRepository struct {
+	mock.Mock
+}
+
+func (m *MockUserRepository) Create(ctx context.Context, user *models.User) error {
+	return m.Called(ctx, user).Error(0)
+}
+
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
+	args := m.Called(ctx, email)
+	user, _ := args.Get(0).(*models.User)
+	return user, args.Error(1)
+}
+
+func (m *MockUserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	args := m.Called(ctx, id)
+	user, _ := args.Get(0).(*models.User)
+	return user, args.Error(1)
+}
+
+func (m *MockUserRepository) FindByFeedToken(ctx context.Context, token string) (*models.User, error) {
+	args := m.Called(ctx, token)
+	user, _ := args.Get(0).(*models.User)
+	return user, args.Error(1)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, user *models.User) error {
+	return m.Called(ctx, user).Error(0)
+}
+
+func (m *MockUserRepository) IncrementNoShows(ctx context.Context, id uint) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockUserRepository) WithTransaction(ctx context.Context, fn func(repo repositories.UserRepository) error) error {
+	return fn(m)
+}
+
+func (m *MockUserRepository) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	return m.Called(ctx, entry).Error(0)
+}
+
+func TestReservationService_ImportReservations_DryRunReportsEveryRow(t *testing.T) {
+	f := newReservationServiceFixture()
+
+	f.resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithBuffers(), nil)
+	f.ruleRepo.On("FindByResource", mock.Anything, uint(1)).Return([]models.BookingRule{}, nil)
+	f.repo.On("FindOverlapping", mock.Anything, uint(1), "2025-05-23", mock.Anything, mock.Anything).
+		Return([]models.Reservation{}, nil)
+	f.repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	report, err := f.service.ImportReservations(context.Background(), []services.ImportRow{
+		{Line: 2, Reservation: models.Reservation{UserID: 1, ResourceID: 1, Date: "2025-05-23", StartTime: "10:00", EndTime: "11:00"}},
+		{Line: 3, Reservation: models.Reservation{UserID: 1, ResourceID: 1, Date: "2025-05-23", StartTime: "12:00", EndTime: "11:00"}},
+		{Line: 4, Violations: []services.Violation{{Code: services.CodeUnknownUser, Field: "user_email"}}},
+	}, true)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, report.Total)
+	assert.Equal(t, 1, report.Valid)
+	assert.Equal(t, 0, report.Imported)
+	if assert.Len(t, report.Errors, 2) {
+		assert.Equal(t, 3, report.Errors[0].Line)
+		assert.Equal(t, services.CodeInvalidTimeRange, report.Errors[0].Violations[0].Code)
+		assert.Equal(t, 4, report.Errors[1].Line)
+		assert.Equal(t, services.CodeUnknownUser, report.Errors[1].Violations[0].Code)
+	}
+}
+
+func TestReservationCSVService_Import_ReadsColumns(t *testing.T) {
+	reservations := new(MockReservationService)
+	userRepo := new(MockUserRepository)
+	service := services.NewReservationCSVService(reservations, new(MockReservationRepository), userRepo)
+
+	user := &models.User{Email: "ana@example.com"}
+	user.ID = 7
+	userRepo.On("FindByEmail", mock.Anything, "ana@example.com").Return(user, nil).Once()
+	userRepo.On("FindByEmail", mock.Anything, "nadie@example.com").Return(nil, errors.New("user not found"))
+	reservations.On("ImportReservations", mock.Anything, mock.Anything, false).Return(&services.ImportReport{}, nil)
+
+	csv := "\ufeffUser_Email,resource_id,date,start_time,end_time,notes\n" +
+		"Ana@example.com,1,2025-05-23,10:00,11:00,\"sala, grande\"\n" +
+		"ana@example.com,,2025-05-24,10:00,11:00,\n" +
+		"nadie@example.com,x,2025-05-25,10:00,11:00,\n"
+	_, err := service.Import(context.Background(), strings.NewReader(csv), false)
+
+	assert.NoError(t, err)
+	rows := reservations.Calls[0].Arguments.Get(1).([]services.ImportRow)
+	if assert.Len(t, rows, 3) {
+		assert.Equal(t, 2, rows[0].Line)
+		assert.Equal(t, models.Reservation{UserID: 7, ResourceID: 1, Date: "2025-05-23", StartTime: "10:00", EndTime: "11:00"}, rows[0].Reservation)
+		assert.Empty(t, rows[0].Violations)
+		assert.Equal(t, uint(0), rows[1].Reservation.ResourceID)
+		assert.Len(t, rows[2].Violations, 2)
+	}
+	userRepo.AssertExpectations(t)
+}
+
+func TestReservationCSVService_Import_RequiresColumns(t *testing.T) {
+	service := services.NewReservationCSVService(new(MockReservationService), new(MockReservationRepository), new(MockUserRepository))
+
+	_, err := service.Import(context.Background(), strings.NewReader("date,start_time,end_time\n2025-05-23,10:00,11:00\n"), true)
+
+	assert.EqualError(t, err, "invalid CSV: missing column user_id or user_email")
+}
+
+func TestReservationCSVService_Export_WritesRows(t *testing.T) {
+	repo := new(MockReservationRepository)
+	service := services.NewReservationCSVService(new(MockReservationService), repo, new(MockUserRepository))
+
+	groupID := uint(3)
+	first := models.Reservation{UserID: 7, ResourceID: 1, GroupID: &groupID, Date: "2025-05-23", StartTime: "10:00", EndTime: "11:00", Status: models.ReservationConfirmed}
+	first.ID = 1
+	second := models.Reservation{UserID: 8, Date: "2025-05-24", StartTime: "12:00", EndTime: "13:00", Status: models.ReservationCancelled}
+	second.ID = 2
+	repo.On("EachBetween", mock.Anything, "2025-05-23", "2025-05-24", uint(0)).Return([]models.Reservation{first, second}, nil)
+
+	var out bytes.Buffer
+	err := service.Export(context.Background(), &out, "2025-05-23", "2025-05-24", 0)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "id,user_id,resource_id,group_id,date,start_time,end_time,status\n"+
+		"1,7,1,3,2025-05-23,10:00,11:00,confirmed\n"+
+		"2,8,0,,2025-05-24,12:00,13:00,cancelled\n", out.String())
+}
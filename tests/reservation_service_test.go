@@ -0,0 +1,563 @@
+package tests
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/repositories/memory"
+	"booking-api/services"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockReservationRepository struct {
+	mock.Mock
+}
+
+func (m *MockReservationRepository) Create(ctx context.Context, reservation *models.Reservation) error {
+	return m.Called(ctx, reservation).Error(0)
+}
+
+func (m *MockReservationRepository) FindOverlapping(ctx context.Context, resourceID uint, date, start, end string) ([]models.Reservation, error) {
+	args := m.Called(ctx, resourceID, date, start, end)
+	return args.Get(0).([]models.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) FindByDate(ctx context.Context, date string) ([]models.Reservation, error) {
+	args := m.Called(ctx, date)
+	return args.Get(0).([]models.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) FindByResourceAndDate(ctx context.Context, resourceID uint, date string) ([]models.Reservation, error) {
+	args := m.Called(ctx, resourceID, date)
+	return args.Get(0).([]models.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) CountActiveByUser(ctx context.Context, userID uint, date, clock string) (int64, error) {
+	args := m.Called(ctx, userID, date, clock)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockReservationRepository) FindByUserBetween(ctx context.Context, userID uint, from, to string) ([]models.Reservation, error) {
+	args := m.Called(ctx, userID, from, to)
+	return args.Get(0).([]models.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) LockUser(ctx context.Context, userID uint) error {
+	return m.Called(ctx, userID).Error(0)
+}
+
+func (m *MockReservationRepository) WithTransaction(ctx context.Context, fn func(repo repositories.ReservationRepository) error) error {
+	return fn(m)
+}
+
+func (m *MockReservationRepository) FindByID(ctx context.Context, id uint) (*models.Reservation, error) {
+	args := m.Called(ctx, id)
+	reservation, _ := args.Get(0).(*models.Reservation)
+	return reservation, args.Error(1)
+}
+
+func (m *MockReservationRepository) Update(ctx context.Context, reservation *models.Reservation) error {
+	return m.Called(ctx, reservation).Error(0)
+}
+
+func (m *MockReservationRepository) FindUncheckedStartedBefore(ctx context.Context, date, clock string) ([]models.Reservation, error) {
+	args := m.Called(ctx, date, clock)
+	return args.Get(0).([]models.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) MarkNoShow(ctx context.Context, reservation *models.Reservation) (bool, error) {
+	args := m.Called(ctx, reservation)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockReservationRepository) MarkCheckedIn(ctx context.Context, reservation *models.Reservation, at time.Time) (bool, error) {
+	args := m.Called(ctx, reservation, at)
+	if args.Bool(0) {
+		reservation.Status = models.ReservationCheckedIn
+		reservation.CheckedInAt = &at
+	}
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockReservationRepository) CreateGroup(ctx context.Context, group *models.BookingGroup) error {
+	return m.Called(ctx, group).Error(0)
+}
+
+func (m *MockReservationRepository) FindByGroup(ctx context.Context, groupID uint) ([]models.Reservation, error) {
+	args := m.Called(ctx, groupID)
+	return args.Get(0).([]models.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) FindByUserFrom(ctx context.Context, userID uint, from string) ([]models.Reservation, error) {
+	args := m.Called(ctx, userID, from)
+	return args.Get(0).([]models.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) FindByResourceFrom(ctx context.Context, resourceID uint, from string) ([]models.Reservation, error) {
+	args := m.Called(ctx, resourceID, from)
+	return args.Get(0).([]models.Reservation), args.Error(1)
+}
+
+func (m *MockReservationRepository) EachBetween(ctx context.Context, from, to string, userID uint, fn func(models.Reservation) error) error {
+	args := m.Called(ctx, from, to, userID)
+	reservations, _ := args.Get(0).([]models.Reservation)
+	for _, res := range reservations {
+		if err := fn(res); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockReservationRepository) CreateOutboxEvent(ctx context.Context, event *models.OutboxEvent) error {
+	return m.Called(ctx, event).Error(0)
+}
+
+func (m *MockReservationRepository) AppendAudit(ctx context.Context, entry *models.AuditEntry) error {
+	return m.Called(ctx, entry).Error(0)
+}
+
+type MockBookingRuleRepository struct {
+	mock.Mock
+}
+
+func (m *MockBookingRuleRepository) Create(ctx context.Context, rule *models.BookingRule) error {
+	return m.Called(ctx, rule).Error(0)
+}
+
+func (m *MockBookingRuleRepository) FindByID(ctx context.Context, id uint) (*models.BookingRule, error) {
+	args := m.Called(ctx, id)
+	rule, _ := args.Get(0).(*models.BookingRule)
+	return rule, args.Error(1)
+}
+
+func (m *MockBookingRuleRepository) FindByResource(ctx context.Context, resourceID uint) ([]models.BookingRule, error) {
+	args := m.Called(ctx, resourceID)
+	return args.Get(0).([]models.BookingRule), args.Error(1)
+}
+
+func (m *MockBookingRuleRepository) Update(ctx context.Context, rule *models.BookingRule) error {
+	return m.Called(ctx, rule).Error(0)
+}
+
+func (m *MockBookingRuleRepository) Delete(ctx context.Context, rule *models.BookingRule) error {
+	return m.Called(ctx, rule).Error(0)
+}
+
+type MockResourceRepository struct {
+	mock.Mock
+}
+
+func (m *MockResourceRepository) Create(ctx context.Context, resource *models.Resource) error {
+	return m.Called(ctx, resource).Error(0)
+}
+
+func (m *MockResourceRepository) FindByID(ctx context.Context, id uint) (*models.Resource, error) {
+	args := m.Called(ctx, id)
+	resource, _ := args.Get(0).(*models.Resource)
+	return resource, args.Error(1)
+}
+
+func (m *MockResourceRepository) FindAll(ctx context.Context) ([]models.Resource, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.Resource), args.Error(1)
+}
+
+func (m *MockResourceRepository) FindByFeedToken(ctx context.Context, token string) (*models.Resource, error) {
+	args := m.Called(ctx, token)
+	resource, _ := args.Get(0).(*models.Resource)
+	return resource, args.Error(1)
+}
+
+func (m *MockResourceRepository) Update(ctx context.Context, resource *models.Resource) error {
+	return m.Called(ctx, resource).Error(0)
+}
+
+type MockQuotaService struct {
+	mock.Mock
+}
+
+func (m *MockQuotaService) LimitsFor(ctx context.Context, userID uint) (models.QuotaLimits, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(models.QuotaLimits), args.Error(1)
+}
+
+func (m *MockQuotaService) LimitsIn(ctx context.Context, tx repositories.Tx, userID uint) (models.QuotaLimits, error) {
+	args := m.Called(ctx, tx, userID)
+	return args.Get(0).(models.QuotaLimits), args.Error(1)
+}
+
+func (m *MockQuotaService) GetQuotaStatus(ctx context.Context, userID uint) (*models.QuotaStatus, error) {
+	args := m.Called(ctx, userID)
+	status, _ := args.Get(0).(*models.QuotaStatus)
+	return status, args.Error(1)
+}
+
+func (m *MockQuotaService) SetRoleQuota(ctx context.Context, role string, limits models.QuotaLimits) (*models.Quota, error) {
+	args := m.Called(ctx, role, limits)
+	quota, _ := args.Get(0).(*models.Quota)
+	return quota, args.Error(1)
+}
+
+func (m *MockQuotaService) SetUserQuota(ctx context.Context, userID uint, limits models.QuotaLimits) (*models.Quota, error) {
+	args := m.Called(ctx, userID, limits)
+	quota, _ := args.Get(0).(*models.Quota)
+	return quota, args.Error(1)
+}
+
+type reservationServiceFixture struct {
+	repo         *MockReservationRepository
+	resourceRepo *MockResourceRepository
+	ruleRepo     *MockBookingRuleRepository
+	quotas       *MockQuotaService
+	service      services.ReservationService
+}
+
+// newReservationServiceFixture wires the service with mocks. Quotas are
+// unlimited and user locks and outbox writes always succeed.
+func newReservationServiceFixture() *reservationServiceFixture {
+	return newReservationServiceFixtureWithQuota(models.QuotaLimits{})
+}
+
+func newReservationServiceFixtureWithQuota(limits models.QuotaLimits) *reservationServiceFixture {
+	f := &reservationServiceFixture{
+		repo:         new(MockReservationRepository),
+		resourceRepo: new(MockResourceRepository),
+		ruleRepo:     new(MockBookingRuleRepository),
+		quotas:       new(MockQuotaService),
+	}
+	uow := fakeUnitOfWork{Reservations: f.repo, Resources: f.resourceRepo, Rules: f.ruleRepo}
+	f.service = services.NewReservationService(f.repo, f.resourceRepo, f.ruleRepo, uow, f.quotas)
+	f.quotas.On("LimitsIn", mock.Anything, mock.Anything, mock.Anything).Return(limits, nil).Maybe()
+	f.repo.On("LockUser", mock.Anything, mock.Anything).Return(nil).Maybe()
+	f.repo.On("CreateOutboxEvent", mock.Anything, mock.Anything).Return(nil).Maybe()
+	f.repo.On("AppendAudit", mock.Anything, mock.Anything).Return(nil).Maybe()
+	return f
+}
+
+func roomWithBuffers() *models.Resource {
+	room := &models.Resource{Name: "Room A", PreBufferMinutes: 15, PostBufferMinutes: 15}
+	room.ID = 1
+	return room
+}
+
+func TestReservationService_CreateReservation_ChecksBufferedWindow(t *testing.T) {
+	f := newReservationServiceFixture()
+
+	f.resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithBuffers(), nil)
+	f.ruleRepo.On("FindByResource", mock.Anything, uint(1)).Return([]models.BookingRule{}, nil)
+	f.repo.On("FindOverlapping", mock.Anything, uint(1), "2025-05-23", "09:45", "11:15").
+		Return([]models.Reservation{}, nil)
+	f.repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	err := f.service.CreateReservation(context.Background(), &models.Reservation{
+		ResourceID: 1,
+		Date:       "2025-05-23",
+		StartTime:  "10:00",
+		EndTime:    "11:00",
+	})
+
+	assert.NoError(t, err)
+	f.repo.AssertExpectations(t)
+}
+
+func TestReservationService_CreateReservation_UnknownResource(t *testing.T) {
+	f := newReservationServiceFixture()
+
+	f.resourceRepo.On("FindByID", mock.Anything, uint(9)).Return(nil, errors.New("resource not found"))
+
+	err := f.service.CreateReservation(context.Background(), &models.Reservation{
+		ResourceID: 9,
+		Date:       "2025-05-23",
+		StartTime:  "10:00",
+		EndTime:    "11:00",
+	})
+
+	assert.EqualError(t, err, "resource not found")
+	f.repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestReservationService_GetAvailability_HidesBuffers(t *testing.T) {
+	f := newReservationServiceFixture()
+
+	f.resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithBuffers(), nil)
+	f.ruleRepo.On("FindByResource", mock.Anything, uint(1)).Return([]models.BookingRule{}, nil)
+	f.repo.On("FindByResourceAndDate", mock.Anything, uint(1), "2025-05-23").Return([]models.Reservation{
+		{ResourceID: 1, Date: "2025-05-23", StartTime: "10:00", EndTime: "11:00"},
+	}, nil)
+
+	slots, err := f.service.GetAvailability(context.Background(), 1, "2025-05-23")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.TimeSlot{
+		{StartTime: "09:00", EndTime: "09:45"},
+		{StartTime: "11:15", EndTime: "18:00"},
+	}, slots)
+}
+
+func TestReservationService_BuffersApplyOnTheirOwnSide(t *testing.T) {
+	store := memory.NewStore()
+	user := &models.User{Name: "Ana", Email: "ana@example.com"}
+	require.NoError(t, memory.NewUserRepository(store).Create(context.Background(), user))
+	room := &models.Resource{Name: "Room A", PreBufferMinutes: 60}
+	require.NoError(t, memory.NewResourceRepository(store).Create(context.Background(), room))
+	service := memoryReservationService(store, models.QuotaLimits{})
+	book := func(start, end string) error {
+		return service.CreateReservation(context.Background(), &models.Reservation{UserID: user.ID, ResourceID: 1, Date: "2025-05-23", StartTime: start, EndTime: end})
+	}
+
+	require.NoError(t, book("12:00", "13:00"))
+	assert.NoError(t, book("13:00", "14:00"), "no cleanup time is needed after a reservation")
+	assert.ErrorIs(t, book("10:30", "11:30"), services.ErrOverlap, "the setup time before a reservation is kept")
+	assert.NoError(t, book("10:00", "11:00"))
+
+	slots, err := service.GetAvailability(context.Background(), 1, "2025-05-23")
+	require.NoError(t, err)
+	assert.Equal(t, []models.TimeSlot{{StartTime: "14:00", EndTime: "18:00"}}, slots)
+}
+
+func TestReservationService_DefaultRulesApplyUnlessReplaced(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	user := &models.User{Name: "Ana", Email: "ana@example.com"}
+	require.NoError(t, memory.NewUserRepository(store).Create(ctx, user))
+	resources := memory.NewResourceRepository(store)
+	rules := memory.NewBookingRuleRepository(store)
+	limited := &models.Resource{Name: "Room A"}
+	require.NoError(t, resources.Create(ctx, limited))
+	require.NoError(t, rules.Create(ctx, &models.BookingRule{ResourceID: limited.ID, Kind: services.RuleMaxDuration, Value: "120"}))
+	early := &models.Resource{Name: "Room B"}
+	require.NoError(t, resources.Create(ctx, early))
+	require.NoError(t, rules.Create(ctx, &models.BookingRule{ResourceID: early.ID, Kind: services.RuleOpeningHours, Value: "07:00-22:00"}))
+	service := memoryReservationService(store, models.QuotaLimits{})
+	violations := func(resourceID uint, start, end string) []string {
+		err := service.CreateReservation(ctx, &models.Reservation{UserID: user.ID, ResourceID: resourceID, Date: "2025-05-23", StartTime: start, EndTime: end})
+		var policyErr *services.PolicyViolationError
+		if !errors.As(err, &policyErr) {
+			require.NoError(t, err)
+			return nil
+		}
+		var codes []string
+		for _, v := range policyErr.Violations {
+			codes = append(codes, v.Code)
+		}
+		return codes
+	}
+
+	assert.Equal(t, []string{services.CodeOutsideOpeningHours, services.CodeDurationTooShort}, violations(limited.ID, "07:00", "07:30"),
+		"a rule of another kind keeps the defaults")
+	assert.Equal(t, []string{services.CodeDurationTooLong}, violations(limited.ID, "10:00", "13:00"))
+	assert.Empty(t, violations(early.ID, "07:00", "08:00"), "a rule replaces the default of its kind")
+	assert.Equal(t, []string{services.CodeDurationTooShort}, violations(early.ID, "20:00", "20:30"))
+}
+
+func TestReservationService_CreateReservation_ReturnsAllViolations(t *testing.T) {
+	f := newReservationServiceFixture()
+
+	f.resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithBuffers(), nil)
+	f.ruleRepo.On("FindByResource", mock.Anything, uint(1)).Return([]models.BookingRule{
+		{Kind: services.RuleOpeningHours, Value: "09:00-18:00"},
+		{Kind: services.RuleMinDuration, Value: "60"},
+		{Kind: services.RuleAllowedWeekdays, Value: "mon,tue,wed,thu,fri"},
+	}, nil)
+	f.repo.On("FindOverlapping", mock.Anything, uint(1), "2025-05-24", "07:45", "08:45").
+		Return([]models.Reservation{{ResourceID: 1}}, nil)
+
+	// 2025-05-24 is a Saturday.
+	err := f.service.CreateReservation(context.Background(), &models.Reservation{
+		ResourceID: 1,
+		Date:       "2025-05-24",
+		StartTime:  "08:00",
+		EndTime:    "08:30",
+	})
+
+	var policyErr *services.PolicyViolationError
+	assert.ErrorAs(t, err, &policyErr)
+
+	codes := make([]string, len(policyErr.Violations))
+	for i, v := range policyErr.Violations {
+		codes[i] = v.Code
+	}
+	assert.Equal(t, []string{
+		services.CodeOutsideOpeningHours,
+		services.CodeDurationTooShort,
+		services.CodeWeekdayNotAllowed,
+		services.CodeOverlap,
+	}, codes)
+	f.repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestReservationService_CreateReservation_MaxActiveBookings(t *testing.T) {
+	f := newReservationServiceFixture()
+
+	f.resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithBuffers(), nil)
+	f.ruleRepo.On("FindByResource", mock.Anything, uint(1)).Return([]models.BookingRule{
+		{Kind: services.RuleMaxActiveBookings, Value: "2"},
+	}, nil)
+	f.repo.On("CountActiveByUser", mock.Anything, uint(7), mock.Anything, mock.Anything).Return(int64(2), nil)
+	f.repo.On("FindOverlapping", mock.Anything, uint(1), "2025-05-23", "09:45", "11:15").
+		Return([]models.Reservation{}, nil)
+
+	err := f.service.CreateReservation(context.Background(), &models.Reservation{
+		UserID:     7,
+		ResourceID: 1,
+		Date:       "2025-05-23",
+		StartTime:  "10:00",
+		EndTime:    "11:00",
+	})
+
+	var policyErr *services.PolicyViolationError
+	assert.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, services.CodeTooManyActive, policyErr.Violations[0].Code)
+}
+
+func TestReservationService_CreateReservation_QuotaExceeded(t *testing.T) {
+	f := newReservationServiceFixtureWithQuota(models.QuotaLimits{HoursPerWeek: 3, BookingsPerDay: 1})
+
+	f.resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithBuffers(), nil)
+	f.ruleRepo.On("FindByResource", mock.Anything, uint(1)).Return([]models.BookingRule{}, nil)
+	f.repo.On("FindByUserBetween", mock.Anything, uint(7), "2025-05-19", "2025-05-25").Return([]models.Reservation{
+		{UserID: 7, ResourceID: 1, Date: "2025-05-23", StartTime: "14:00", EndTime: "16:00"},
+	}, nil)
+	f.repo.On("CountActiveByUser", mock.Anything, uint(7), mock.Anything, mock.Anything).Return(int64(0), nil)
+	f.repo.On("FindOverlapping", mock.Anything, uint(1), "2025-05-23", "09:45", "12:15").
+		Return([]models.Reservation{}, nil)
+
+	err := f.service.CreateReservation(context.Background(), &models.Reservation{
+		UserID:     7,
+		ResourceID: 1,
+		Date:       "2025-05-23",
+		StartTime:  "10:00",
+		EndTime:    "12:00",
+	})
+
+	var policyErr *services.PolicyViolationError
+	assert.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, services.CodeQuotaHoursPerWeek, policyErr.Violations[0].Code)
+	assert.Equal(t, services.CodeQuotaBookingsPerDay, policyErr.Violations[1].Code)
+	f.repo.AssertCalled(t, "LockUser", mock.Anything, uint(7))
+	f.repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func projector() *models.Resource {
+	projector := &models.Resource{Name: "Projector"}
+	projector.ID = 2
+	return projector
+}
+
+func TestReservationService_CreateGroupReservation_AllOrNothing(t *testing.T) {
+	f := newReservationServiceFixture()
+
+	f.resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithBuffers(), nil)
+	f.resourceRepo.On("FindByID", mock.Anything, uint(2)).Return(projector(), nil)
+	f.ruleRepo.On("FindByResource", mock.Anything, mock.Anything).Return([]models.BookingRule{}, nil)
+	f.repo.On("FindOverlapping", mock.Anything, uint(1), "2025-05-23", "09:45", "11:15").
+		Return([]models.Reservation{}, nil)
+	f.repo.On("FindOverlapping", mock.Anything, uint(2), "2025-05-23", "10:00", "11:00").
+		Return([]models.Reservation{{ResourceID: 2}}, nil)
+
+	_, err := f.service.CreateGroupReservation(context.Background(), &models.Reservation{
+		UserID:    7,
+		Date:      "2025-05-23",
+		StartTime: "10:00",
+		EndTime:   "11:00",
+	}, []uint{1, 2})
+
+	var policyErr *services.PolicyViolationError
+	assert.ErrorAs(t, err, &policyErr)
+	assert.Equal(t, []services.Violation{{
+		Code:       services.CodeOverlap,
+		ResourceID: 2,
+		Message:    "reservation time overlaps with an existing reservation",
+	}}, policyErr.Violations)
+	f.repo.AssertNotCalled(t, "CreateGroup", mock.Anything, mock.Anything)
+	f.repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestReservationService_CreateGroupReservation_Success(t *testing.T) {
+	f := newReservationServiceFixture()
+
+	f.resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithBuffers(), nil)
+	f.resourceRepo.On("FindByID", mock.Anything, uint(2)).Return(projector(), nil)
+	f.ruleRepo.On("FindByResource", mock.Anything, mock.Anything).Return([]models.BookingRule{}, nil)
+	f.repo.On("FindOverlapping", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]models.Reservation{}, nil)
+	f.repo.On("CreateGroup", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.BookingGroup).ID = 5
+	}).Return(nil)
+	f.repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	reservations, err := f.service.CreateGroupReservation(context.Background(), &models.Reservation{
+		UserID:    7,
+		Date:      "2025-05-23",
+		StartTime: "10:00",
+		EndTime:   "11:00",
+	}, []uint{1, 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, reservations, 2)
+	for _, res := range reservations {
+		assert.Equal(t, uint(5), *res.GroupID)
+	}
+	f.repo.AssertNumberOfCalls(t, "Create", 2)
+}
+
+func TestReservationService_RescheduleReservation_MovesWholeGroup(t *testing.T) {
+	f := newReservationServiceFixture()
+
+	groupID := uint(5)
+	date := time.Now().AddDate(0, 0, 7).Format("2006-01-02")
+	member := func(id, resourceID uint) models.Reservation {
+		res := models.Reservation{UserID: 7, ResourceID: resourceID, GroupID: &groupID, Date: date,
+			StartTime: "10:00", EndTime: "11:00", Status: models.ReservationConfirmed}
+		res.ID = id
+		return res
+	}
+	first, second := member(11, 1), member(12, 2)
+
+	f.repo.On("FindByID", mock.Anything, uint(11)).Return(&first, nil)
+	f.repo.On("FindByID", mock.Anything, uint(12)).Return(&second, nil)
+	f.repo.On("FindByGroup", mock.Anything, groupID).Return([]models.Reservation{member(11, 1), member(12, 2)}, nil)
+	f.resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithBuffers(), nil)
+	f.resourceRepo.On("FindByID", mock.Anything, uint(2)).Return(projector(), nil)
+	f.ruleRepo.On("FindByResource", mock.Anything, mock.Anything).Return([]models.BookingRule{}, nil)
+	// The old slot of the group overlaps the new one and must be ignored.
+	f.repo.On("FindOverlapping", mock.Anything, uint(1), date, "10:15", "11:45").Return([]models.Reservation{member(11, 1)}, nil)
+	f.repo.On("FindOverlapping", mock.Anything, uint(2), date, "10:30", "11:30").Return([]models.Reservation{member(12, 2)}, nil)
+	f.repo.On("Update", mock.Anything, mock.Anything).Return(nil)
+
+	reservations, err := f.service.RescheduleReservation(context.Background(), 11, 7, date, "10:30", "11:30")
+
+	assert.NoError(t, err)
+	for _, res := range reservations {
+		assert.Equal(t, "10:30", res.StartTime)
+		assert.Equal(t, "11:30", res.EndTime)
+	}
+	f.repo.AssertNumberOfCalls(t, "Update", 2)
+	for _, id := range []uint{11, 12} {
+		f.repo.AssertCalled(t, "AppendAudit", mock.Anything, mock.MatchedBy(func(e *models.AuditEntry) bool {
+			return e.Action == models.EventReservationRescheduled && e.TargetID == id &&
+				strings.Contains(e.Changes, `"start_time":{"before":"10:00","after":"10:30"}`)
+		}))
+	}
+}
+
+func TestReservationService_CancelReservation_OtherUser(t *testing.T) {
+	f := newReservationServiceFixture()
+
+	res := &models.Reservation{UserID: 8, Date: "2099-01-01", StartTime: "10:00", EndTime: "11:00", Status: models.ReservationConfirmed}
+	res.ID = 11
+	f.repo.On("FindByID", mock.Anything, uint(11)).Return(res, nil)
+
+	_, err := f.service.CancelReservation(context.Background(), 11, 7)
+
+	assert.EqualError(t, err, "reservation belongs to another user")
+	f.repo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/repositories/memory"
+	"booking-api/services"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceService_UpdateAndDeleteRules(t *testing.T) {
+	store := memory.NewStore()
+	ctx := context.Background()
+	rules := memory.NewBookingRuleRepository(store)
+	service := services.NewResourceService(memory.NewResourceRepository(store), rules)
+	require.NoError(t, service.CreateResource(ctx, &models.Resource{Name: "Room A"}))
+	rule := &models.BookingRule{ResourceID: 1, Kind: services.RuleMaxDuration, Value: "120"}
+	require.NoError(t, service.AddRule(ctx, rule))
+
+	_, err := service.UpdateRule(ctx, rule.ID, services.RuleMaxDuration, "soon")
+	assert.Equal(t, services.CodeInvalidRule, serviceErrorCode(t, err))
+	updated, err := service.UpdateRule(ctx, rule.ID, services.RuleOpeningHours, "07:00-22:00")
+	require.NoError(t, err)
+	assert.Equal(t, uint(1), updated.ResourceID, "the rule stays on its resource")
+	stored, err := rules.FindByID(ctx, rule.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "07:00-22:00", stored.Value)
+
+	require.NoError(t, service.DeleteRule(ctx, rule.ID))
+	remaining, err := service.GetRules(ctx, 1)
+	require.NoError(t, err)
+	assert.Empty(t, remaining)
+
+	var notFound *repositories.NotFoundError
+	assert.ErrorAs(t, service.DeleteRule(ctx, rule.ID), &notFound)
+	_, err = service.UpdateRule(ctx, rule.ID, services.RuleMaxDuration, "60")
+	assert.ErrorAs(t, err, &notFound)
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"booking-api/models"
+	"booking-api/utils"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoutes_UnversionedAliasesAreDeprecated(t *testing.T) {
+	app := newRoutedApp()
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/openapi.json", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get("Deprecation"))
+	assert.Empty(t, resp.Header.Get("Sunset"))
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/openapi.json", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Regexp(t, `^@\d+$`, resp.Header.Get("Deprecation"))
+	assert.Equal(t, "Fri, 30 Apr 2027 00:00:00 GMT", resp.Header.Get("Sunset"))
+	assert.Equal(t, `</v1/openapi.json>; rel="successor-version"`, resp.Header.Get(fiber.HeaderLink))
+}
+
+func TestRoutes_AliasesKeepMiddleware(t *testing.T) {
+	app := newRoutedApp()
+
+	for _, path := range []string{"/v1/reservations", "/reservations"} {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode, path)
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/v2/reservations", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode, "unknown versions are not served")
+}
+
+func TestRoutes_ResourceManagementIsForAdmins(t *testing.T) {
+	app := newRoutedApp()
+	token, err := utils.GenerateJWT("secret", time.Hour, 7, models.RoleUser)
+	require.NoError(t, err)
+
+	for _, route := range [][2]string{{"POST", "/v1/resources"}, {"POST", "/v1/resources/1/rules"}, {"GET", "/v1/resources/1/rules"}} {
+		req := httptest.NewRequest(route[0], route[1], nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusForbidden, resp.StatusCode, route[0]+" "+route[1])
+	}
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	"booking-api/models"
+	"booking-api/realtime"
+	"booking-api/services"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOutboxRepository struct {
+	mock.Mock
+}
+
+func (m *MockOutboxRepository) FindAfter(ctx context.Context, id uint, limit int) ([]models.OutboxEvent, error) {
+	args := m.Called(ctx, id, limit)
+	return args.Get(0).([]models.OutboxEvent), args.Error(1)
+}
+
+func (m *MockOutboxRepository) LatestID(ctx context.Context) (uint, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(uint), args.Error(1)
+}
+
+func TestMemoryBroker_FiltersAndDropsSlowSubscribers(t *testing.T) {
+	broker := realtime.NewMemoryBroker()
+	roomOne, unsubscribe := broker.Subscribe(func(e realtime.Event) bool { return e.Reservation.ResourceID == 1 })
+	defer unsubscribe()
+	slow, _ := broker.Subscribe(nil)
+
+	for i := 1; i <= 100; i++ {
+		broker.Publish(realtime.Event{ID: uint(i), Reservation: models.Reservation{ResourceID: uint(i % 2)}})
+	}
+
+	assert.Len(t, roomOne, 50)
+	assert.Equal(t, uint(1), (<-roomOne).ID)
+	assert.Equal(t, 1, broker.Subscribers())
+	received := 0
+	for range slow {
+		received++
+	}
+	assert.Less(t, received, 100)
+}
+
+func TestMemoryBroker_CloseEndsEverySubscription(t *testing.T) {
+	broker := realtime.NewMemoryBroker()
+	open, _ := broker.Subscribe(nil)
+
+	broker.Close()
+	_, ok := <-open
+	assert.False(t, ok)
+
+	late, unsubscribe := broker.Subscribe(nil)
+	_, ok = <-late
+	assert.False(t, ok, "subscriptions after Close end at once")
+	unsubscribe()
+	assert.Equal(t, 0, broker.Subscribers())
+}
+
+func TestStreamFilter_MatchesBothSlotsOfARescheduledReservation(t *testing.T) {
+	resourceID := uint(1)
+	filter := services.StreamFilter{Date: "2025-05-23", ResourceID: &resourceID}
+
+	moved := models.Reservation{ResourceID: 1, Date: "2025-05-24", Previous: &models.ReservationSlot{Date: "2025-05-23"}}
+	otherRoom := models.Reservation{ResourceID: 2, Date: "2025-05-23"}
+	otherDay := models.Reservation{ResourceID: 1, Date: "2025-05-25"}
+
+	assert.True(t, filter.Matches(moved))
+	assert.False(t, filter.Matches(otherRoom))
+	assert.False(t, filter.Matches(otherDay))
+	assert.True(t, services.StreamFilter{}.Matches(otherDay))
+}
+
+func TestStreamService_Relay_PublishesNewEvents(t *testing.T) {
+	repo := new(MockOutboxRepository)
+	broker := realtime.NewMemoryBroker()
+	service := services.NewStreamService(repo, broker)
+
+	repo.On("LatestID", mock.Anything).Return(uint(10), nil)
+	repo.On("FindAfter", mock.Anything, uint(10), mock.Anything).Return([]models.OutboxEvent{
+		{ID: 11, Type: models.EventReservationCreated, Payload: `{"ID":1,"date":"2025-05-23"}`},
+		{ID: 12, Type: models.EventReservationCancelled, Payload: `{"ID":2,"date":"2025-05-24"}`},
+	}, nil).Once()
+	repo.On("FindAfter", mock.Anything, uint(12), mock.Anything).Return([]models.OutboxEvent{}, nil)
+
+	events, unsubscribe := service.Subscribe(services.StreamFilter{Date: "2025-05-23"})
+	defer unsubscribe()
+
+	_, err := service.Relay(context.Background())
+	assert.NoError(t, err)
+	relayed, err := service.Relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, relayed)
+	relayed, err = service.Relay(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, relayed)
+
+	if assert.Len(t, events, 1) {
+		event := <-events
+		assert.Equal(t, uint(11), event.ID)
+		assert.Equal(t, models.EventReservationCreated, event.Type)
+		assert.Equal(t, uint(1), event.Reservation.ID)
+	}
+}
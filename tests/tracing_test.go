@@ -0,0 +1,155 @@
+package tests
+
+import (
+	"booking-api/database"
+	"booking-api/middlewares"
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/services"
+	"booking-api/tracing"
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans installs a tracer provider keeping the ended spans in memory
+// for the rest of the test.
+func recordSpans(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+	return exporter
+}
+
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	for _, span := range spans {
+		if span.Name == name {
+			return span
+		}
+	}
+	require.Failf(t, "span not found", "no span named %q", name)
+	return tracetest.SpanStub{}
+}
+
+func spanAttribute(span tracetest.SpanStub, key string) attribute.Value {
+	for _, attr := range span.Attributes {
+		if string(attr.Key) == key {
+			return attr.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+func TestTracing_ContinuesIncomingTrace(t *testing.T) {
+	exporter := recordSpans(t)
+	app := setupFiber()
+	app.Use(middlewares.Tracing())
+	app.Use(middlewares.Metrics())
+	app.Get("/items/:id", func(c *fiber.Ctx) error {
+		_, span := tracing.Start(c.UserContext(), "handler")
+		span.End()
+		return fiber.ErrInternalServerError
+	})
+
+	req := httptest.NewRequest("GET", "/items/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	spans := exporter.GetSpans()
+	server := findSpan(t, spans, "GET /items/:id")
+	assert.Equal(t, trace.SpanKindServer, server.SpanKind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext.TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", server.Parent.SpanID().String())
+	assert.True(t, server.Parent.IsRemote())
+	assert.Equal(t, int64(fiber.StatusInternalServerError), spanAttribute(server, "http.response.status_code").AsInt64())
+	assert.Equal(t, codes.Error, server.Status.Code, "server errors fail the span")
+
+	handler := findSpan(t, spans, "handler")
+	assert.Equal(t, server.SpanContext.SpanID(), handler.Parent.SpanID(), "handlers see the request span")
+}
+
+func TestTracing_DoesNotRecordFeedTokens(t *testing.T) {
+	exporter := recordSpans(t)
+	app := setupFiber()
+	app.Use(middlewares.Tracing())
+	app.Get("/v1/feeds/:token/calendar.ics", func(c *fiber.Ctx) error {
+		return c.SendString("BEGIN:VCALENDAR")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/v1/feeds/secret-feed-token/calendar.ics", nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	server := findSpan(t, exporter.GetSpans(), "GET /v1/feeds/:token/calendar.ics")
+	for _, attr := range server.Attributes {
+		assert.NotContains(t, attr.Value.Emit(), "secret-feed-token", "attribute %s", attr.Key)
+	}
+}
+
+func TestTracing_ClientErrorsDoNotFailRequestSpan(t *testing.T) {
+	exporter := recordSpans(t)
+	app := setupFiber()
+	app.Use(middlewares.Tracing())
+	app.Use(middlewares.Metrics())
+	app.Get("/items/:id", func(c *fiber.Ctx) error { return fiber.ErrConflict })
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/items/1", nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	resp, err = app.Test(httptest.NewRequest("GET", "/nothing/here", nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	spans := exporter.GetSpans()
+	conflict := findSpan(t, spans, "GET /items/:id")
+	assert.False(t, conflict.Parent.IsValid(), "requests without traceparent start a trace")
+	assert.Equal(t, codes.Unset, conflict.Status.Code)
+	findSpan(t, spans, "GET unmatched")
+}
+
+func TestTracing_SpansServiceAndQueries(t *testing.T) {
+	exporter := recordSpans(t)
+	db := openMigrationDB(t, filepath.Join(t.TempDir(), "booking.db"))
+	migrator, err := database.NewMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	require.NoError(t, database.Instrument(db))
+	service := services.NewAuthService(repositories.NewUserRepository(db), testAuthSettings)
+
+	ctx, root := tracing.Start(context.Background(), "request")
+	err = service.Register(ctx, &models.User{Name: "Ana", Email: "ana@example.com", Password: "secret"})
+	root.End()
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	register := findSpan(t, spans, "AuthService.Register")
+	assert.Equal(t, root.SpanContext().SpanID(), register.Parent.SpanID())
+	assert.Equal(t, register.SpanContext.SpanID(), findSpan(t, spans, "bcrypt.hash").Parent.SpanID())
+
+	lookup := findSpan(t, spans, "query users")
+	assert.Equal(t, trace.SpanKindClient, lookup.SpanKind)
+	assert.Equal(t, register.SpanContext.SpanID(), lookup.Parent.SpanID())
+	assert.Equal(t, codes.Unset, lookup.Status.Code, "finding nothing is not an error")
+	query := spanAttribute(lookup, "db.query.text").AsString()
+	assert.Contains(t, query, "users")
+	assert.NotContains(t, query, "ana@example.com", "statements are recorded without their values")
+
+	insert := findSpan(t, spans, "create users")
+	assert.Equal(t, register.SpanContext.SpanID(), insert.Parent.SpanID())
+}
@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"booking-api/controllers"
+	"booking-api/database"
+	"booking-api/middlewares"
+	"booking-api/models"
+	"booking-api/repositories"
+	"booking-api/services"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// migratedDB returns a SQLite database with every migration applied.
+func migratedDB(t *testing.T) *gorm.DB {
+	db := openMigrationDB(t, filepath.Join(t.TempDir(), "booking.db"))
+	migrator, err := database.NewMigrator(db)
+	require.NoError(t, err)
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	return db
+}
+
+func TestUnitOfWork_CommitsOrRollsBackEveryRepository(t *testing.T) {
+	db := migratedDB(t)
+	uow := repositories.NewUnitOfWork(db)
+	ctx := context.Background()
+	errRollback := errors.New("rollback")
+
+	save := func(email string, result error) error {
+		return uow.Do(ctx, func(tx repositories.Tx) error {
+			user := &models.User{Name: "Ana", Email: email, Password: "hash"}
+			if err := tx.Users.Create(ctx, user); err != nil {
+				return err
+			}
+			reservation := &models.Reservation{UserID: user.ID, ResourceID: 1, Date: "2025-05-20", StartTime: "10:00", EndTime: "11:00"}
+			if err := tx.Reservations.Create(ctx, reservation); err != nil {
+				return err
+			}
+			return result
+		})
+	}
+
+	require.ErrorIs(t, save("ana@example.com", errRollback), errRollback)
+	var users, reservations int64
+	require.NoError(t, db.Model(&models.User{}).Count(&users).Error)
+	require.NoError(t, db.Model(&models.Reservation{}).Count(&reservations).Error)
+	assert.Zero(t, users)
+	assert.Zero(t, reservations)
+
+	require.NoError(t, save("bea@example.com", nil))
+	require.NoError(t, db.Model(&models.User{}).Count(&users).Error)
+	require.NoError(t, db.Model(&models.Reservation{}).Count(&reservations).Error)
+	assert.Equal(t, int64(1), users)
+	assert.Equal(t, int64(1), reservations)
+}
+
+func TestCheckInService_ReleaseNoShows_CountsAgainstUser(t *testing.T) {
+	db := migratedDB(t)
+	ctx := context.Background()
+	users := repositories.NewUserRepository(db)
+	reservations := repositories.NewReservationRepository(db)
+
+	user := &models.User{Name: "Ana", Email: "ana@example.com", Password: "hash"}
+	require.NoError(t, users.Create(ctx, user))
+	late := reservationStartingIn(-time.Hour)
+	late.ID = 0
+	late.UserID = user.ID
+	require.NoError(t, reservations.Create(ctx, late))
+
+	service := services.NewCheckInService(reservations, repositories.NewResourceRepository(db), repositories.NewUnitOfWork(db), checkInSettings)
+	released, err := service.ReleaseNoShows(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, released)
+
+	stored, err := users.FindByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.NoShowCount)
+	res, err := reservations.FindByID(ctx, late.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.ReservationNoShow, res.Status)
+
+	released, err = service.ReleaseNoShows(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, released, "a released reservation is not counted twice")
+}
+
+func TestDBTimeout_CancelsSlowRequests(t *testing.T) {
+	users := repositories.NewUserRepository(migratedDB(t))
+	app := setupFiber()
+	app.Use(middlewares.DBTimeout(20 * time.Millisecond))
+	app.Get("/users/:id", func(c *fiber.Ctx) error {
+		if c.Query("slow") != "" {
+			<-c.UserContext().Done()
+		}
+		_, err := users.FindByID(c.UserContext(), 1)
+		if err != nil && !errors.Is(err, repositories.ErrNotFound) {
+			return fmt.Errorf("failed to load user: %v", err)
+		}
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/users/1?slow=1", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	var problem controllers.Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+	assert.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, controllers.CodeDatabaseTimeout, problem.Code)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/users/1", nil))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode, "fast requests are not affected")
+}
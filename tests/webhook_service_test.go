@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"booking-api/models"
+	"booking-api/services"
+	"booking-api/utils"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockWebhookRepository struct {
+	mock.Mock
+}
+
+func (m *MockWebhookRepository) CreateSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return m.Called(ctx, subscription).Error(0)
+}
+
+func (m *MockWebhookRepository) FindSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.WebhookSubscription), args.Error(1)
+}
+
+func (m *MockWebhookRepository) FindSubscriptionByID(ctx context.Context, id uint) (*models.WebhookSubscription, error) {
+	args := m.Called(ctx, id)
+	subscription, _ := args.Get(0).(*models.WebhookSubscription)
+	return subscription, args.Error(1)
+}
+
+func (m *MockWebhookRepository) DeleteSubscription(ctx context.Context, subscription *models.WebhookSubscription) error {
+	return m.Called(ctx, subscription).Error(0)
+}
+
+func (m *MockWebhookRepository) FindPendingEvents(ctx context.Context, limit int) ([]models.OutboxEvent, error) {
+	args := m.Called(ctx, limit)
+	return args.Get(0).([]models.OutboxEvent), args.Error(1)
+}
+
+func (m *MockWebhookRepository) DispatchEvent(ctx context.Context, event *models.OutboxEvent, deliveries []models.WebhookDelivery) error {
+	return m.Called(ctx, event, deliveries).Error(0)
+}
+
+func (m *MockWebhookRepository) FindDueDeliveries(ctx context.Context, now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(ctx, now, limit)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) FindDeliveryByID(ctx context.Context, id uint) (*models.WebhookDelivery, error) {
+	args := m.Called(ctx, id)
+	delivery, _ := args.Get(0).(*models.WebhookDelivery)
+	return delivery, args.Error(1)
+}
+
+func (m *MockWebhookRepository) FindDeliveriesBySubscription(ctx context.Context, subscriptionID uint, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(ctx, subscriptionID, limit)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+
+func (m *MockWebhookRepository) UpdateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	return m.Called(ctx, delivery).Error(0)
+}
+
+func webhookSubscription(id uint, url, events string) models.WebhookSubscription {
+	subscription := models.WebhookSubscription{URL: url, Events: events, Secret: "s3cret"}
+	subscription.ID = id
+	return subscription
+}
+
+func TestWebhookService_ProcessOutbox_FiltersByEvent(t *testing.T) {
+	repo := new(MockWebhookRepository)
+	service := services.NewWebhookService(repo, services.WebhookSettings{})
+
+	repo.On("FindPendingEvents", mock.Anything, mock.Anything).Return([]models.OutboxEvent{
+		{ID: 5, Type: models.EventReservationCancelled, Payload: `{"ID":42}`},
+	}, nil)
+	repo.On("FindSubscriptions", mock.Anything).Return([]models.WebhookSubscription{
+		webhookSubscription(1, "https://catering.example.com", models.EventReservationCreated),
+		webhookSubscription(2, "https://doors.example.com", models.EventReservationCreated+","+models.EventReservationCancelled),
+		webhookSubscription(3, "https://all.example.com", ""),
+	}, nil)
+	repo.On("DispatchEvent", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	dispatched, err := service.ProcessOutbox(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	deliveries := repo.Calls[2].Arguments.Get(2).([]models.WebhookDelivery)
+	if assert.Len(t, deliveries, 2) {
+		assert.Equal(t, uint(2), deliveries[0].SubscriptionID)
+		assert.Equal(t, uint(3), deliveries[1].SubscriptionID)
+		assert.Equal(t, models.DeliveryPending, deliveries[0].Status)
+		assert.JSONEq(t, `{"id":5,"type":"reservation.cancelled","created_at":"0001-01-01T00:00:00Z","data":{"ID":42}}`, deliveries[0].Payload)
+	}
+}
+
+func TestWebhookService_DeliverDue_SignsPayload(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := new(MockWebhookRepository)
+	service := services.NewWebhookService(repo, services.WebhookSettings{AllowPrivateHosts: true})
+
+	subscription := webhookSubscription(2, server.URL, "")
+	delivery := models.WebhookDelivery{SubscriptionID: 2, EventType: models.EventReservationCreated, Payload: `{"id":5}`, Status: models.DeliveryPending}
+	delivery.ID = 9
+	repo.On("FindDueDeliveries", mock.Anything, mock.Anything, mock.Anything).Return([]models.WebhookDelivery{delivery}, nil)
+	repo.On("FindSubscriptionByID", mock.Anything, uint(2)).Return(&subscription, nil)
+	repo.On("UpdateDelivery", mock.Anything, mock.Anything).Return(nil)
+
+	attempted, err := service.DeliverDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, attempted)
+	timestamp, _ := strconv.ParseInt(received.Header.Get("X-Webhook-Timestamp"), 10, 64)
+	assert.Equal(t, utils.SignWebhook("s3cret", timestamp, body), received.Header.Get("X-Webhook-Signature"))
+	assert.Equal(t, models.EventReservationCreated, received.Header.Get("X-Webhook-Event"))
+	assert.Equal(t, "9", received.Header.Get("X-Webhook-Delivery"))
+
+	saved := repo.Calls[2].Arguments.Get(1).(*models.WebhookDelivery)
+	assert.Equal(t, models.DeliverySucceeded, saved.Status)
+	assert.Equal(t, http.StatusNoContent, saved.ResponseStatus)
+	assert.Nil(t, saved.NextAttemptAt)
+}
+
+func TestWebhookService_DeliverDue_RetriesWithBackoff(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	repo := new(MockWebhookRepository)
+	service := services.NewWebhookService(repo, services.WebhookSettings{AllowPrivateHosts: true})
+
+	subscription := webhookSubscription(2, server.URL, "")
+	delivery := models.WebhookDelivery{SubscriptionID: 2, Payload: `{}`, Status: models.DeliveryPending, Attempts: 2}
+	repo.On("FindDueDeliveries", mock.Anything, mock.Anything, mock.Anything).Return([]models.WebhookDelivery{delivery}, nil)
+	repo.On("FindSubscriptionByID", mock.Anything, uint(2)).Return(&subscription, nil)
+	repo.On("UpdateDelivery", mock.Anything, mock.Anything).Return(nil)
+
+	before := time.Now()
+	_, err := service.DeliverDue(context.Background())
+
+	assert.NoError(t, err)
+	saved := repo.Calls[2].Arguments.Get(1).(*models.WebhookDelivery)
+	assert.Equal(t, models.DeliveryPending, saved.Status)
+	assert.Equal(t, 3, saved.Attempts)
+	assert.Equal(t, "endpoint responded with status 503", saved.LastError)
+	if assert.NotNil(t, saved.NextAttemptAt) {
+		assert.WithinDuration(t, before.Add(2*time.Minute), *saved.NextAttemptAt, 5*time.Second)
+	}
+}
+
+func TestWebhookService_DeliverDue_RefusesPrivateHosts(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := new(MockWebhookRepository)
+	service := services.NewWebhookService(repo, services.WebhookSettings{})
+
+	subscription := webhookSubscription(2, server.URL, "")
+	delivery := models.WebhookDelivery{SubscriptionID: 2, Payload: `{}`, Status: models.DeliveryPending}
+	repo.On("FindDueDeliveries", mock.Anything, mock.Anything, mock.Anything).Return([]models.WebhookDelivery{delivery}, nil)
+	repo.On("FindSubscriptionByID", mock.Anything, uint(2)).Return(&subscription, nil)
+	repo.On("UpdateDelivery", mock.Anything, mock.Anything).Return(nil)
+
+	_, err := service.DeliverDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.False(t, called, "loopback addresses are refused")
+	saved := repo.Calls[2].Arguments.Get(1).(*models.WebhookDelivery)
+	assert.Equal(t, models.DeliveryPending, saved.Status)
+	assert.Contains(t, saved.LastError, "private and loopback addresses are not allowed")
+}
+
+func TestReservationService_CreateReservation_PublishesEvent(t *testing.T) {
+	f := newReservationServiceFixture()
+
+	f.resourceRepo.On("FindByID", mock.Anything, uint(1)).Return(roomWithBuffers(), nil)
+	f.ruleRepo.On("FindByResource", mock.Anything, uint(1)).Return([]models.BookingRule{}, nil)
+	f.repo.On("FindOverlapping", mock.Anything, uint(1), "2025-05-23", mock.Anything, mock.Anything).
+		Return([]models.Reservation{}, nil)
+	f.repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	err := f.service.CreateReservation(context.Background(), &models.Reservation{
+		UserID:     7,
+		ResourceID: 1,
+		Date:       "2025-05-23",
+		StartTime:  "10:00",
+		EndTime:    "11:00",
+	})
+
+	assert.NoError(t, err)
+	f.repo.AssertCalled(t, "CreateOutboxEvent", mock.Anything, mock.MatchedBy(func(e *models.OutboxEvent) bool {
+		var payload models.Reservation
+		return e.Type == models.EventReservationCreated &&
+			json.Unmarshal([]byte(e.Payload), &payload) == nil && payload.UserID == 7
+	}))
+}
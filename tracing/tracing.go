@@ -0,0 +1,116 @@
+// Package tracing sets up OpenTelemetry tracing. Requests are traced from the
+// HTTP middleware through the services down to every database query, and the
+// W3C trace context of incoming requests is continued. The rest of the code
+// starts spans through the functions of this package.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ServiceName identifies the API in the exported spans unless
+// OTEL_SERVICE_NAME says otherwise.
+const ServiceName = "booking-api"
+
+// Exporters accepted by Setup.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// tracer returns the tracer of the global provider. Without an exporter it
+// is a no-op one, so spans are simply not recorded.
+func tracer() trace.Tracer {
+	return otel.Tracer(ServiceName)
+}
+
+// propagator reads and writes the W3C traceparent, tracestate and baggage
+// headers. The trace context of a request is continued even when spans are not
+// exported, so the trace ID stays the same across services.
+var propagator = propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{})
+
+// Setup installs the W3C propagators globally and, unless exporter is "none",
+// a tracer provider sending spans to it. "stdout" writes one JSON document per
+// span; "otlp" sends them over HTTP and reads the standard
+// OTEL_EXPORTER_OTLP_* variables. The returned function flushes the pending
+// spans and must be called on shutdown.
+func Setup(ctx context.Context, exporter string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagator)
+
+	var spanExporter sdktrace.SpanExporter
+	var err error
+	switch exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s exporter: %v", exporter, err)
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(ServiceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe the service: %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Start starts an internal span as a child of the span in ctx.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartKind starts a span of the given kind as a child of the span in ctx.
+func StartKind(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer().Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+// End records *err on the span, if any, and ends it. It is meant to be
+// deferred with the address of a named error result.
+func End(span trace.Span, err *error) {
+	Fail(span, *err)
+	span.End()
+}
+
+// Fail records err on the span and marks it as failed. A nil err does nothing.
+func Fail(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// Extract returns ctx carrying the trace context and baggage found in headers.
+func Extract(ctx context.Context, headers propagation.TextMapCarrier) context.Context {
+	return propagator.Extract(ctx, headers)
+}
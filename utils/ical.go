@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ICalEvent is a VEVENT of an RFC 5545 calendar.
+type ICalEvent struct {
+	UID         string
+	Summary     string
+	Description string
+	Location    string
+	Start       time.Time
+	End         time.Time
+	Stamp       time.Time
+	Sequence    int
+	Status      string // CONFIRMED, TENTATIVE or CANCELLED
+}
+
+const icalTimeFormat = "20060102T150405Z"
+
+// BuildICalendar renders events as a VCALENDAR document with CRLF line endings
+// and folded lines, as required by RFC 5545.
+func BuildICalendar(name string, events []ICalEvent) string {
+	var b strings.Builder
+	line := func(content string) {
+		b.WriteString(foldICalLine(content))
+		b.WriteString("\r\n")
+	}
+
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//booking-api//Booking API//ES")
+	line("CALSCALE:GREGORIAN")
+	line("METHOD:PUBLISH")
+	if name != "" {
+		line("X-WR-CALNAME:" + escapeICalText(name))
+	}
+	for _, event := range events {
+		line("BEGIN:VEVENT")
+		line("UID:" + event.UID)
+		line("DTSTAMP:" + event.Stamp.UTC().Format(icalTimeFormat))
+		line("DTSTART:" + event.Start.UTC().Format(icalTimeFormat))
+		line("DTEND:" + event.End.UTC().Format(icalTimeFormat))
+		line("SEQUENCE:" + strconv.Itoa(event.Sequence))
+		line("STATUS:" + event.Status)
+		line("SUMMARY:" + escapeICalText(event.Summary))
+		if event.Description != "" {
+			line("DESCRIPTION:" + escapeICalText(event.Description))
+		}
+		if event.Location != "" {
+			line("LOCATION:" + escapeICalText(event.Location))
+		}
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+
+	return b.String()
+}
+
+func escapeICalText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
+
+// foldICalLine splits lines longer than 75 octets, without breaking UTF-8
+// sequences, continuing them with a leading space.
+func foldICalLine(content string) string {
+	const limit = 75
+	if len(content) <= limit {
+		return content
+	}
+
+	var b strings.Builder
+	width := 0
+	for _, r := range content {
+		size := len(string(r))
+		if width+size > limit {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	return b.String()
+}
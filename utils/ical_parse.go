@@ -0,0 +1,620 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ICalOccurrence is a single busy interval of an imported calendar.
+type ICalOccurrence struct {
+	UID     string
+	Summary string
+	Start   time.Time
+	End     time.Time
+}
+
+type icalProperty struct {
+	params map[string]string
+	value  string
+}
+
+type icalEvent map[string][]icalProperty
+
+func (e icalEvent) first(name string) (icalProperty, bool) {
+	props := e[name]
+	if len(props) == 0 {
+		return icalProperty{}, false
+	}
+	return props[0], true
+}
+
+// maxRecurrenceIterations bounds the expansion of a single RRULE.
+const maxRecurrenceIterations = 50000
+
+// ParseICalBusyTimes returns the busy occurrences of every VEVENT in data that
+// overlap [from, to). Recurring events (RRULE with FREQ, INTERVAL, COUNT,
+// UNTIL, WKST, BYDAY, BYMONTHDAY, BYMONTH and BYSETPOS) are expanded, EXDATE
+// instances removed and instances moved by a RECURRENCE-ID override replaced
+// by the override. Cancelled and transparent (free) events are skipped.
+// Floating times are read in loc.
+//
+// An event that cannot be read, such as one with a rule part not supported,
+// is left out and reported in skipped; err is only set when data is not a
+// calendar at all.
+func ParseICalBusyTimes(data string, from, to time.Time, loc *time.Location) (occurrences []ICalOccurrence, skipped []error, err error) {
+	events, err := parseICalEvents(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The instances of each recurring event replaced by an override, by UID.
+	overridden := map[string]map[int64]bool{}
+	var kept []icalEvent
+	for _, event := range events {
+		uid, _ := event.first("UID")
+		recurrenceID, ok := event.first("RECURRENCE-ID")
+		if !ok {
+			kept = append(kept, event)
+			continue
+		}
+		if value := recurrenceID.params["RANGE"]; value != "" {
+			skipped = append(skipped, fmt.Errorf("event %q: unsupported RECURRENCE-ID RANGE %q", uid.value, value))
+			continue
+		}
+		instance, _, err := parseICalTime(recurrenceID, loc)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("event %q: invalid RECURRENCE-ID: %v", uid.value, err))
+			continue
+		}
+		if overridden[uid.value] == nil {
+			overridden[uid.value] = map[int64]bool{}
+		}
+		overridden[uid.value][instance.Unix()] = true
+		kept = append(kept, event)
+	}
+
+	for _, event := range kept {
+		if status, ok := event.first("STATUS"); ok && strings.EqualFold(status.value, "CANCELLED") {
+			continue
+		}
+		if transp, ok := event.first("TRANSP"); ok && strings.EqualFold(transp.value, "TRANSPARENT") {
+			continue
+		}
+
+		uid, _ := event.first("UID")
+		var moved map[int64]bool
+		if _, override := event.first("RECURRENCE-ID"); !override {
+			moved = overridden[uid.value]
+		}
+		expanded, err := expandICalEvent(event, moved, from, to, loc)
+		if err != nil {
+			skipped = append(skipped, fmt.Errorf("event %q: %v", uid.value, err))
+			continue
+		}
+		occurrences = append(occurrences, expanded...)
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool { return occurrences[i].Start.Before(occurrences[j].Start) })
+	return occurrences, skipped, nil
+}
+
+func parseICalEvents(data string) ([]icalEvent, error) {
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	data = strings.ReplaceAll(data, "\n ", "")
+	data = strings.ReplaceAll(data, "\n\t", "")
+
+	var events []icalEvent
+	var current icalEvent
+	sawCalendar := false
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" {
+			continue
+		}
+		name, prop, ok := parseICalLine(line)
+		if !ok {
+			// The content is not echoed: it may come from a file that is
+			// not a calendar at all.
+			return nil, fmt.Errorf("malformed line %d", n+1)
+		}
+
+		switch {
+		case name == "BEGIN" && strings.EqualFold(prop.value, "VCALENDAR"):
+			sawCalendar = true
+		case name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			current = icalEvent{}
+		case name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if current != nil {
+				events = append(events, current)
+			}
+			current = nil
+		case current != nil:
+			current[name] = append(current[name], prop)
+		}
+	}
+
+	if !sawCalendar {
+		return nil, errors.New("not an iCalendar document")
+	}
+	return events, nil
+}
+
+func parseICalLine(line string) (string, icalProperty, bool) {
+	colon := -1
+	inQuotes := false
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		}
+		if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return "", icalProperty{}, false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := icalProperty{params: map[string]string{}, value: line[colon+1:]}
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return strings.ToUpper(parts[0]), prop, true
+}
+
+// parseICalTime reads a DATE or DATE-TIME value. allDay reports a DATE value.
+func parseICalTime(prop icalProperty, loc *time.Location) (t time.Time, allDay bool, err error) {
+	value := strings.TrimSpace(prop.value)
+	if prop.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err = time.ParseInLocation("20060102", value, loc)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err = time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if tz, err := time.LoadLocation(tzid); err == nil {
+			loc = tz
+		}
+	}
+	t, err = time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+// expandICalEvent returns the occurrences of event overlapping [from, to),
+// leaving out the instances starting at the times in excluded, as EXDATE does.
+func expandICalEvent(event icalEvent, excluded map[int64]bool, from, to time.Time, loc *time.Location) ([]ICalOccurrence, error) {
+	dtstart, ok := event.first("DTSTART")
+	if !ok {
+		return nil, errors.New("missing DTSTART")
+	}
+	start, allDay, err := parseICalTime(dtstart, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DTSTART: %v", err)
+	}
+
+	duration, err := eventDuration(event, start, allDay, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded = maps.Clone(excluded)
+	if excluded == nil {
+		excluded = map[int64]bool{}
+	}
+	for _, exdate := range event["EXDATE"] {
+		for _, value := range strings.Split(exdate.value, ",") {
+			t, _, err := parseICalTime(icalProperty{params: exdate.params, value: value}, loc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid EXDATE: %v", err)
+			}
+			excluded[t.Unix()] = true
+		}
+	}
+
+	uid, _ := event.first("UID")
+	summary, _ := event.first("SUMMARY")
+	occurrence := func(s time.Time) ICalOccurrence {
+		return ICalOccurrence{UID: uid.value, Summary: summary.value, Start: s, End: s.Add(duration)}
+	}
+
+	rrule, recurring := event.first("RRULE")
+	if !recurring {
+		if start.Before(to) && start.Add(duration).After(from) {
+			return []ICalOccurrence{occurrence(start)}, nil
+		}
+		return nil, nil
+	}
+
+	starts, err := expandRRule(rrule.value, start, to, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	var occurrences []ICalOccurrence
+	for _, s := range starts {
+		if excluded[s.Unix()] {
+			continue
+		}
+		if s.Before(to) && s.Add(duration).After(from) {
+			occurrences = append(occurrences, occurrence(s))
+		}
+	}
+	return occurrences, nil
+}
+
+func eventDuration(event icalEvent, start time.Time, allDay bool, loc *time.Location) (time.Duration, error) {
+	if dtend, ok := event.first("DTEND"); ok {
+		end, _, err := parseICalTime(dtend, loc)
+		if err != nil {
+			return 0, fmt.Errorf("invalid DTEND: %v", err)
+		}
+		if end.Before(start) {
+			return 0, errors.New("DTEND is before DTSTART")
+		}
+		return end.Sub(start), nil
+	}
+	if prop, ok := event.first("DURATION"); ok {
+		return parseICalDuration(prop.value)
+	}
+	if allDay {
+		return 24 * time.Hour, nil
+	}
+	return 0, nil
+}
+
+// parseICalDuration reads RFC 5545 durations such as PT1H30M, P1D or P1W.
+func parseICalDuration(value string) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.TrimPrefix(value, "+"), "P")
+	var total time.Duration
+	number := ""
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			number += string(r)
+		case r == 'T':
+		default:
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				return 0, fmt.Errorf("invalid DURATION %q", value)
+			}
+			number = ""
+			switch r {
+			case 'W':
+				total += time.Duration(n) * 7 * 24 * time.Hour
+			case 'D':
+				total += time.Duration(n) * 24 * time.Hour
+			case 'H':
+				total += time.Duration(n) * time.Hour
+			case 'M':
+				total += time.Duration(n) * time.Minute
+			case 'S':
+				total += time.Duration(n) * time.Second
+			default:
+				return 0, fmt.Errorf("invalid DURATION %q", value)
+			}
+		}
+	}
+	return total, nil
+}
+
+var icalWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// icalWeekdayNum is a BYDAY entry: a weekday and, when N is not zero, which
+// one of the month, counted from the end when negative (1MO, -1FR).
+type icalWeekdayNum struct {
+	N       int
+	Weekday time.Weekday
+}
+
+// icalRule is a parsed RRULE.
+type icalRule struct {
+	freq       string
+	interval   int
+	count      int // -1 without COUNT
+	until      time.Time
+	hasUntil   bool
+	weekStart  time.Weekday
+	byDay      []icalWeekdayNum
+	byMonthDay []int
+	byMonth    []time.Month
+	bySetPos   []int
+}
+
+// parseRRule reads a rule, refusing the parts it cannot expand so that an
+// event is never blocked on the wrong days.
+func parseRRule(rule string, loc *time.Location) (*icalRule, error) {
+	r := &icalRule{interval: 1, count: -1, weekStart: time.Monday}
+	parts := map[string]string{}
+	for _, part := range strings.Split(rule, ";") {
+		key, value, _ := strings.Cut(part, "=")
+		parts[strings.ToUpper(key)] = value
+	}
+
+	r.freq = strings.ToUpper(parts["FREQ"])
+	switch r.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return nil, fmt.Errorf("unsupported FREQ %q", parts["FREQ"])
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(parts)) {
+		value := parts[key]
+		var err error
+		switch key {
+		case "FREQ":
+		case "INTERVAL":
+			r.interval, err = strconv.Atoi(value)
+			if err != nil || r.interval <= 0 {
+				return nil, fmt.Errorf("invalid INTERVAL %q", value)
+			}
+		case "COUNT":
+			r.count, err = strconv.Atoi(value)
+			if err != nil || r.count <= 0 {
+				return nil, fmt.Errorf("invalid COUNT %q", value)
+			}
+		case "UNTIL":
+			r.until, _, err = parseICalTime(icalProperty{params: map[string]string{}, value: value}, loc)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL %q", value)
+			}
+			if len(value) == 8 {
+				r.until = r.until.Add(24*time.Hour - time.Second)
+			}
+			r.hasUntil = true
+		case "WKST":
+			weekday, ok := icalWeekdays[strings.ToUpper(value)]
+			if !ok {
+				return nil, fmt.Errorf("invalid WKST %q", value)
+			}
+			r.weekStart = weekday
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				entry, err := parseICalWeekdayNum(day)
+				if err != nil {
+					return nil, err
+				}
+				// Ordinals count within the month: yearly ones without
+				// BYMONTH would count within the year.
+				if entry.N != 0 && (r.freq == "DAILY" || r.freq == "WEEKLY" || (r.freq == "YEARLY" && parts["BYMONTH"] == "")) {
+					return nil, fmt.Errorf("unsupported BYDAY %q with FREQ=%s", day, r.freq)
+				}
+				r.byDay = append(r.byDay, entry)
+			}
+		case "BYMONTHDAY":
+			if r.freq == "WEEKLY" {
+				return nil, errors.New("BYMONTHDAY is not allowed with FREQ=WEEKLY")
+			}
+			r.byMonthDay, err = parseICalNumbers(key, value, 31)
+			if err != nil {
+				return nil, err
+			}
+		case "BYMONTH":
+			months, err := parseICalNumbers(key, value, 12)
+			if err != nil {
+				return nil, err
+			}
+			for _, month := range months {
+				if month < 0 {
+					return nil, fmt.Errorf("invalid BYMONTH %q", value)
+				}
+				r.byMonth = append(r.byMonth, time.Month(month))
+			}
+		case "BYSETPOS":
+			r.bySetPos, err = parseICalNumbers(key, value, 366)
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unsupported %s", key)
+		}
+	}
+	return r, nil
+}
+
+// parseICalWeekdayNum reads a BYDAY entry such as MO, 2TU or -1FR.
+func parseICalWeekdayNum(value string) (icalWeekdayNum, error) {
+	value = strings.ToUpper(strings.TrimSpace(value))
+	if len(value) < 2 {
+		return icalWeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+	weekday, ok := icalWeekdays[value[len(value)-2:]]
+	if !ok {
+		return icalWeekdayNum{}, fmt.Errorf("invalid BYDAY %q", value)
+	}
+	entry := icalWeekdayNum{Weekday: weekday}
+	if ordinal := value[:len(value)-2]; ordinal != "" {
+		n, err := strconv.Atoi(ordinal)
+		if err != nil || n == 0 || n < -5 || n > 5 {
+			return icalWeekdayNum{}, fmt.Errorf("unsupported BYDAY %q", value)
+		}
+		entry.N = n
+	}
+	return entry, nil
+}
+
+// parseICalNumbers reads a list of non-zero numbers between -limit and limit.
+func parseICalNumbers(key, value string, limit int) ([]int, error) {
+	var numbers []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(strings.TrimPrefix(item, "+"))
+		if err != nil || n == 0 || n < -limit || n > limit {
+			return nil, fmt.Errorf("invalid %s %q", key, value)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+// expandRRule returns the start of every occurrence of the rule beginning at
+// or before `to`. COUNT includes occurrences later removed by EXDATE, as the
+// RFC requires.
+func expandRRule(rule string, start, to time.Time, loc *time.Location) ([]time.Time, error) {
+	r, err := parseRRule(rule, loc)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := to
+	if r.hasUntil && r.until.Before(limit) {
+		limit = r.until
+	}
+
+	var starts []time.Time
+	for i := 0; i < maxRecurrenceIterations; i++ {
+		period, candidates := r.period(start, i)
+		if period.After(limit) {
+			break
+		}
+		for _, t := range r.setPositions(candidates) {
+			if t.Before(start) {
+				continue
+			}
+			if t.After(limit) || (r.count >= 0 && len(starts) >= r.count) {
+				return starts, nil
+			}
+			starts = append(starts, t)
+		}
+	}
+	return starts, nil
+}
+
+// period returns the first day of the i-th period of the rule and, in order,
+// the days in it matching the BY* parts, at the time of day of start.
+func (r *icalRule) period(start time.Time, i int) (time.Time, []time.Time) {
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+	}
+
+	var first time.Time
+	var candidates []time.Time
+	switch r.freq {
+	case "DAILY":
+		first = start.AddDate(0, 0, i*r.interval)
+		if r.matchesMonth(first) && r.matchesMonthDay(first) && r.matchesWeekday(first) {
+			candidates = append(candidates, first)
+		}
+	case "WEEKLY":
+		weekStart := start.AddDate(0, 0, -((int(start.Weekday()) - int(r.weekStart) + 7) % 7))
+		first = weekStart.AddDate(0, 0, 7*i*r.interval)
+		for d := 0; d < 7; d++ {
+			t := first.AddDate(0, 0, d)
+			matches := t.Weekday() == start.Weekday()
+			if len(r.byDay) > 0 {
+				matches = r.matchesWeekday(t)
+			}
+			if matches && r.matchesMonth(t) {
+				candidates = append(candidates, t)
+			}
+		}
+	case "MONTHLY":
+		first = day(start.Year(), start.Month()+time.Month(i*r.interval), 1)
+		if r.matchesMonth(first) {
+			candidates = r.monthDays(first, start)
+		}
+	case "YEARLY":
+		year := start.Year() + i*r.interval
+		first = day(year, time.January, 1)
+		months := r.byMonth
+		if len(months) == 0 && (len(r.byDay) > 0 || len(r.byMonthDay) > 0) {
+			months = []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		} else if len(months) == 0 {
+			months = []time.Month{start.Month()}
+		}
+		months = slices.Sorted(slices.Values(months))
+		for _, month := range slices.Compact(months) {
+			candidates = append(candidates, r.monthDays(day(year, month, 1), start)...)
+		}
+	}
+	return first, candidates
+}
+
+// monthDays returns the days of the month starting at first that match
+// BYMONTHDAY and BYDAY, or the day of the month of start when neither is set.
+func (r *icalRule) monthDays(first, start time.Time) []time.Time {
+	days := first.AddDate(0, 1, -first.Day()).Day()
+	if len(r.byMonthDay) == 0 && len(r.byDay) == 0 {
+		if start.Day() > days {
+			return nil
+		}
+		return []time.Time{first.AddDate(0, 0, start.Day()-1)}
+	}
+
+	var matching []time.Time
+	for d := 1; d <= days; d++ {
+		t := first.AddDate(0, 0, d-1)
+		if r.matchesMonthDay(t) && r.matchesWeekday(t) {
+			matching = append(matching, t)
+		}
+	}
+	return matching
+}
+
+func (r *icalRule) matchesMonth(t time.Time) bool {
+	return len(r.byMonth) == 0 || slices.Contains(r.byMonth, t.Month())
+}
+
+func (r *icalRule) matchesMonthDay(t time.Time) bool {
+	if len(r.byMonthDay) == 0 {
+		return true
+	}
+	days := t.AddDate(0, 1, -t.Day()).Day()
+	for _, d := range r.byMonthDay {
+		if d == t.Day() || days+d+1 == t.Day() {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesWeekday reports whether t is one of the BYDAY entries, ordinals
+// counted within its month.
+func (r *icalRule) matchesWeekday(t time.Time) bool {
+	if len(r.byDay) == 0 {
+		return true
+	}
+	days := t.AddDate(0, 1, -t.Day()).Day()
+	for _, entry := range r.byDay {
+		if entry.Weekday != t.Weekday() {
+			continue
+		}
+		if entry.N == 0 || entry.N == (t.Day()-1)/7+1 || entry.N == -((days-t.Day())/7+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// setPositions keeps the candidates of a period at the BYSETPOS positions.
+func (r *icalRule) setPositions(candidates []time.Time) []time.Time {
+	if len(r.bySetPos) == 0 {
+		return candidates
+	}
+	var kept []time.Time
+	for i, t := range candidates {
+		for _, pos := range r.bySetPos {
+			if pos == i+1 || pos == i-len(candidates) {
+				kept = append(kept, t)
+				break
+			}
+		}
+	}
+	return kept
+}
@@ -6,18 +6,14 @@ import (
 	"github.com/golang-jwt/jwt/v4"
 )
 
-var jwtKey []byte
-
-func SetJWTSecret(secret string) {
-	jwtKey = []byte(secret)
-}
-
-func GenerateJWT(userID uint) (string, error) {
+// GenerateJWT signs a token for the user with secret, valid for ttl.
+func GenerateJWT(secret string, ttl time.Duration, userID uint, role string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+		"role":    role,
+		"exp":     time.Now().Add(ttl).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+	return token.SignedString([]byte(secret))
 }